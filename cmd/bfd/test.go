@@ -0,0 +1,250 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package bfd
+
+import (
+	"encoding/json"
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"loxicmd/pkg/probe"
+	"net"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type TestOptions struct {
+	Instance     string
+	Count        int
+	ProbeTimeout time.Duration
+}
+
+// BFDRoundTrip - round trip of ICMP echo from this host to the remote
+type BFDRoundTrip struct {
+	Sent     int           `json:"sent"`
+	Received int           `json:"received"`
+	Min      time.Duration `json:"min"`
+	Avg      time.Duration `json:"avg"`
+	Max      time.Duration `json:"max"`
+	// Detail - why the round trip is not measured
+	Detail string `json:"detail,omitempty"`
+}
+
+type BFDTestResult struct {
+	Session   api.BFDSessionInfo `json:"session"`
+	RoundTrip BFDRoundTrip       `json:"roundTrip"`
+	BFDPort   probe.Result       `json:"bfdPort"`
+	Diagnosis []string           `json:"diagnosis"`
+}
+
+func NewTestCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := TestOptions{}
+
+	var testCmd = &cobra.Command{
+		Use:   "test <remoteIP> [--instance=<instance>] [--count=<count>] [--probe-timeout=<duration>]",
+		Short: "Measure the round trip to the remote and explain the session state",
+		Long: `Send ICMP echo and an empty UDP datagram to the BFD port of the remote of a session
+and explain the state of the session with the results and the timers of the session.
+The probes are sent from this host, which should be the host of the LoxiLB for the best results.
+
+ex) loxicmd bfd test 192.168.10.2
+    loxicmd bfd test 192.168.10.2 --instance=default --count=10
+`,
+		Args: cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if net.ParseIP(args[0]) == nil {
+				fmt.Printf("Error: remote IP '%s' is invalid format\n", args[0])
+				return
+			}
+			sessions, err := get.GetBFDSessions(restOptions)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			session, ok := findBFDSession(sessions, args[0], o.Instance)
+			if !ok {
+				fmt.Printf("Error: BFD session to '%s' is not found\n", args[0])
+				return
+			}
+			if o.Count < 1 {
+				fmt.Printf("Error: count should be 1 or more\n")
+				return
+			}
+			PrintBFDTestResult(TestBFDSession(session, o), *restOptions)
+		},
+	}
+
+	testCmd.Flags().StringVarP(&o.Instance, "instance", "", "", "Cluster instance of the session")
+	testCmd.Flags().IntVarP(&o.Count, "count", "c", 5, "Number of ICMP echo")
+	testCmd.Flags().DurationVarP(&o.ProbeTimeout, "probe-timeout", "", time.Second, "Timeout of each probe")
+
+	return testCmd
+}
+
+func findBFDSession(sessions api.BFDSessionGet, remoteIP, instance string) (api.BFDSessionInfo, bool) {
+	for _, bfd := range sessions.BFDSessionAttr {
+		if !net.ParseIP(bfd.RemoteIP).Equal(net.ParseIP(remoteIP)) {
+			continue
+		}
+		if instance == "" || bfd.Instance == instance {
+			return bfd, true
+		}
+	}
+	return api.BFDSessionInfo{}, false
+}
+
+// TestBFDSession probes the remote of the session and explains its state
+func TestBFDSession(session api.BFDSessionInfo, o TestOptions) BFDTestResult {
+	result := BFDTestResult{Session: session}
+
+	rtt := &result.RoundTrip
+	var sum time.Duration
+	for i := 0; i < o.Count; i++ {
+		if i > 0 {
+			time.Sleep(200 * time.Millisecond)
+		}
+		r := probe.Probe(probe.Target{IP: session.RemoteIP, Protocol: "icmp"}, o.ProbeTimeout)
+		if r.Result == probe.Unknown {
+			// ICMP is not permitted on this host
+			rtt.Sent, rtt.Detail = 0, r.Detail
+			break
+		}
+		rtt.Sent++
+		if r.Result != probe.OK {
+			rtt.Detail = r.Detail
+			continue
+		}
+		rtt.Received++
+		sum += r.Latency
+		if rtt.Min == 0 || r.Latency < rtt.Min {
+			rtt.Min = r.Latency
+		}
+		if r.Latency > rtt.Max {
+			rtt.Max = r.Latency
+		}
+	}
+	if rtt.Received > 0 {
+		rtt.Avg = sum / time.Duration(rtt.Received)
+		rtt.Detail = ""
+	}
+
+	port := session.Port
+	if port == 0 {
+		port = api.BFDDefaultPort
+	}
+	result.BFDPort = probe.Probe(probe.Target{IP: session.RemoteIP, Port: port, Protocol: "udp"}, o.ProbeTimeout)
+	result.Diagnosis = DiagnoseBFDSession(session, result.RoundTrip, result.BFDPort)
+	return result
+}
+
+// DiagnoseBFDSession explains the state of the session with the probe results
+func DiagnoseBFDSession(session api.BFDSessionInfo, rtt BFDRoundTrip, bfdPort probe.Result) []string {
+	var notes []string
+	detect := session.DetectTime()
+	source := session.SourceIP
+	if source == "" {
+		source = "the source IP of the LoxiLB"
+	}
+
+	switch session.State {
+	case api.BFDStateUp:
+		notes = append(notes, "The session is up.")
+	case api.BFDStateAdminDown:
+		notes = append(notes, "The session is administratively down on the LoxiLB or on the remote. It is not a network problem.")
+	case api.BFDStateInit:
+		notes = append(notes, fmt.Sprintf("The LoxiLB receives BFD packets from %s but the remote does not see the packets from %s yet (one-way).", session.RemoteIP, source))
+	default:
+		notes = append(notes, fmt.Sprintf("The LoxiLB receives no BFD packets from %s.", session.RemoteIP))
+	}
+
+	switch {
+	case rtt.Sent == 0:
+		notes = append(notes, fmt.Sprintf("The round trip is not measured: %s.", rtt.Detail))
+	case rtt.Received == 0:
+		notes = append(notes, fmt.Sprintf("%s does not answer ICMP echo (%s). Check the route and the link to the remote, or ICMP is filtered.", session.RemoteIP, rtt.Detail))
+	case rtt.Received < rtt.Sent:
+		notes = append(notes, fmt.Sprintf("%d%% of ICMP echo is lost. BFD packets are likely lost too and %d lost packets in a row bring the session down.",
+			(rtt.Sent-rtt.Received)*100/rtt.Sent, session.RetryCount))
+	}
+	if rtt.Received > 0 && detect > 0 {
+		if rtt.Max >= detect {
+			notes = append(notes, fmt.Sprintf("The max round trip %s exceeds the detect time %s. Raise --interval or --multiplier.", rtt.Max, detect))
+		} else if rtt.Max*2 >= detect {
+			notes = append(notes, fmt.Sprintf("The max round trip %s is more than half of the detect time %s. The session may flap.", rtt.Max, detect))
+		}
+	}
+
+	if bfdPort.Result == probe.Fail {
+		notes = append(notes, fmt.Sprintf("UDP port %d of the remote is not open (%s). BFD is not running on the remote or it has no session to %s.",
+			sessionPort(session), bfdPort.Detail, source))
+	} else if session.State != api.BFDStateUp && rtt.Received > 0 {
+		notes = append(notes, fmt.Sprintf("The remote is reachable. Check that the remote has a session to %s and that UDP port %d is allowed on the path.", source, sessionPort(session)))
+	}
+	if session.SourceIP == "" && session.State != api.BFDStateUp {
+		notes = append(notes, "The session has no source IP. When the remote expects a specific peer address, create the session with --sourceIP.")
+	}
+	return notes
+}
+
+func sessionPort(session api.BFDSessionInfo) uint16 {
+	if session.Port == 0 {
+		return api.BFDDefaultPort
+	}
+	return session.Port
+}
+
+func PrintBFDTestResult(result BFDTestResult, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(result, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	s := result.Session
+	source := s.SourceIP
+	if source == "" {
+		source = "-"
+	}
+	fmt.Printf("Session    : %s (source %s, port %d)\n", s.Key(), source, sessionPort(s))
+	fmt.Printf("State      : %s\n", s.State)
+	fmt.Printf("Timers     : interval %s, min-rx %s, multiplier %d, detect time %s\n",
+		api.FormatBFDInterval(s.Interval), api.FormatBFDInterval(s.MinRxInterval), s.RetryCount, s.DetectTime())
+	rtt := result.RoundTrip
+	if rtt.Received > 0 {
+		fmt.Printf("Round trip : min/avg/max %s/%s/%s, %d/%d received\n", rtt.Min, rtt.Avg, rtt.Max, rtt.Received, rtt.Sent)
+	} else if rtt.Sent > 0 {
+		fmt.Printf("Round trip : 0/%d received (%s)\n", rtt.Sent, rtt.Detail)
+	} else {
+		fmt.Printf("Round trip : - (%s)\n", rtt.Detail)
+	}
+	port := result.BFDPort.Result
+	if result.BFDPort.Detail != "" {
+		port += " (" + result.BFDPort.Detail + ")"
+	}
+	fmt.Printf("BFD port   : udp %d %s\n", sessionPort(s), port)
+	fmt.Println("Diagnosis  :")
+	for _, n := range result.Diagnosis {
+		fmt.Printf("  - %s\n", n)
+	}
+}
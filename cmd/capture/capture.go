@@ -0,0 +1,283 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package capture
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"loxicmd/cmd/create"
+	"loxicmd/pkg/api"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/vishvananda/netlink"
+)
+
+type CaptureOptions struct {
+	Port     string
+	Lb       string
+	Duration time.Duration
+	ToPort   string
+	Filter   string
+	File     string
+	Count    int
+	Snaplen  int
+	Ident    string
+}
+
+const defaultCapturePort = "loxicap0"
+
+func CaptureCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := CaptureOptions{}
+
+	var captureCmd = &cobra.Command{
+		Use:   "capture --port=<port> | --lb=<lb name> [--duration=30s] [--to-port=<tap>] [--filter=<bpf filter>] [-w <file>]",
+		Short: "Capture packets of a port or a LB rule",
+		Long: `Capture packets of a port or a LB rule into a pcap file.
+A temporary SPAN mirror toward a local interface (--to-port) is created in the LoxiLB
+and the packets arriving on that interface are written to the pcap file.
+When --to-port is not given, a temporary tap interface "loxicap0" is created.
+The mirror (and the tap interface) are always removed on exit or Ctrl-C.
+
+--filter takes a tcpdump filter expression and needs tcpdump to compile it.
+--duration 0 captures until Ctrl-C or --count packets.
+
+ex) loxicmd capture --port hs1 --duration 30s
+    loxicmd capture --lb k8s-web --filter "tcp port 80" -w web.pcap
+    loxicmd capture --port hs1 --to-port tap0 --count 100
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if (o.Port == "") == (o.Lb == "") {
+				fmt.Printf("Error: one of --port or --lb is needed\n")
+				return
+			}
+			if o.Snaplen <= 0 || o.Snaplen > 65535 {
+				fmt.Printf("Error: snaplen must be between 1 and 65535\n")
+				return
+			}
+			if o.File == "" {
+				o.File = fmt.Sprintf("capture_%s.pcap", time.Now().Format("20060102_150405"))
+			}
+			if o.Ident == "" {
+				o.Ident = fmt.Sprintf("mirr-cap-%d", os.Getpid())
+			}
+			if err := RunCapture(restOptions, o); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+			}
+		},
+	}
+
+	captureCmd.Flags().StringVarP(&o.Port, "port", "", "", "Port to capture")
+	captureCmd.Flags().StringVarP(&o.Lb, "lb", "", "", "Name of the LB rule to capture")
+	captureCmd.Flags().DurationVarP(&o.Duration, "duration", "", 30*time.Second, "Capture duration (0 captures until Ctrl-C)")
+	captureCmd.Flags().StringVarP(&o.ToPort, "to-port", "", "", "Local interface receiving the mirrored traffic (default: temporary tap \"loxicap0\")")
+	captureCmd.Flags().StringVarP(&o.Filter, "filter", "", "", "tcpdump filter expression")
+	captureCmd.Flags().StringVarP(&o.File, "write", "w", "", "pcap file to write (default: capture_<time>.pcap)")
+	captureCmd.Flags().IntVarP(&o.Count, "count", "c", 0, "Stop after this number of packets (0 is unlimited)")
+	captureCmd.Flags().IntVarP(&o.Snaplen, "snaplen", "", 65535, "Bytes to capture of each packet")
+	captureCmd.Flags().StringVarP(&o.Ident, "mirror-name", "", "", "Ident of the temporary mirror (default: mirr-cap-<pid>)")
+	captureCmd.MarkFlagsMutuallyExclusive("port", "lb")
+
+	return captureCmd
+}
+
+// RunCapture sets up the mirror and the capture interface, captures packets
+// and removes everything it created before returning.
+func RunCapture(restOptions *api.RESTOptions, o CaptureOptions) error {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	toPort := o.ToPort
+	if toPort == "" {
+		toPort = defaultCapturePort
+	}
+
+	// Mirror
+	var err error
+	mirr := api.MirrMod{
+		Ident: o.Ident,
+		Info:  api.MirrInfo{MirrType: api.MirrTypeSpan, MirrPort: toPort},
+	}
+	if o.Port != "" {
+		mirr.Target = api.MirrObj{MirrObjName: o.Port, AttachMent: api.MirrAttachPort}
+	} else {
+		mirr.Target, err = create.LbRuleMirrorTarget(restOptions, o.Lb)
+		if err != nil {
+			return err
+		}
+	}
+	if err := mirr.Validation(); err != nil {
+		return err
+	}
+
+	// Capture interface
+	if _, err := net.InterfaceByName(toPort); err != nil {
+		if o.ToPort != "" {
+			return fmt.Errorf("capture interface %s: %s", toPort, err.Error())
+		}
+		tap, err := createTap(toPort)
+		if err != nil {
+			return err
+		}
+		defer deleteTap(tap)
+	}
+
+	filter, err := CompileFilter(toPort, o.Filter)
+	if err != nil {
+		return err
+	}
+	sock, err := OpenPacketSocket(toPort, o.Snaplen, filter)
+	if err != nil {
+		return err
+	}
+	defer sock.Close()
+
+	f, err := os.Create(o.File)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	pw, err := NewPcapWriter(f, uint32(o.Snaplen))
+	if err != nil {
+		return err
+	}
+
+	if err := createMirror(restOptions, mirr); err != nil {
+		return err
+	}
+	defer func() {
+		if err := deleteMirror(restOptions, mirr.Ident); err != nil {
+			fmt.Printf("Error: Failed to delete mirror %s, delete it with \"loxicmd delete mirror %s\": %s\n", mirr.Ident, mirr.Ident, err.Error())
+			return
+		}
+		fmt.Printf("Mirror %s deleted\n", mirr.Ident)
+	}()
+
+	fmt.Printf("Capturing on %s through mirror %s into %s", toPort, mirr.Ident, o.File)
+	if o.Duration > 0 {
+		fmt.Printf(" for %s", o.Duration)
+	}
+	fmt.Printf(" (Ctrl-C to stop)\n")
+
+	var deadline <-chan time.Time
+	if o.Duration > 0 {
+		timer := time.NewTimer(o.Duration)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var count int
+	var captureErr error
+loop:
+	for o.Count == 0 || count < o.Count {
+		select {
+		case <-sigCh:
+			break loop
+		case <-deadline:
+			break loop
+		default:
+		}
+		data, origLen, err := sock.ReadPacket()
+		if err != nil {
+			captureErr = err
+			break
+		}
+		if data == nil {
+			continue
+		}
+		if err := pw.WritePacket(time.Now(), data, origLen); err != nil {
+			captureErr = err
+			break
+		}
+		count++
+	}
+	if err := pw.Flush(); err != nil && captureErr == nil {
+		captureErr = err
+	}
+	fmt.Printf("%d packets captured\n", count)
+	return captureErr
+}
+
+func createTap(name string) (*netlink.Tuntap, error) {
+	// NonPersist keeps the tap only as long as its queue is open so that it
+	// disappears even when loxicmd is killed.
+	tap := &netlink.Tuntap{
+		LinkAttrs:  netlink.LinkAttrs{Name: name},
+		Mode:       netlink.TUNTAP_MODE_TAP,
+		Flags:      netlink.TUNTAP_NO_PI,
+		Queues:     1,
+		NonPersist: true,
+	}
+	if err := netlink.LinkAdd(tap); err != nil {
+		return nil, fmt.Errorf("failed to create capture interface %s: %s", name, err.Error())
+	}
+	if err := netlink.LinkSetUp(tap); err != nil {
+		deleteTap(tap)
+		return nil, fmt.Errorf("failed to set capture interface %s up: %s", name, err.Error())
+	}
+	return tap, nil
+}
+
+func deleteTap(tap *netlink.Tuntap) {
+	for _, fd := range tap.Fds {
+		fd.Close()
+	}
+}
+
+func createMirror(restOptions *api.RESTOptions, mirr api.MirrMod) error {
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Mirror().Create(ctx, mirr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to create mirror %s: status %d", mirr.Ident, resp.StatusCode)
+	}
+	return nil
+}
+
+func deleteMirror(restOptions *api.RESTOptions, ident string) error {
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Mirror().SubResources([]string{"ident", ident}).Delete(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(resp.Status)
+	}
+	return nil
+}
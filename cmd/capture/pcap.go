@@ -0,0 +1,174 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package capture
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	pcapMagic          = 0xa1b2c3d4
+	pcapLinkTypeEther  = 1
+	pcapVersionMajor   = 2
+	pcapVersionMinor   = 4
+	captureReadTimeout = 200 * time.Millisecond
+)
+
+// PcapWriter writes packets in the libpcap file format.
+type PcapWriter struct {
+	w *bufio.Writer
+}
+
+func NewPcapWriter(w io.Writer, snaplen uint32) (*PcapWriter, error) {
+	pw := &PcapWriter{w: bufio.NewWriter(w)}
+	hdr := make([]byte, 24)
+	binary.LittleEndian.PutUint32(hdr[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(hdr[4:], pcapVersionMajor)
+	binary.LittleEndian.PutUint16(hdr[6:], pcapVersionMinor)
+	binary.LittleEndian.PutUint32(hdr[16:], snaplen)
+	binary.LittleEndian.PutUint32(hdr[20:], pcapLinkTypeEther)
+	if _, err := pw.w.Write(hdr); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// WritePacket writes one packet. origLen is the length on the wire.
+func (pw *PcapWriter) WritePacket(ts time.Time, data []byte, origLen int) error {
+	hdr := make([]byte, 16)
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(ts.Unix()))
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(ts.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(hdr[8:], uint32(len(data)))
+	binary.LittleEndian.PutUint32(hdr[12:], uint32(origLen))
+	if _, err := pw.w.Write(hdr); err != nil {
+		return err
+	}
+	_, err := pw.w.Write(data)
+	return err
+}
+
+func (pw *PcapWriter) Flush() error {
+	return pw.w.Flush()
+}
+
+// PacketSocket is an AF_PACKET socket bound to one interface.
+type PacketSocket struct {
+	fd      int
+	snaplen int
+	buf     []byte
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+// OpenPacketSocket opens a raw socket on ifName. The filter, if any, is
+// attached before the socket is bound so that no unfiltered packet is queued.
+func OpenPacketSocket(ifName string, snaplen int, filter []unix.SockFilter) (*PacketSocket, error) {
+	ifi, err := net.InterfaceByName(ifName)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := unix.Socket(unix.AF_PACKET, unix.SOCK_RAW, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open packet socket: %s", err.Error())
+	}
+	if len(filter) > 0 {
+		prog := unix.SockFprog{Len: uint16(len(filter)), Filter: &filter[0]}
+		if err := unix.SetsockoptSockFprog(fd, unix.SOL_SOCKET, unix.SO_ATTACH_FILTER, &prog); err != nil {
+			unix.Close(fd)
+			return nil, fmt.Errorf("failed to attach filter: %s", err.Error())
+		}
+	}
+	tv := unix.NsecToTimeval(captureReadTimeout.Nanoseconds())
+	if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, &tv); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	sa := &unix.SockaddrLinklayer{Protocol: htons(unix.ETH_P_ALL), Ifindex: ifi.Index}
+	if err := unix.Bind(fd, sa); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("failed to bind packet socket to %s: %s", ifName, err.Error())
+	}
+	return &PacketSocket{fd: fd, snaplen: snaplen, buf: make([]byte, 65536)}, nil
+}
+
+// ReadPacket returns the next packet truncated to the snaplen and its length
+// on the wire. It returns a nil packet when the read timed out.
+func (s *PacketSocket) ReadPacket() ([]byte, int, error) {
+	n, _, err := unix.Recvfrom(s.fd, s.buf, unix.MSG_TRUNC)
+	if err == unix.EAGAIN || err == unix.EINTR {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	capLen := n
+	if capLen > len(s.buf) {
+		capLen = len(s.buf)
+	}
+	if capLen > s.snaplen {
+		capLen = s.snaplen
+	}
+	return s.buf[:capLen], n, nil
+}
+
+func (s *PacketSocket) Close() error {
+	return unix.Close(s.fd)
+}
+
+var bpfInsnRe = regexp.MustCompile(`\{\s*(0x[0-9a-fA-F]+),\s*(\d+),\s*(\d+),\s*(0x[0-9a-fA-F]+)\s*\}`)
+
+// CompileFilter compiles a tcpdump filter expression into classic BPF for
+// the interface with "tcpdump -dd".
+func CompileFilter(ifName, expr string) ([]unix.SockFilter, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	path, err := exec.LookPath("tcpdump")
+	if err != nil {
+		return nil, fmt.Errorf("tcpdump is needed to compile the filter '%s'", expr)
+	}
+	out, err := exec.Command(path, "-i", ifName, "-dd", expr).Output()
+	if err != nil {
+		if ee, ok := err.(*exec.ExitError); ok {
+			return nil, fmt.Errorf("invalid filter '%s': %s", expr, string(ee.Stderr))
+		}
+		return nil, err
+	}
+	var prog []unix.SockFilter
+	for _, m := range bpfInsnRe.FindAllStringSubmatch(string(out), -1) {
+		code, _ := strconv.ParseUint(m[1], 0, 16)
+		jt, _ := strconv.ParseUint(m[2], 10, 8)
+		jf, _ := strconv.ParseUint(m[3], 10, 8)
+		k, _ := strconv.ParseUint(m[4], 0, 32)
+		prog = append(prog, unix.SockFilter{Code: uint16(code), Jt: uint8(jt), Jf: uint8(jf), K: uint32(k)})
+	}
+	if len(prog) == 0 {
+		return nil, fmt.Errorf("failed to compile the filter '%s'", expr)
+	}
+	return prog, nil
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package check
+
+import (
+	"fmt"
+	"loxicmd/pkg/api"
+
+	"github.com/spf13/cobra"
+)
+
+func CheckCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var checkCmd = &cobra.Command{
+		Use:   "check",
+		Short: "Check the LoxiLB features against this host's view",
+		Long: `Check the LoxiLB features by probing them from this host.
+Check - LB, Sessions
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			fmt.Printf("Error: unknown command \"%v\"for \"loxicmd\" \nRun \"loxicmd --help\" for usage.\n", args)
+			cmd.Help()
+			return err
+		},
+	}
+
+	checkCmd.AddCommand(NewCheckLbCmd(restOptions))
+	checkCmd.AddCommand(NewCheckSessionCmd(restOptions))
+
+	return checkCmd
+}
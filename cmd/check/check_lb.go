@@ -0,0 +1,295 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package check
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"loxicmd/pkg/probe"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var CHECK_LB_TITLE = []string{"Service", "Endpoint", "Probe", "Result", "Latency", "LB State", "EP State", "Note"}
+
+type CheckLbOptions struct {
+	ProbeTimeout time.Duration
+}
+
+// LbEndpointCheck - an endpoint probed from this host next to the states loxilb reports
+type LbEndpointCheck struct {
+	Service    string       `json:"service"`
+	EndpointIP string       `json:"endpointIP"`
+	TargetPort uint16       `json:"targetPort"`
+	Probe      probe.Result `json:"probe"`
+	// LbState - state of the endpoint in the LB rule
+	LbState string `json:"lbState"`
+	// EpState - state of the endpoint health check of loxilb
+	EpState string `json:"epState"`
+	Note    string `json:"note,omitempty"`
+}
+
+func NewCheckLbCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := CheckLbOptions{}
+
+	var checkLbCmd = &cobra.Command{
+		Use:   "lb <name|vip:port> [--probe-timeout=<duration>]",
+		Short: "Probe the endpoints of a load balancer rule",
+		Long: `Probe each endpoint of a load balancer rule from this host with the protocol of the rule
+and compare the results with the endpoint state of the rule and of "get endpoint".
+
+Probes
+  tcp  - TCP connect
+  udp  - an empty datagram. No reply is "unknown", an ICMP port unreachable is "fail"
+  sctp - SCTP INIT
+  http - GET / when the rule has a host or security. e2ehttps uses https
+
+A note is shown when this host and loxilb disagree, which tells a loxilb problem
+from a backend problem.
+
+ex) loxicmd check lb k8s-web
+    loxicmd check lb 10.0.0.5:80
+    loxicmd check lb [2001::1]:443 --probe-timeout 5s
+`,
+		Aliases: []string{"loadbalancer", "LB"},
+		Args:    cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			rules, err := getLbRules(restOptions)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			matched := FindLbRules(rules, args[0])
+			if len(matched) == 0 {
+				fmt.Printf("Error: load balancer rule '%s' is not found\n", args[0])
+				return
+			}
+			// The health check states are extra information, the check goes on without them
+			eps, err := getEndPoints(restOptions)
+			if err != nil {
+				fmt.Printf("Warning: failed to get endpoint states: %s\n", err.Error())
+			}
+			PrintCheckLbResult(CheckLbRules(matched, eps, o.ProbeTimeout), *restOptions)
+		},
+	}
+
+	checkLbCmd.Flags().DurationVarP(&o.ProbeTimeout, "probe-timeout", "", 2*time.Second, "Timeout of each probe")
+	return checkLbCmd
+}
+
+// FindLbRules finds the rules by name or by "vip:port". A port inside a port range matches too.
+func FindLbRules(rules api.LbRuleModGet, target string) []api.LoadBalancerModel {
+	var found []api.LoadBalancerModel
+	host, portStr, err := net.SplitHostPort(target)
+	port, perr := strconv.ParseUint(portStr, 10, 16)
+	isVip := err == nil && perr == nil && net.ParseIP(host) != nil
+	for _, lb := range rules.LbRules {
+		svc := lb.Service
+		if !isVip {
+			if svc.Name == target {
+				found = append(found, lb)
+			}
+			continue
+		}
+		portMax := svc.PortMax
+		if portMax == 0 {
+			portMax = svc.Port
+		}
+		if net.ParseIP(svc.ExternalIP).Equal(net.ParseIP(host)) && svc.Port <= uint16(port) && uint16(port) <= portMax {
+			found = append(found, lb)
+		}
+	}
+	return found
+}
+
+func makeProbeTarget(svc api.LoadBalancerService, ep api.LoadBalancerEndpoint) probe.Target {
+	t := probe.Target{IP: ep.EndpointIP, Port: ep.TargetPort, Protocol: svc.Protocol}
+	if t.Port == 0 {
+		t.Port = svc.Port
+	}
+	if svc.Host != "" || svc.Security != api.LbSecNone {
+		t.HTTP = true
+		t.Host = svc.Host
+		// TLS is terminated at loxilb unless it is end-to-end
+		t.Scheme = "http"
+		if svc.Security == api.LbSecE2EHTTPS {
+			t.Scheme = "https"
+		}
+	}
+	return t
+}
+
+// findEpState returns the state of the loxilb health check of the endpoint.
+// A check of the target port wins over a host check without a port.
+func findEpState(eps api.EPInformationGet, ip string, port uint16) string {
+	state := "-"
+	for _, ep := range eps.EPInfo {
+		if ep.HostName != ip {
+			continue
+		}
+		if ep.ProbePort == port {
+			return ep.CurrState
+		}
+		if ep.ProbePort == 0 {
+			state = ep.CurrState
+		}
+	}
+	return state
+}
+
+// lbStateUp converts a state reported by loxilb to up/down, ok is false for unknown states
+func lbStateUp(state string) (up bool, ok bool) {
+	switch state {
+	case "active", "ok", "up", "green":
+		return true, true
+	case "inactive", "nok", "down", "red":
+		return false, true
+	}
+	return false, false
+}
+
+func makeCheckNote(c LbEndpointCheck) string {
+	if c.Probe.Result == probe.Unknown {
+		return ""
+	}
+	probeUp := c.Probe.Result == probe.OK
+	lbUp, lbKnown := lbStateUp(c.LbState)
+	epUp, epKnown := lbStateUp(c.EpState)
+	switch {
+	case probeUp && ((lbKnown && !lbUp) || (epKnown && !epUp)):
+		return "loxilb marks it down but it answers here"
+	case !probeUp && ((lbKnown && lbUp) || (epKnown && epUp)):
+		return "loxilb marks it up but it fails here"
+	case !probeUp:
+		return "backend is down"
+	}
+	return ""
+}
+
+// CheckLbRules probes all endpoints of the rules in parallel
+func CheckLbRules(rules []api.LoadBalancerModel, eps api.EPInformationGet, timeout time.Duration) []LbEndpointCheck {
+	var checks []LbEndpointCheck
+	var targets []probe.Target
+	for _, lb := range rules {
+		svc := lb.Service
+		name := svc.Name
+		if name == "" {
+			name = fmt.Sprintf("%s:%d/%s", svc.ExternalIP, svc.Port, svc.Protocol)
+		}
+		for _, ep := range lb.Endpoints {
+			t := makeProbeTarget(svc, ep)
+			targets = append(targets, t)
+			checks = append(checks, LbEndpointCheck{
+				Service:    name,
+				EndpointIP: ep.EndpointIP,
+				TargetPort: t.Port,
+				LbState:    ep.State,
+				EpState:    findEpState(eps, ep.EndpointIP, t.Port),
+			})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i := range checks {
+		wg.Add(1)
+		go func(c *LbEndpointCheck, t probe.Target) {
+			defer wg.Done()
+			c.Probe = probe.Probe(t, timeout)
+			c.Note = makeCheckNote(*c)
+		}(&checks[i], targets[i])
+	}
+	wg.Wait()
+	return checks
+}
+
+func PrintCheckLbResult(checks []LbEndpointCheck, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(checks, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	var data [][]string
+	table := get.TableInit()
+	table.SetHeader(CHECK_LB_TITLE)
+	for _, c := range checks {
+		result := c.Probe.Result
+		if c.Probe.Detail != "" {
+			result += fmt.Sprintf(" (%s)", c.Probe.Detail)
+		}
+		latency := "-"
+		if c.Probe.Result == probe.OK {
+			latency = c.Probe.Latency.Round(time.Microsecond).String()
+		}
+		data = append(data, []string{c.Service, net.JoinHostPort(c.EndpointIP, strconv.Itoa(int(c.TargetPort))),
+			c.Probe.Probe, result, latency, c.LbState, c.EpState, c.Note})
+	}
+	get.TableShow(data, table)
+}
+
+func getLbRules(restOptions *api.RESTOptions) (api.LbRuleModGet, error) {
+	lbresp := api.LbRuleModGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.LoadBalancerAll().Get(ctx)
+	if err != nil {
+		return lbresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lbresp, fmt.Errorf("failed to get load balancer rules: status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&lbresp); err != nil {
+		return lbresp, fmt.Errorf("failed to unmarshal HTTP response: (%s)", err.Error())
+	}
+	return lbresp, nil
+}
+
+func getEndPoints(restOptions *api.RESTOptions) (api.EPInformationGet, error) {
+	epresp := api.EPInformationGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.EndPoint().SetUrl("/config/endpoint/all").Get(ctx)
+	if err != nil {
+		return epresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return epresp, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&epresp); err != nil {
+		return epresp, fmt.Errorf("failed to unmarshal HTTP response: (%s)", err.Error())
+	}
+	return epresp, nil
+}
@@ -0,0 +1,118 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package check
+
+import (
+	"fmt"
+	"loxicmd/pkg/api"
+	"loxicmd/pkg/probe"
+	"testing"
+)
+
+func TestFindLbRules(t *testing.T) {
+	rules := api.LbRuleModGet{LbRules: []api.LoadBalancerModel{
+		{Service: api.LoadBalancerService{ExternalIP: "10.0.0.5", Port: 80, Protocol: "tcp", Name: "k8s-web"}},
+		{Service: api.LoadBalancerService{ExternalIP: "10.0.0.5", Port: 80, Protocol: "udp", Name: "k8s-web"}},
+		{Service: api.LoadBalancerService{ExternalIP: "10.0.0.6", Port: 443, PortMax: 445, Protocol: "tcp", Name: "k8s-range"}},
+		{Service: api.LoadBalancerService{ExternalIP: "2001:db8::1", Port: 443, Protocol: "tcp", Name: "v6"}},
+	}}
+	tests := []struct {
+		target string
+		want   []string
+	}{
+		{"k8s-web", []string{"10.0.0.5:80/tcp", "10.0.0.5:80/udp"}},
+		{"10.0.0.5:80", []string{"10.0.0.5:80/tcp", "10.0.0.5:80/udp"}},
+		{"10.0.0.5:81", nil},
+		{"10.0.0.6:443", []string{"10.0.0.6:443/tcp"}},
+		{"10.0.0.6:445", []string{"10.0.0.6:443/tcp"}},
+		{"10.0.0.6:446", nil},
+		{"[2001:db8:0::1]:443", []string{"2001:db8::1:443/tcp"}},
+		{"2001:db8::1", nil},
+		{"unknown", nil},
+		// Not a vip:port, so it is looked up as a name
+		{"web:80", nil},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, lb := range FindLbRules(rules, tt.target) {
+			got = append(got, fmt.Sprintf("%s:%d/%s", lb.Service.ExternalIP, lb.Service.Port, lb.Service.Protocol))
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: found %v, want %v", tt.target, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: found %v, want %v", tt.target, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestMakeProbeTarget(t *testing.T) {
+	ep := api.LoadBalancerEndpoint{EndpointIP: "10.212.0.1", TargetPort: 8080}
+	tests := []struct {
+		name string
+		svc  api.LoadBalancerService
+		ep   api.LoadBalancerEndpoint
+		want probe.Target
+	}{
+		{"tcp", api.LoadBalancerService{Port: 80, Protocol: "tcp"}, ep,
+			probe.Target{IP: "10.212.0.1", Port: 8080, Protocol: "tcp"}},
+		{"service port without target port", api.LoadBalancerService{Port: 53, Protocol: "udp"}, api.LoadBalancerEndpoint{EndpointIP: "10.212.0.1"},
+			probe.Target{IP: "10.212.0.1", Port: 53, Protocol: "udp"}},
+		{"host", api.LoadBalancerService{Port: 80, Protocol: "tcp", Host: "web.example"}, ep,
+			probe.Target{IP: "10.212.0.1", Port: 8080, Protocol: "tcp", HTTP: true, Scheme: "http", Host: "web.example"}},
+		{"tls terminated at loxilb", api.LoadBalancerService{Port: 443, Protocol: "tcp", Security: api.LbSecHTTPS}, ep,
+			probe.Target{IP: "10.212.0.1", Port: 8080, Protocol: "tcp", HTTP: true, Scheme: "http"}},
+		{"e2e tls", api.LoadBalancerService{Port: 443, Protocol: "tcp", Security: api.LbSecE2EHTTPS}, ep,
+			probe.Target{IP: "10.212.0.1", Port: 8080, Protocol: "tcp", HTTP: true, Scheme: "https"}},
+	}
+	for _, tt := range tests {
+		if got := makeProbeTarget(tt.svc, tt.ep); got != tt.want {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMakeCheckNote(t *testing.T) {
+	ok := probe.Result{Result: probe.OK}
+	fail := probe.Result{Result: probe.Fail}
+	unknown := probe.Result{Result: probe.Unknown}
+	tests := []struct {
+		result  probe.Result
+		lbState string
+		epState string
+		want    string
+	}{
+		{ok, "active", "ok", ""},
+		{ok, "-", "-", ""},
+		{ok, "inactive", "-", "loxilb marks it down but it answers here"},
+		{ok, "active", "nok", "loxilb marks it down but it answers here"},
+		{fail, "active", "-", "loxilb marks it up but it fails here"},
+		{fail, "-", "ok", "loxilb marks it up but it fails here"},
+		{fail, "inactive", "nok", "backend is down"},
+		{fail, "-", "-", "backend is down"},
+		{unknown, "inactive", "ok", ""},
+	}
+	for _, tt := range tests {
+		c := LbEndpointCheck{Probe: tt.result, LbState: tt.lbState, EpState: tt.epState}
+		if got := makeCheckNote(c); got != tt.want {
+			t.Errorf("%s lb %s ep %s: note %q, want %q", tt.result.Result, tt.lbState, tt.epState, got, tt.want)
+		}
+	}
+}
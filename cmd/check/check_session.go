@@ -0,0 +1,167 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package check
+
+import (
+	"encoding/json"
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var CHECK_SESSION_TITLE = []string{"Check", "Ident", "Detail"}
+
+const (
+	// SessionCheckOrphanUlCl - a ULCL classifier whose session doesn't exist
+	SessionCheckOrphanUlCl = "orphan-ulcl"
+	// SessionCheckDuplicateTeID - sessions with the same TeID on the same tunnel IP
+	SessionCheckDuplicateTeID = "duplicate-teid"
+	// SessionCheckQfiConflict - ULCL classifiers of a session with different QFIs for an IP
+	SessionCheckQfiConflict = "qfi-conflict"
+)
+
+// SessionIssue - an inconsistency between the sessions and the ULCL classifiers
+type SessionIssue struct {
+	Check  string   `json:"check"`
+	Idents []string `json:"idents"`
+	Detail string   `json:"detail"`
+}
+
+func NewCheckSessionCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var checkSessionCmd = &cobra.Command{
+		Use:   "sessions",
+		Short: "Check the sessions and the ULCL classifiers are consistent",
+		Long: `Check the sessions and the ULCL classifiers of loxilb are consistent.
+
+Checks
+  orphan-ulcl    - a ULCL classifier whose session doesn't exist
+  duplicate-teid - sessions with the same TeID on the same access or core tunnel IP
+  qfi-conflict   - ULCL classifiers of a session with different QFIs for the same IP
+
+ex) loxicmd check sessions
+`,
+		Aliases: []string{"session"},
+		Args:    cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			sessions, err := get.GetSessions(restOptions, api.SessionFilter{})
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			ulcls, err := get.GetSessionUlCls(restOptions)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			issues := CheckSessions(sessions, ulcls)
+			PrintCheckSessionResult(issues, *restOptions)
+			if len(issues) == 0 && restOptions.PrintOption != "json" {
+				fmt.Printf("No issue found in %d sessions and %d ULCLs\n", len(sessions.SessionInfo), len(ulcls.UlclInfo))
+			}
+		},
+	}
+
+	return checkSessionCmd
+}
+
+func CheckSessions(sessionresp api.SessionInformationGet, ulclresp api.UlclInformationGet) []SessionIssue {
+	issues := []SessionIssue{}
+
+	idents := map[string]bool{}
+	teids := map[string][]string{}
+	for _, s := range sessionresp.SessionInfo {
+		idents[s.Ident] = true
+		for _, tun := range []struct {
+			name string
+			tun  api.SessTun
+		}{{"access", s.AnTun}, {"core", s.CnTun}} {
+			key := fmt.Sprintf("%s tunnel TeID %d on %s", tun.name, tun.tun.TeID, tun.tun.Addr.String())
+			teids[key] = append(teids[key], s.Ident)
+		}
+	}
+	for key, dups := range teids {
+		if len(dups) > 1 {
+			sort.Strings(dups)
+			issues = append(issues, SessionIssue{Check: SessionCheckDuplicateTeID, Idents: dups,
+				Detail: fmt.Sprintf("%d sessions use %s", len(dups), key)})
+		}
+	}
+
+	// ULCLs of each session
+	qfiOfIP := map[string]map[string]map[uint8]bool{}
+	for _, ulcl := range ulclresp.UlclInfo {
+		if !idents[ulcl.Ident] {
+			issues = append(issues, SessionIssue{Check: SessionCheckOrphanUlCl, Idents: []string{ulcl.Ident},
+				Detail: fmt.Sprintf("ULCL %s (QFI %d) has no session", ulcl.Args.Addr.String(), ulcl.Args.Qfi)})
+			continue
+		}
+		ip := ulcl.Args.Addr.String()
+		if qfiOfIP[ulcl.Ident] == nil {
+			qfiOfIP[ulcl.Ident] = map[string]map[uint8]bool{}
+		}
+		if qfiOfIP[ulcl.Ident][ip] == nil {
+			qfiOfIP[ulcl.Ident][ip] = map[uint8]bool{}
+		}
+		qfiOfIP[ulcl.Ident][ip][ulcl.Args.Qfi] = true
+	}
+	for ident, ips := range qfiOfIP {
+		for ip, qfis := range ips {
+			if len(qfis) > 1 {
+				var list []string
+				for qfi := range qfis {
+					list = append(list, fmt.Sprintf("%d", qfi))
+				}
+				sort.Strings(list)
+				issues = append(issues, SessionIssue{Check: SessionCheckQfiConflict, Idents: []string{ident},
+					Detail: fmt.Sprintf("ULCL %s has QFIs %s", ip, strings.Join(list, ", "))})
+			}
+		}
+	}
+
+	sort.Slice(issues, func(i, j int) bool {
+		if issues[i].Check != issues[j].Check {
+			return issues[i].Check < issues[j].Check
+		}
+		if issues[i].Idents[0] != issues[j].Idents[0] {
+			return issues[i].Idents[0] < issues[j].Idents[0]
+		}
+		return issues[i].Detail < issues[j].Detail
+	})
+	return issues
+}
+
+func PrintCheckSessionResult(issues []SessionIssue, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(issues, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+	if len(issues) == 0 {
+		return
+	}
+
+	var data [][]string
+	table := get.TableInit()
+	table.SetHeader(CHECK_SESSION_TITLE)
+	for _, issue := range issues {
+		data = append(data, []string{issue.Check, strings.Join(issue.Idents, ", "), issue.Detail})
+	}
+	get.TableShow(data, table)
+}
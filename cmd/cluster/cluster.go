@@ -0,0 +1,57 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"fmt"
+	"loxicmd/pkg/api"
+
+	"github.com/spf13/cobra"
+)
+
+func ClusterCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var clusterCmd = &cobra.Command{
+		Use:   "cluster",
+		Short: "Compare the configuration of the LoxiLB nodes of --servers",
+		Long: `Compare the configuration of the LoxiLB nodes of --servers.
+diff - Show configuration drift between the nodes
+
+Other commands run on every node of --servers. The nodes are checked concurrently first.
+"get" runs on the reachable nodes concurrently and the other commands run on them in turn.
+A node fails when one of its API requests fails. "get" prints one table with a node column
+and the others print the output of each node. --atomic is supported by "apply -f" only: it
+checks that all nodes answer first, stops at the first node which fails and deletes the
+file from the nodes it ran on, including the failed one.
+
+ex) loxicmd get lb --servers 192.168.10.1,192.168.10.2
+    loxicmd apply -f lb.yaml --servers 192.168.10.1,192.168.10.2 --atomic
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			fmt.Printf("Error: unknown command \"%v\"for \"loxicmd\" \nRun \"loxicmd --help\" for usage.\n", args)
+			cmd.Help()
+			return err
+		},
+	}
+
+	clusterCmd.AddCommand(NewDiffCmd(restOptions))
+
+	return clusterCmd
+}
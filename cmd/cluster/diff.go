@@ -0,0 +1,390 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var CLUSTER_DIFF_TITLE = []string{"Resource", "Key"}
+
+// Resources compared by default. The others are often different per node on purpose.
+var defaultDiffResources = []string{"lb", "firewall", "endpoint", "policy", "mirror", "session", "ulcl", "bgppolicy"}
+
+type DiffOptions struct {
+	Resources []string
+}
+
+// diffResource gets the items of a resource from a node as key and JSON without the runtime states
+type diffResource func(restOptions *api.RESTOptions) (map[string]string, error)
+
+var diffResources = map[string]diffResource{
+	"lb": diffList("config/loadbalancer/all", "lbAttr", func(lb *api.LoadBalancerModel) string {
+		for i := range lb.Endpoints {
+			ep := &lb.Endpoints[i]
+			ep.State, ep.Counter, ep.Packets, ep.Bytes = "", "", 0, 0
+		}
+		sort.Slice(lb.Endpoints, func(i, j int) bool {
+			return fmt.Sprintf("%s|%05d", lb.Endpoints[i].EndpointIP, lb.Endpoints[i].TargetPort) <
+				fmt.Sprintf("%s|%05d", lb.Endpoints[j].EndpointIP, lb.Endpoints[j].TargetPort)
+		})
+		return fmt.Sprintf("%s:%d/%s", lb.Service.ExternalIP, lb.Service.Port, lb.Service.Protocol)
+	}),
+	"firewall": diffList("config/firewall/all", "fwAttr", func(fw *api.FwRuleMod) string {
+		fw.Opts.Counter, fw.Opts.Packets, fw.Opts.Bytes = "", 0, 0
+		return fmt.Sprintf("%s pref %d", fw.Rule.MatchString(), fw.Rule.Pref)
+	}),
+	"endpoint": diffList("config/endpoint/all", "Attr", func(ep *api.EndPointGetEntry) string {
+		ep.MinDelay, ep.AvgDelay, ep.MaxDelay, ep.CurrState = "", "", "", ""
+		return fmt.Sprintf("%s %s:%d", ep.HostName, ep.ProbeType, ep.ProbePort)
+	}),
+	"policy": diffList("config/policy/all", "polAttr", func(pol *api.PolMod) string {
+		return pol.Ident
+	}),
+	"mirror": diffList("config/mirror/all", "mirrAttr", func(mirr *api.MirrGetMod) string {
+		mirr.Sync = 0
+		return mirr.Ident
+	}),
+	"session": diffList("config/session/all", "sessionAttr", func(sess *api.SessionMod) string {
+		return sess.Ident
+	}),
+	"ulcl": diffList("config/sessionulcl/all", "ulclAttr", func(ulcl *api.SessionUlClMod) string {
+		return ulcl.Ident + " " + ulcl.Args.Addr.String()
+	}),
+	"bgppolicy": diffList("config/bgp/policy/all", "bgpPolicyAttr", func(pol *api.BGPPolicyMod) string {
+		return pol.Name
+	}),
+	"route": diffList("config/route/all", "routeAttr", func(rt *api.Routev4Get) string {
+		rt.Statistic, rt.HardwareMark = api.RouteGetEntryStatistic{}, 0
+		return rt.Dst
+	}),
+	"bfd": diffList("config/bfd/all", "Attr", func(bfd *api.BFDSessionInfo) string {
+		bfd.State = ""
+		return bfd.Key()
+	}),
+	"bgpneighbor": diffList("config/bgp/neigh/all", "bgpNeiAttr", func(nei *api.BGPNeighborEntry) string {
+		nei.State, nei.UpDownTime, nei.PrefixesReceived, nei.PrefixesAdvertised, nei.LastError = "", "", 0, 0, ""
+		return nei.IPaddress
+	}),
+}
+
+// diffList makes a diffResource of a list of the model. clean removes the runtime states and returns the key.
+func diffList[T any](url, attr string, clean func(*T) string) diffResource {
+	return func(restOptions *api.RESTOptions) (map[string]string, error) {
+		items := map[string]string{}
+		client := api.NewLoxiClient(restOptions)
+		ctx := context.TODO()
+		var cancel context.CancelFunc
+		if restOptions.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+			defer cancel()
+		}
+		resp, err := client.Status().SetUrl(url).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to get %s: %s", url, resp.Status)
+		}
+		err = api.DecodeList(resp.Body, attr, func(item T) error {
+			key := clean(&item)
+			value, err := json.Marshal(item)
+			if err != nil {
+				return err
+			}
+			items[key] = string(value)
+			return nil
+		})
+		return items, err
+	}
+}
+
+// DiffEntry - an item which is not the same on all nodes
+type DiffEntry struct {
+	Resource string `json:"resource"`
+	Key      string `json:"key"`
+	// Variants - variant of the item per node. "-" is missing and the same letter is the same item
+	Variants map[string]string `json:"variants"`
+	// Fields - fields which differ, as "<field>: <node>=<value>, ..."
+	Fields []string `json:"fields,omitempty"`
+}
+
+type DiffResult struct {
+	Nodes       []string    `json:"nodes"`
+	Diffs       []DiffEntry `json:"diffs"`
+	Unreachable []string    `json:"unreachable,omitempty"`
+}
+
+func NewDiffCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := DiffOptions{}
+
+	var diffCmd = &cobra.Command{
+		Use:   "diff [--resources=<resource>,...]",
+		Short: "Show configuration drift between the nodes of --servers",
+		Long: fmt.Sprintf(`Get the configuration from each node of --servers and show the items which are
+missing on some nodes or differ between nodes. Runtime states like counters are ignored.
+The same letter in a node column is the same item, "-" is a missing item.
+
+Resources: %s
+Default  : %s
+
+ex) loxicmd cluster diff --servers 192.168.10.1,192.168.10.2
+    loxicmd cluster diff --servers 192.168.10.1,192.168.10.2,192.168.10.3 --resources lb,route
+`, strings.Join(diffResourceNames(), ", "), strings.Join(defaultDiffResources, ", ")),
+		Run: func(cmd *cobra.Command, args []string) {
+			nodes, err := MakeNodes(restOptions)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			if len(nodes) < 2 {
+				fmt.Printf("Error: cluster diff needs two or more nodes in --servers\n")
+				return
+			}
+			for _, r := range o.Resources {
+				if _, ok := diffResources[r]; !ok {
+					fmt.Printf("Error: resource '%s' is unknown. it should be one of %s\n", r, strings.Join(diffResourceNames(), ", "))
+					return
+				}
+			}
+			result, err := DiffNodes(nodes, o.Resources)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			PrintDiffResult(result, *restOptions)
+		},
+	}
+
+	diffCmd.Flags().StringSliceVarP(&o.Resources, "resources", "", defaultDiffResources, "Resources to compare")
+
+	return diffCmd
+}
+
+func diffResourceNames() []string {
+	var names []string
+	for name := range diffResources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DiffNodes gets the resources from the nodes in parallel and compares them
+func DiffNodes(nodes []*api.RESTOptions, resources []string) (DiffResult, error) {
+	// items[node][resource]
+	items := make([]map[string]map[string]string, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *api.RESTOptions) {
+			defer wg.Done()
+			items[i] = map[string]map[string]string{}
+			for _, r := range resources {
+				list, err := diffResources[r](node)
+				if err != nil {
+					errs[i] = err
+					return
+				}
+				items[i][r] = list
+			}
+		}(i, node)
+	}
+	wg.Wait()
+
+	result := DiffResult{Diffs: []DiffEntry{}}
+	var reachable []int
+	for i, node := range nodes {
+		if errs[i] != nil {
+			result.Unreachable = append(result.Unreachable, fmt.Sprintf("%s: %s", node.Server(), errs[i].Error()))
+			continue
+		}
+		reachable = append(reachable, i)
+		result.Nodes = append(result.Nodes, node.Server())
+	}
+	if len(reachable) < 2 {
+		return result, fmt.Errorf("less than two nodes answered: %s", strings.Join(result.Unreachable, ", "))
+	}
+
+	for _, r := range resources {
+		keys := map[string]bool{}
+		for _, i := range reachable {
+			for key := range items[i][r] {
+				keys[key] = true
+			}
+		}
+		var sorted []string
+		for key := range keys {
+			sorted = append(sorted, key)
+		}
+		sort.Strings(sorted)
+		for _, key := range sorted {
+			if entry, ok := diffItem(r, key, nodes, reachable, items); ok {
+				result.Diffs = append(result.Diffs, entry)
+			}
+		}
+	}
+	return result, nil
+}
+
+func diffItem(resource, key string, nodes []*api.RESTOptions, reachable []int, items []map[string]map[string]string) (DiffEntry, bool) {
+	entry := DiffEntry{Resource: resource, Key: key, Variants: map[string]string{}}
+	letters := map[string]string{}
+	values := map[string]string{}
+	for _, i := range reachable {
+		node := nodes[i].Server()
+		value, ok := items[i][resource][key]
+		if !ok {
+			entry.Variants[node] = "-"
+			continue
+		}
+		if _, ok := letters[value]; !ok {
+			letters[value] = string(rune('A' + len(letters)))
+		}
+		entry.Variants[node] = letters[value]
+		values[node] = value
+	}
+	if len(letters) == 1 && len(values) == len(reachable) {
+		return entry, false
+	}
+	if len(letters) > 1 {
+		entry.Fields = diffFields(nodes, reachable, values)
+	}
+	return entry, true
+}
+
+// diffFields lists the fields which differ between the nodes having the item
+func diffFields(nodes []*api.RESTOptions, reachable []int, values map[string]string) []string {
+	flat := map[string]map[string]string{}
+	fields := map[string]bool{}
+	for node, value := range values {
+		var v interface{}
+		json.Unmarshal([]byte(value), &v)
+		flat[node] = map[string]string{}
+		flattenJSON("", v, flat[node])
+		for f := range flat[node] {
+			fields[f] = true
+		}
+	}
+	var sorted []string
+	for f := range fields {
+		sorted = append(sorted, f)
+	}
+	sort.Strings(sorted)
+
+	var result []string
+	for _, f := range sorted {
+		var parts []string
+		same, first, seen := true, "", false
+		for _, i := range reachable {
+			node := nodes[i].Server()
+			if _, ok := values[node]; !ok {
+				continue
+			}
+			val, ok := flat[node][f]
+			if !ok {
+				val = "-"
+			}
+			if !seen {
+				first, seen = val, true
+			} else if val != first {
+				same = false
+			}
+			parts = append(parts, fmt.Sprintf("%s=%s", node, val))
+		}
+		if !same {
+			result = append(result, fmt.Sprintf("%s: %s", f, strings.Join(parts, ", ")))
+		}
+	}
+	return result
+}
+
+func flattenJSON(prefix string, v interface{}, out map[string]string) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, val := range v {
+			name := k
+			if prefix != "" {
+				name = prefix + "." + k
+			}
+			flattenJSON(name, val, out)
+		}
+	case []interface{}:
+		for i, val := range v {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), val, out)
+		}
+	default:
+		b, _ := json.Marshal(v)
+		out[prefix] = string(b)
+	}
+}
+
+func PrintDiffResult(result DiffResult, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(result, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	for _, n := range result.Unreachable {
+		fmt.Printf("Warning: %s is not compared\n", n)
+	}
+	if len(result.Diffs) == 0 {
+		fmt.Printf("No drift between %s\n", strings.Join(result.Nodes, ", "))
+		return
+	}
+
+	var data [][]string
+	table := get.TableInit()
+	// Auto format breaks the node addresses
+	table.SetAutoFormatHeaders(false)
+	var header []string
+	for _, title := range CLUSTER_DIFF_TITLE {
+		header = append(header, strings.ToUpper(title))
+	}
+	table.SetHeader(append(header, result.Nodes...))
+	for _, d := range result.Diffs {
+		row := []string{d.Resource, d.Key}
+		for _, node := range result.Nodes {
+			row = append(row, d.Variants[node])
+		}
+		data = append(data, row)
+	}
+	get.TableShow(data, table)
+
+	for _, d := range result.Diffs {
+		if len(d.Fields) == 0 {
+			continue
+		}
+		fmt.Printf("%s %s:\n", d.Resource, d.Key)
+		for _, f := range d.Fields {
+			fmt.Printf("  %s\n", f)
+		}
+	}
+}
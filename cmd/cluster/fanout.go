@@ -0,0 +1,504 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"loxicmd/cmd/delete"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type FanOutOptions struct {
+	// Atomic rolls back the nodes the command ran on when any node fails
+	Atomic bool
+}
+
+// NodeResult - output of a command run on a node
+type NodeResult struct {
+	Node   string
+	Output string
+	// Tables - the tables shown by a get command, which are not in Output
+	Tables []get.TableRows
+	Failed bool
+	// Skipped - the command was not run on the node
+	Skipped bool
+}
+
+// NewRootCmd builds the command tree on the options of a node
+type NewRootCmd func(restOptions *api.RESTOptions) *cobra.Command
+
+// fanOutCommands - commands which run on each node. "get" merges the tables.
+var fanOutCommands = map[string]bool{
+	"get": true, "create": true, "delete": true, "apply": true, "set": true,
+	"update": true, "drain": true, "undrain": true, "firewall": true, "check": true, "bfd": true,
+}
+
+// writeCommands - commands which change the configuration
+var writeCommands = map[string]bool{
+	"create": true, "delete": true, "apply": true, "set": true,
+	"update": true, "drain": true, "undrain": true, "firewall": true,
+}
+
+// localCommands - commands which work on this host only or use --servers themselves
+var localCommands = map[string]bool{
+	"ha": true, "cluster": true, "version": true, "completion": true, "help": true,
+}
+
+func topCommand(cmd *cobra.Command) string {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	return cmd.Name()
+}
+
+// CheckFanOut tells if the command runs on the nodes of --servers
+func CheckFanOut(cmd *cobra.Command, restOptions *api.RESTOptions, o FanOutOptions) (bool, error) {
+	top := topCommand(cmd)
+	if len(restOptions.Servers) == 0 {
+		if o.Atomic {
+			return false, errors.New("--atomic needs --servers")
+		}
+		return false, nil
+	}
+	if localCommands[top] || !cmd.HasParent() {
+		return false, nil
+	}
+	if !fanOutCommands[top] {
+		return false, fmt.Errorf("--servers can't be used with \"%s\"", top)
+	}
+	if top == "apply" {
+		for _, local := range []string{"ip", "per-intf", "ipv4route"} {
+			if cmd.Flags().Changed(local) {
+				return false, fmt.Errorf("--servers can't be used with --%s which configures this host", local)
+			}
+		}
+	}
+	if f := cmd.Flags().Lookup("watch"); f != nil && f.Changed {
+		return false, errors.New("--servers can't be used with --watch")
+	}
+	if o.Atomic {
+		if !writeCommands[top] {
+			return false, fmt.Errorf("--atomic can't be used with \"%s\" which changes nothing", top)
+		}
+		// Only a file can be deleted again as a whole. Other commands would need
+		// the inverse of each change, so they are not accepted.
+		if file, _ := cmd.Flags().GetString("file"); top != "apply" || file == "" {
+			return false, errors.New("--atomic supports \"apply -f <file>\" only, which can be rolled back")
+		}
+	}
+	return true, nil
+}
+
+// FanOut runs the command on every node of --servers and prints the results.
+// Each node runs the command in this process on a command tree of its own. The nodes
+// are checked concurrently first. The output of a node is taken from stdout, so the
+// command runs on the reachable nodes in turn. "get" changes nothing, so it runs on all
+// the nodes concurrently first and then in turn on the answers they gave.
+// It returns the exit code.
+func FanOut(cmd *cobra.Command, restOptions *api.RESTOptions, o FanOutOptions, args []string, newRoot NewRootCmd) int {
+	nodes, err := MakeNodes(restOptions)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return 1
+	}
+	top := topCommand(cmd)
+	reachErrs := checkNodes(nodes)
+	if o.Atomic {
+		for i, err := range reachErrs {
+			if err != nil {
+				fmt.Printf("Error: %s is not reachable: %s. Nothing is changed\n", nodes[i].Server(), err.Error())
+				return 1
+			}
+		}
+	}
+
+	transports := make([]*replayTransport, len(nodes))
+	if top == "get" {
+		transports = prefetch(cmd, newRoot, args, nodes, reachErrs)
+	}
+
+	results := make([]NodeResult, len(nodes))
+	stop := false
+	for i, node := range nodes {
+		switch {
+		case stop:
+			results[i] = NodeResult{Node: node.Server(), Skipped: true}
+		case reachErrs[i] != nil:
+			results[i] = NodeResult{Node: node.Server(), Failed: true, Output: fmt.Sprintf("Error: not reachable: %s\n", reachErrs[i].Error())}
+		default:
+			results[i] = runOnNode(cmd, newRoot, args, node, transports[i])
+		}
+		// Stop at the first failure so fewer nodes are rolled back
+		stop = stop || (o.Atomic && results[i].Failed)
+	}
+
+	if top == "get" {
+		PrintMergedResult(results, *restOptions)
+	} else {
+		PrintNodeResults(results)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Failed || r.Skipped {
+			failed++
+		}
+	}
+	if top == "get" {
+		if failed > 0 {
+			return 1
+		}
+		return 0
+	}
+	fmt.Printf("Succeeded on %d/%d nodes\n", len(nodes)-failed, len(nodes))
+	if failed == 0 {
+		return 0
+	}
+	if o.Atomic {
+		file, _ := cmd.Flags().GetString("file")
+		rollback(nodes, results, file)
+	}
+	return 1
+}
+
+// MakeNodes returns the options of each node of --servers
+func MakeNodes(restOptions *api.RESTOptions) ([]*api.RESTOptions, error) {
+	var nodes []*api.RESTOptions
+	seen := map[string]bool{}
+	for _, server := range restOptions.Servers {
+		node, err := restOptions.WithServer(server)
+		if err != nil {
+			return nil, err
+		}
+		if seen[node.Server()] {
+			continue
+		}
+		seen[node.Server()] = true
+		node.Servers = nil
+		nodes = append(nodes, node)
+	}
+	return nodes, nil
+}
+
+// checkNodes asks every node for its version concurrently and returns the error of each node
+func checkNodes(nodes []*api.RESTOptions) []error {
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *api.RESTOptions) {
+			defer wg.Done()
+			client := api.NewLoxiClient(node)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if node.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(node.Timeout)*time.Second)
+				defer cancel()
+			}
+			resp, err := client.LBVersion().Get(ctx)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				errs[i] = fmt.Errorf("it answers %s", resp.Status)
+			}
+		}(i, node)
+	}
+	wg.Wait()
+	return errs
+}
+
+// fanOutServerFlags - root flags which select the API server. They are removed from
+// the command line of a node, whose server is set after the flags are parsed.
+var fanOutServerFlags = []string{"servers", "apiserver", "port", "atomic"}
+
+// fanOutArgs removes the API server flags of the root from the command line of cmd,
+// which is parsed already. A flag of the same name which cmd defines itself, like
+// "get conntrack --port", shadows the root flag and is kept.
+func fanOutArgs(cmd *cobra.Command, args []string) []string {
+	withValue := map[string]bool{}
+	noValue := map[string]bool{}
+	for _, name := range fanOutServerFlags {
+		f := cmd.Root().PersistentFlags().Lookup(name)
+		if f == nil || cmd.Flags().Lookup(name) != f {
+			continue
+		}
+		names := []string{"--" + name}
+		if f.Shorthand != "" && cmd.Flags().ShorthandLookup(f.Shorthand) == f {
+			names = append(names, "-"+f.Shorthand)
+		}
+		for _, n := range names {
+			if f.NoOptDefVal != "" {
+				noValue[n] = true
+			} else {
+				withValue[n] = true
+			}
+		}
+	}
+
+	var result []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			result = append(result, args[i:]...)
+			break
+		}
+		name, _, hasValue := strings.Cut(arg, "=")
+		switch {
+		case withValue[name]:
+			if !hasValue {
+				i++
+			}
+			continue
+		case noValue[name]:
+			continue
+		case len(arg) > 2 && !strings.HasPrefix(arg, "--") && withValue[arg[:2]]:
+			// -s10.0.0.1
+			continue
+		}
+		result = append(result, arg)
+	}
+	return result
+}
+
+// prefetch runs the command on the reachable nodes concurrently with the output
+// discarded and returns the transports which recorded the answers of each node
+func prefetch(cmd *cobra.Command, newRoot NewRootCmd, args []string, nodes []*api.RESTOptions, reachErrs []error) []*replayTransport {
+	transports := make([]*replayTransport, len(nodes))
+	devNull, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		return transports
+	}
+	defer devNull.Close()
+	stdout := os.Stdout
+	os.Stdout = devNull
+	restore := get.CollectTables(func(get.TableRows) {})
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		if reachErrs[i] != nil {
+			continue
+		}
+		transports[i] = newReplayTransport(node.Transport)
+		wg.Add(1)
+		go func(i int, node *api.RESTOptions) {
+			defer wg.Done()
+			executeOnNode(cmd, newRoot, args, node, &api.RequestLog{}, transports[i])
+		}(i, node)
+	}
+	wg.Wait()
+
+	restore()
+	os.Stdout = stdout
+	for _, t := range transports {
+		if t != nil {
+			t.Replay()
+		}
+	}
+	return transports
+}
+
+// executeOnNode runs the command line on a command tree of the node
+func executeOnNode(cmd *cobra.Command, newRoot NewRootCmd, args []string, node *api.RESTOptions, log *api.RequestLog, transport http.RoundTripper) error {
+	opts := &api.RESTOptions{}
+	root := newRoot(opts)
+	root.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		opts.ServerIP, opts.ServerPort, opts.Log = node.ServerIP, node.ServerPort, log
+		if transport != nil {
+			opts.Transport = transport
+		}
+	}
+	root.SetArgs(fanOutArgs(cmd, args))
+	root.SilenceErrors = true
+	root.SilenceUsage = true
+	return root.Execute()
+}
+
+// runOnNode runs the command line on a command tree of the node. The node fails when
+// one of its API requests fails, or when the command stops before making any request.
+func runOnNode(cmd *cobra.Command, newRoot NewRootCmd, args []string, node *api.RESTOptions, transport *replayTransport) NodeResult {
+	r := NodeResult{Node: node.Server()}
+	log := &api.RequestLog{}
+	var rt http.RoundTripper
+	if transport != nil {
+		rt = transport
+	}
+
+	restore := get.CollectTables(func(t get.TableRows) {
+		r.Tables = append(r.Tables, t)
+	})
+	out, err := captureStdout(func() error {
+		return executeOnNode(cmd, newRoot, args, node, log, rt)
+	})
+	restore()
+
+	r.Output = out
+	if err != nil {
+		r.Output += fmt.Sprintf("Error: %s\n", err.Error())
+	}
+	errs := log.Errors()
+	for _, e := range errs {
+		r.Output += fmt.Sprintf("Error: %s\n", e)
+	}
+	r.Failed = err != nil || len(errs) != 0 || log.Requests() == 0
+	if r.Failed && strings.TrimSpace(r.Output) == "" {
+		r.Output = "Error: the command made no API request\n"
+	}
+	return r
+}
+
+// captureStdout runs fn while stdout goes to a pipe and returns what was written
+func captureStdout(fn func() error) (string, error) {
+	pr, pw, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	os.Stdout = pw
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(&buf, pr)
+		close(done)
+	}()
+
+	err = fn()
+	os.Stdout = stdout
+	pw.Close()
+	<-done
+	pr.Close()
+	return buf.String(), err
+}
+
+// rollback deletes the file on the nodes it ran on. The node which failed is rolled
+// back too, as it may have applied a part of the file before the failure.
+func rollback(nodes []*api.RESTOptions, results []NodeResult, file string) {
+	for i, r := range results {
+		if r.Skipped {
+			continue
+		}
+		fmt.Printf("--- %s: rolling back %s ---\n", r.Node, file)
+		if err := delete.DeleteFileConfig(file, nodes[i]); err != nil {
+			fmt.Printf("Error: rollback failed on %s: %s\n", r.Node, err.Error())
+		}
+	}
+}
+
+// PrintNodeResults prints the output of each node under a heading
+func PrintNodeResults(results []NodeResult) {
+	for _, r := range results {
+		state := "ok"
+		switch {
+		case r.Skipped:
+			fmt.Printf("--- %s (skipped) ---\n", r.Node)
+			continue
+		case r.Failed:
+			state = "failed"
+		}
+		fmt.Printf("--- %s (%s) ---\n", r.Node, state)
+		for _, t := range r.Tables {
+			table := get.TableInit()
+			table.SetHeader(t.Header)
+			get.TableShow(t.Rows, table)
+		}
+		fmt.Print(r.Output)
+		if r.Output != "" && !strings.HasSuffix(r.Output, "\n") {
+			fmt.Println()
+		}
+	}
+}
+
+// PrintMergedResult prints one table with a node column when every node showed one
+// table with the same header, and the output of each node otherwise
+func PrintMergedResult(results []NodeResult, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		printMergedJSON(results)
+		return
+	}
+
+	var header []string
+	for _, r := range results {
+		if r.Failed {
+			continue
+		}
+		if len(r.Tables) != 1 || header != nil && strings.Join(r.Tables[0].Header, "|") != strings.Join(header, "|") {
+			PrintNodeResults(results)
+			return
+		}
+		header = r.Tables[0].Header
+	}
+
+	if header != nil {
+		var data [][]string
+		table := get.TableInit()
+		table.SetHeader(append([]string{"Node"}, header...))
+		for _, r := range results {
+			if r.Failed {
+				continue
+			}
+			for _, row := range r.Tables[0].Rows {
+				data = append(data, append([]string{r.Node}, row...))
+			}
+		}
+		get.TableShow(data, table)
+	}
+	// Warnings and errors of the nodes
+	for _, r := range results {
+		out := strings.TrimRight(r.Output, "\n")
+		if out == "" {
+			continue
+		}
+		for _, line := range strings.Split(out, "\n") {
+			fmt.Printf("[%s] %s\n", r.Node, line)
+		}
+	}
+}
+
+func printMergedJSON(results []NodeResult) {
+	type nodeJSON struct {
+		Node   string          `json:"node"`
+		Result json.RawMessage `json:"result,omitempty"`
+		Error  string          `json:"error,omitempty"`
+	}
+	var merged []nodeJSON
+	for _, r := range results {
+		n := nodeJSON{Node: r.Node}
+		out := bytes.TrimSpace([]byte(r.Output))
+		if !r.Failed && json.Valid(out) {
+			n.Result = out
+		} else {
+			n.Error = string(out)
+		}
+		merged = append(merged, n)
+	}
+	resultIndent, _ := json.MarshalIndent(merged, "", "    ")
+	fmt.Println(string(resultIndent))
+}
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func newFanOutTestRoot() *cobra.Command {
+	var s, o string
+	var servers []string
+	var p, port int
+	var atomic bool
+	root := &cobra.Command{Use: "loxicmd"}
+	root.PersistentFlags().StringVarP(&o, "output", "o", "", "")
+	root.PersistentFlags().StringVarP(&s, "apiserver", "s", "127.0.0.1", "")
+	root.PersistentFlags().IntVarP(&p, "port", "p", 11111, "")
+	root.PersistentFlags().StringSliceVar(&servers, "servers", nil, "")
+	root.PersistentFlags().BoolVar(&atomic, "atomic", false, "")
+	get := &cobra.Command{Use: "get"}
+	ct := &cobra.Command{Use: "conntrack", Run: func(*cobra.Command, []string) {}}
+	ct.Flags().IntVarP(&port, "port", "", 0, "")
+	lb := &cobra.Command{Use: "lb", Run: func(*cobra.Command, []string) {}}
+	get.AddCommand(ct, lb)
+	root.AddCommand(get)
+	return root
+}
+
+func TestFanOutArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"get", "lb", "--servers", "a,b"}, "get lb"},
+		{[]string{"get", "lb", "--servers=a,b", "-s", "10.0.0.1", "-p", "8080"}, "get lb"},
+		{[]string{"get", "lb", "-s10.0.0.1", "--port=8080", "--atomic"}, "get lb"},
+		{[]string{"--servers", "a,b", "get", "lb", "-o", "json"}, "get lb -o json"},
+		{[]string{"get", "conntrack", "--port", "80", "--servers", "a,b"}, "get conntrack --port 80"},
+		{[]string{"get", "conntrack", "--port=80", "-s", "10.0.0.1", "--servers", "a,b"}, "get conntrack --port=80"},
+		{[]string{"get", "lb", "--", "--port", "80"}, "get lb -- --port 80"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			root := newFanOutTestRoot()
+			root.SetArgs(tt.args)
+			var cmd *cobra.Command
+			root.PersistentPreRun = func(c *cobra.Command, _ []string) { cmd = c }
+			if err := root.Execute(); err != nil {
+				t.Fatal(err)
+			}
+			if got := strings.Join(fanOutArgs(cmd, tt.args), " "); got != tt.want {
+				t.Errorf("fanOutArgs = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
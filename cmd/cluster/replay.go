@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"sync"
+)
+
+type recordedAnswer struct {
+	resp *http.Response
+	body []byte
+	err  error
+}
+
+// replayTransport records the answers of a node to a command, so that the command can
+// run again on the same answers without waiting for the node. Requests are matched by
+// method, URL and body in the order they were made. A request which was not recorded
+// goes to the node.
+type replayTransport struct {
+	base    http.RoundTripper
+	mutex   sync.Mutex
+	replay  bool
+	answers map[string][]recordedAnswer
+}
+
+func newReplayTransport(base http.RoundTripper) *replayTransport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &replayTransport{base: base, answers: map[string][]recordedAnswer{}}
+}
+
+// Replay makes the transport answer from the recorded answers from now on
+func (t *replayTransport) Replay() {
+	t.mutex.Lock()
+	t.replay = true
+	t.mutex.Unlock()
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+	key := req.Method + " " + req.URL.String() + " " + string(reqBody)
+
+	t.mutex.Lock()
+	replay := t.replay
+	if replay && len(t.answers[key]) > 0 {
+		a := t.answers[key][0]
+		t.answers[key] = t.answers[key][1:]
+		t.mutex.Unlock()
+		if a.err != nil {
+			return nil, a.err
+		}
+		resp := *a.resp
+		resp.Request = req
+		resp.Body = io.NopCloser(bytes.NewReader(a.body))
+		return &resp, nil
+	}
+	t.mutex.Unlock()
+
+	resp, err := t.base.RoundTrip(req)
+	if replay {
+		return resp, err
+	}
+	a := recordedAnswer{err: err}
+	if err == nil {
+		a.body, err = io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		a.resp = resp
+		resp = &http.Response{}
+		*resp = *a.resp
+		resp.Body = io.NopCloser(bytes.NewReader(a.body))
+	}
+	t.mutex.Lock()
+	t.answers[key] = append(t.answers[key], a)
+	t.mutex.Unlock()
+	return resp, err
+}
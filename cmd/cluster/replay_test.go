@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package cluster
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestReplayTransport(t *testing.T) {
+	var hits int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt64(&hits, 1)
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		io.WriteString(w, r.URL.Path+strings.Repeat("!", int(n)))
+	}))
+	defer srv.Close()
+
+	rt := newReplayTransport(nil)
+	client := &http.Client{Transport: rt}
+	get := func(path string) (int, string) {
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	recorded := map[string][]string{}
+	for _, path := range []string{"/a", "/fail", "/a"} {
+		_, body := get(path)
+		recorded[path] = append(recorded[path], body)
+	}
+	rt.Replay()
+	for _, path := range []string{"/a", "/fail", "/a"} {
+		status, body := get(path)
+		if body != recorded[path][0] {
+			t.Errorf("replayed %s = %q, want %q", path, body, recorded[path][0])
+		}
+		if path == "/fail" && status != http.StatusInternalServerError {
+			t.Errorf("replayed %s status %d", path, status)
+		}
+		recorded[path] = recorded[path][1:]
+	}
+	if hits != 3 {
+		t.Errorf("%d requests reached the server, want 3", hits)
+	}
+	// A request which was not recorded goes to the server
+	if _, body := get("/b"); body != "/b!!!!" {
+		t.Errorf("unrecorded /b = %q", body)
+	}
+}
@@ -58,6 +58,7 @@ Create - Service type external load-balancer, Vlan, Vxlan, Qos Policies,
 	createCmd.AddCommand(NewCreateFirewallCmd(restOptions))
 	createCmd.AddCommand(NewCreateEndPointCmd(restOptions))
 	createCmd.AddCommand(NewCreateBGPNeighborCmd(restOptions))
+	createCmd.AddCommand(NewCreateBGPCmd(restOptions))
 	createCmd.AddCommand(NewCreateBFDCmd(restOptions))
 
 	return createCmd
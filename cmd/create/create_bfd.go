@@ -28,15 +28,27 @@ import (
 	"github.com/spf13/cobra"
 )
 
+type CreateBFDOptions struct {
+	Interval   string
+	MinRx      string
+	Multiplier uint8
+}
+
 func NewCreateBFDCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := api.BFDSessionInfo{}
+	opt := CreateBFDOptions{}
 
 	var createBFDCmd = &cobra.Command{
-		Use:   "bfd remoteIP [--instance=<instance>] [--sourceIP=<source-IP>] [--interval=<interval>] [--retryCount=<count>]",
+		Use:   "bfd remoteIP [--instance=<instance>] [--sourceIP=<source-IP>] [--interval=<interval>] [--minRx=<interval>] [--multiplier=<count>]",
 		Short: "Create a BFD session",
 		Long: `Create a BFD session for HA failover
+--interval   - BFD packet Tx interval like 200ms, 1s or 300us. A number without a unit is in microseconds
+--minRx      - Minimum Rx interval required from the remote. It is the same as --interval if not given
+--multiplier - Number of missed packets to detect a failure (same as --retryCount)
 
-ex) loxicmd create bfd 32.32.32.2 --instance=default --sourceIP=32.32.32.1 --interval=200000 --retryCount=3`,
+ex) loxicmd create bfd 32.32.32.2 --instance=default --sourceIP=32.32.32.1 --interval=200ms --multiplier=3
+    loxicmd create bfd 32.32.32.2 --sourceIP=32.32.32.1 --interval=100ms --minRx=300ms
+    loxicmd create bfd 32.32.32.2 --instance=default --sourceIP=32.32.32.1 --interval=200000 --retryCount=3`,
 		Aliases: []string{"bfd-session"},
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
@@ -57,6 +69,10 @@ ex) loxicmd create bfd 32.32.32.2 --instance=default --sourceIP=32.32.32.1 --int
 				fmt.Printf("Error: %s\n", err.Error())
 				return
 			}
+			if err := ReadBFDTimerOptions(cmd, &o, opt); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
 			resp, err := CreateBFDAPICall(restOptions, o)
 			if err != nil {
 				fmt.Printf("Error: %s\n", err.Error())
@@ -72,8 +88,11 @@ ex) loxicmd create bfd 32.32.32.2 --instance=default --sourceIP=32.32.32.1 --int
 	}
 
 	createBFDCmd.Flags().StringVarP(&o.Instance, "instance", "", "default", "Specify the cluster instance name")
-	createBFDCmd.Flags().Uint64VarP(&o.Interval, "interval", "", 200000, "Specify the BFD packet tx interval (in microseconds)")
+	createBFDCmd.Flags().StringVarP(&opt.Interval, "interval", "", "200ms", "Specify the BFD packet tx interval (ex. 200ms, 1s or microseconds)")
+	createBFDCmd.Flags().StringVarP(&opt.MinRx, "minRx", "", "", "Specify the minimum rx interval required from the remote")
 	createBFDCmd.Flags().Uint8VarP(&o.RetryCount, "retryCount", "", 3, "Specify the number of reties")
+	createBFDCmd.Flags().Uint8VarP(&opt.Multiplier, "multiplier", "", 3, "Specify the detect multiplier (same as --retryCount)")
+	createBFDCmd.MarkFlagsMutuallyExclusive("retryCount", "multiplier")
 	createBFDCmd.Flags().StringVar(&o.SourceIP, "sourceIP", "", "Specify the source IP for the session")
 
 	return createBFDCmd
@@ -95,6 +114,30 @@ func ReadCreateBfdOptions(o *api.BFDSessionInfo, args []string) error {
 	return nil
 }
 
+// ReadBFDTimerOptions sets the intervals and the multiplier which are given by the flags
+func ReadBFDTimerOptions(cmd *cobra.Command, o *api.BFDSessionInfo, opt CreateBFDOptions) error {
+	var err error
+	if opt.Interval != "" {
+		if o.Interval, err = api.ParseBFDInterval(opt.Interval); err != nil {
+			return err
+		}
+	}
+	if opt.MinRx != "" {
+		if o.MinRxInterval, err = api.ParseBFDInterval(opt.MinRx); err != nil {
+			return fmt.Errorf("minRx: %s", err.Error())
+		}
+	}
+	if cmd.Flags().Changed("multiplier") {
+		o.RetryCount = opt.Multiplier
+	}
+	if cmd.Flags().Changed("retryCount") || cmd.Flags().Changed("multiplier") {
+		if o.RetryCount == 0 {
+			return fmt.Errorf("multiplier should be 1 or more")
+		}
+	}
+	return o.Validation()
+}
+
 func CreateBFDAPICall(restOptions *api.RESTOptions, bfdModel api.BFDSessionInfo) (*http.Response, error) {
 	client := api.NewLoxiClient(restOptions)
 	ctx := context.TODO()
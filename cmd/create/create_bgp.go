@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package create
+
+import (
+	"context"
+	"fmt"
+	"loxicmd/pkg/api"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type CreateBGPPolicyOptions struct {
+	Direction   string
+	Peers       []string
+	Prefixes    []string
+	LbNames     []string
+	Action      string
+	Communities []string
+	Med         uint32
+	LocalPref   uint32
+}
+
+func NewCreateBGPCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var createBGPCmd = &cobra.Command{
+		Use:   "bgp",
+		Short: "Create BGP features",
+		Long: `Create BGP features using LoxiLB.
+	policy - import and export policies
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+		},
+	}
+
+	createBGPCmd.AddCommand(NewCreateBGPPolicyCmd(restOptions))
+	return createBGPCmd
+}
+
+func NewCreateBGPPolicyCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := CreateBGPPolicyOptions{}
+
+	var createBGPPolicyCmd = &cobra.Command{
+		Use:   "policy <name> --direction=import|export [--peer=<PeerIP>,...] [--prefix=<cidr>[:<min>-<max>],...] [--lb=<lbName>,...] [--action=accept|reject] [--community=<ASN>:<value>,...] [--med=<MED>] [--localPref=<pref>]",
+		Short: "Create a BGP policy",
+		Long: `Create a BGP import or export policy using LoxiLB.
+
+--prefix is a prefix-list entry. A mask length range also matches longer prefixes inside it.
+--lb adds the VIP of a load balancer rule to the prefix-list.
+Without --peer the policy applies to all neighbors, without --prefix and --lb to all prefixes.
+
+ex) loxicmd create bgp policy vip-med --direction=export --lb=k8s-web --med=100 --community=65000:100
+    loxicmd create bgp policy no-private --direction=import --prefix=10.0.0.0/8:8-32 --action=reject
+    loxicmd create bgp policy prefer-a --direction=import --peer=192.168.10.2 --localPref=200
+`,
+		Aliases: []string{"policies", "pol"},
+		Args:    cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			pol, err := MakeBGPPolicyMod(cmd, restOptions, args[0], o)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			if err := pol.Validation(); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			resp, err := client.BGPPolicy().Create(ctx, pol)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				PrintCreateResult(resp, *restOptions)
+				return
+			}
+			fmt.Printf("Error: failed to create BGP policy %s: %s\n", pol.Name, resp.Status)
+		},
+	}
+
+	createBGPPolicyCmd.Flags().StringVarP(&o.Direction, "direction", "", "", "import or export")
+	createBGPPolicyCmd.Flags().StringSliceVarP(&o.Peers, "peer", "", nil, "Neighbors the policy applies to")
+	createBGPPolicyCmd.Flags().StringSliceVarP(&o.Prefixes, "prefix", "", nil, "Prefix-list entries as <cidr>[:<min>-<max>]")
+	createBGPPolicyCmd.Flags().StringSliceVarP(&o.LbNames, "lb", "", nil, "Load balancer rules whose VIP is added to the prefix-list")
+	createBGPPolicyCmd.Flags().StringVarP(&o.Action, "action", "", api.BGPPolicyAccept, "accept or reject the matching prefixes")
+	createBGPPolicyCmd.Flags().StringSliceVarP(&o.Communities, "community", "", nil, "Communities to add as <ASN>:<value> or no-export, no-advertise")
+	createBGPPolicyCmd.Flags().Uint32VarP(&o.Med, "med", "", 0, "MED to set")
+	createBGPPolicyCmd.Flags().Uint32VarP(&o.LocalPref, "localPref", "", 0, "Local preference to set")
+	createBGPPolicyCmd.MarkFlagRequired("direction")
+	return createBGPPolicyCmd
+}
+
+// MakeBGPPolicyMod makes the policy from the flags, resolving --lb to the VIP of each rule
+func MakeBGPPolicyMod(cmd *cobra.Command, restOptions *api.RESTOptions, name string, o CreateBGPPolicyOptions) (api.BGPPolicyMod, error) {
+	pol := api.BGPPolicyMod{
+		Name:           name,
+		Direction:      o.Direction,
+		Peers:          o.Peers,
+		Action:         o.Action,
+		SetCommunities: o.Communities,
+	}
+	for _, prefix := range o.Prefixes {
+		p, err := api.ParseBGPPolicyPrefix(prefix)
+		if err != nil {
+			return pol, err
+		}
+		pol.Prefixes = append(pol.Prefixes, p)
+	}
+	for _, lbName := range o.LbNames {
+		svc, err := ResolveLbRuleName(restOptions, lbName)
+		if err != nil {
+			return pol, err
+		}
+		ip := net.ParseIP(svc.ExternalIP)
+		if ip == nil {
+			return pol, fmt.Errorf("load balancer rule '%s' has no valid VIP", lbName)
+		}
+		if !svc.BGP {
+			fmt.Printf("Warning: load balancer rule '%s' doesn't have BGP enabled, its VIP is not advertised\n", lbName)
+		}
+		prefix := svc.ExternalIP + "/32"
+		if ip.To4() == nil {
+			prefix = svc.ExternalIP + "/128"
+		}
+		pol.Prefixes = append(pol.Prefixes, api.BGPPolicyPrefix{Prefix: prefix})
+	}
+	if cmd.Flags().Changed("med") {
+		pol.SetMed = &o.Med
+	}
+	if cmd.Flags().Changed("localPref") {
+		pol.SetLocalPref = &o.LocalPref
+	}
+	return pol, nil
+}
+
+func BGPPolicyAPICall(restOptions *api.RESTOptions, pol api.BGPPolicyMod) (*http.Response, error) {
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	return client.BGPPolicy().Create(ctx, pol)
+}
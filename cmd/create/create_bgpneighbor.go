@@ -30,18 +30,31 @@ import (
 )
 
 type CreateBGPNeighborOptions struct {
-	SetMultiHtop bool
-	RemotePort   uint8
+	SetMultiHtop         bool
+	RemotePort           uint16
+	HoldTime             uint32
+	KeepaliveInterval    uint32
+	Password             string
+	SourceAddress        string
+	AddressFamilies      []string
+	LocalAs              int
+	RouteReflectorClient bool
 }
 
 func NewCreateBGPNeighborCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := CreateBGPNeighborOptions{}
 
 	var createBGPNeighborCmd = &cobra.Command{
-		Use:   "bgpneighbor <PeerIP> <ASN> [--remotePort=<remoteBGPPort>] [--setMultiHtop]",
+		Use:   "bgpneighbor <PeerIP> <ASN> [--remotePort=<remoteBGPPort>] [--setMultiHtop] [--holdTime=<sec>] [--keepaliveInterval=<sec>] [--password=<md5 key>] [--sourceAddress=<IP>] [--addressFamily=ipv4,ipv6] [--localAs=<ASN>] [--routeReflectorClient]",
 		Short: "Create a BGP Neighbor",
 		Long: `Create a BGP Neighbor using LoxiLB.
+Timers are in seconds. The hold time is 0 or at least 3 and the keepalive interval is less than the hold time.
+Unset timers and address families use the defaults of loxilb.
+
 ex) loxicmd create bgpneighbor 10.10.10.1 64512
+    loxicmd create bgpneighbor 10.10.10.1 64512 --holdTime=9 --keepaliveInterval=3 --password=secret
+    loxicmd create bgpneighbor 2001::1 64512 --sourceAddress=2001::2 --addressFamily=ipv4,ipv6 --localAs=65001
+    loxicmd create bgpneighbor 10.10.10.2 64512 --routeReflectorClient
 `,
 		Aliases: []string{"bgpnei", "bgpneigh"},
 		PreRun: func(cmd *cobra.Command, args []string) {
@@ -64,6 +77,17 @@ ex) loxicmd create bgpneighbor 10.10.10.1 64512
 			if o.SetMultiHtop {
 				BGPNeighborMod.SetMultiHop = o.SetMultiHtop
 			}
+			BGPNeighborMod.HoldTime = o.HoldTime
+			BGPNeighborMod.KeepaliveInterval = o.KeepaliveInterval
+			BGPNeighborMod.Password = o.Password
+			BGPNeighborMod.SourceAddress = o.SourceAddress
+			BGPNeighborMod.AddressFamilies = o.AddressFamilies
+			BGPNeighborMod.LocalAs = o.LocalAs
+			BGPNeighborMod.RouteReflectorClient = o.RouteReflectorClient
+			if err := BGPNeighborMod.Validation(); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
 			resp, err := BGPNeighborAPICall(restOptions, BGPNeighborMod)
 			if err != nil {
 				fmt.Printf("Error: %s\n", err.Error())
@@ -79,7 +103,14 @@ ex) loxicmd create bgpneighbor 10.10.10.1 64512
 		},
 	}
 	createBGPNeighborCmd.Flags().BoolVarP(&o.SetMultiHtop, "setMultiHtop", "", false, "Enable Multihop BGP in the load balancer")
-	createBGPNeighborCmd.Flags().Uint8VarP(&o.RemotePort, "remotePort", "", 179, "BGP Port number of the remote site")
+	createBGPNeighborCmd.Flags().Uint16VarP(&o.RemotePort, "remotePort", "", 179, "BGP Port number of the remote site")
+	createBGPNeighborCmd.Flags().Uint32VarP(&o.HoldTime, "holdTime", "", 0, "Hold timer in seconds")
+	createBGPNeighborCmd.Flags().Uint32VarP(&o.KeepaliveInterval, "keepaliveInterval", "", 0, "Keepalive timer in seconds")
+	createBGPNeighborCmd.Flags().StringVarP(&o.Password, "password", "", "", "TCP MD5 password of the session")
+	createBGPNeighborCmd.Flags().StringVarP(&o.SourceAddress, "sourceAddress", "", "", "Local address of the session")
+	createBGPNeighborCmd.Flags().StringSliceVarP(&o.AddressFamilies, "addressFamily", "", nil, "Address families to exchange (ipv4, ipv6)")
+	createBGPNeighborCmd.Flags().IntVarP(&o.LocalAs, "localAs", "", 0, "ASN used toward this neighbor instead of the global ASN")
+	createBGPNeighborCmd.Flags().BoolVarP(&o.RouteReflectorClient, "routeReflectorClient", "", false, "Make the neighbor a route reflector client")
 
 	return createBGPNeighborCmd
 }
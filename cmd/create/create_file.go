@@ -16,8 +16,11 @@
 package create
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"loxicmd/pkg/api"
+	"net/http"
 
 	"gopkg.in/yaml.v2"
 )
@@ -27,7 +30,7 @@ func EndPointCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := EndPointAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(EndPointAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -38,7 +41,7 @@ func FDBCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := FDBAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(FDBAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -49,7 +52,7 @@ func FirewallCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := FirewallAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(FirewallAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -60,7 +63,7 @@ func IPv4AddressCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) err
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := IPv4AddressAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(IPv4AddressAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -71,7 +74,10 @@ func LoadBalancerCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) er
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := LoadbalancerAPICall(restOptions, c.Spec); err != nil {
+	if err := c.Spec.Validation(); err != nil {
+		return err
+	}
+	if err := CheckFileResp(LoadbalancerAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -82,7 +88,10 @@ func MirrorCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := MirrorAPICall(restOptions, c.Spec); err != nil {
+	if err := c.Spec.Validation(); err != nil {
+		return err
+	}
+	if err := CheckFileResp(MirrorAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -92,7 +101,7 @@ func NeighborsCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := NeighborsAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(NeighborsAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -103,7 +112,10 @@ func PolicyCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := PolicyAPICall(restOptions, c.Spec); err != nil {
+	if err := c.Spec.Validation(); err != nil {
+		return err
+	}
+	if err := CheckFileResp(PolicyAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -114,7 +126,10 @@ func RouteCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := RouteAPICall(restOptions, c.Spec); err != nil {
+	if err := c.Spec.Validation(); err != nil {
+		return err
+	}
+	if err := CheckFileResp(RouteAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -128,7 +143,7 @@ func SessionCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := c.Spec.Validation(); err != nil {
 		return err
 	}
-	if _, err := SessionAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(SessionAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -139,7 +154,7 @@ func SessionUlClCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) err
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := SessionUlClAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(SessionUlClAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -152,7 +167,7 @@ func VlanMemberCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) erro
 	}
 	// URL Maker
 	url := fmt.Sprintf("/config/vlan/%d/member", c.ObjectMeta.VlanID)
-	if _, err := VlanMemberAPICall(restOptions, c.Spec, url); err != nil {
+	if err := CheckFileResp(VlanMemberAPICall(restOptions, c.Spec, url)); err != nil {
 		return err
 	}
 	return nil
@@ -164,7 +179,7 @@ func VlanBridgeCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) erro
 		return err
 	}
 
-	if _, err := VlanBridgeAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(VlanBridgeAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -177,7 +192,7 @@ func VxlanPeerCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error
 	}
 	// URL Maker
 	url := fmt.Sprintf("/config/tunnel/vxlan/%d/peer", c.ObjectMeta.VxlanID)
-	if _, err := VxlanPeerAPICall(restOptions, c.Spec, url); err != nil {
+	if err := CheckFileResp(VxlanPeerAPICall(restOptions, c.Spec, url)); err != nil {
 		return err
 	}
 	return nil
@@ -188,7 +203,7 @@ func VxlanBridgeCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) err
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := VxlanBridgeAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(VxlanBridgeAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
@@ -199,8 +214,93 @@ func BFDCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
-	if _, err := CreateBFDAPICall(restOptions, c.Spec); err != nil {
+	if err := CheckFileResp(CreateBFDAPICall(restOptions, c.Spec)); err != nil {
 		return err
 	}
 	return nil
 }
+
+func BGPNeighborCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
+	var c api.ConfigurationBGPFile
+	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
+		return err
+	}
+	if err := c.Spec.Validation(); err != nil {
+		return err
+	}
+	if err := CheckFileResp(BGPNeighborAPICall(restOptions, c.Spec)); err != nil {
+		return err
+	}
+	return nil
+}
+
+func BGPPolicyCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
+	var c api.ConfigurationBGPPolicyFile
+	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
+		return err
+	}
+	if err := c.Spec.Validation(); err != nil {
+		return err
+	}
+	if err := CheckFileResp(BGPPolicyAPICall(restOptions, c.Spec)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// TopologyCreateWithFile creates the objects of a topology in the order of the dependency.
+// It returns the objects created, which are deleted in the reverse order to roll back a failure.
+func TopologyCreateWithFile(restOptions *api.RESTOptions, byteBuf []byte) ([]api.TopologyObject, error) {
+	var c api.ConfigurationTopologyFile
+	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
+		return nil, err
+	}
+	if err := c.Spec.Validation(); err != nil {
+		return nil, err
+	}
+	objs := c.Spec.Objects()
+	for i, obj := range objs {
+		if err := createTopologyObject(restOptions, obj); err != nil {
+			return objs[:i], fmt.Errorf("failed to create %s: %s", obj.Name, err.Error())
+		}
+		fmt.Printf("%s created\n", obj.Name)
+	}
+	return objs, nil
+}
+
+func createTopologyObject(restOptions *api.RESTOptions, obj api.TopologyObject) error {
+	switch c := obj.File.(type) {
+	case api.ConfigurationVlanFile:
+		return CheckFileResp(VlanBridgeAPICall(restOptions, c.Spec))
+	case api.ConfigurationVlanMemberFile:
+		url := fmt.Sprintf("/config/vlan/%d/member", c.ObjectMeta.VlanID)
+		return CheckFileResp(VlanMemberAPICall(restOptions, c.Spec, url))
+	case api.ConfigurationVxlanFile:
+		return CheckFileResp(VxlanBridgeAPICall(restOptions, c.Spec))
+	case api.ConfigurationVxlanPeerFile:
+		url := fmt.Sprintf("/config/tunnel/vxlan/%d/peer", c.ObjectMeta.VxlanID)
+		return CheckFileResp(VxlanPeerAPICall(restOptions, c.Spec, url))
+	case api.ConfigurationIPv4File:
+		return CheckFileResp(IPv4AddressAPICall(restOptions, c.Spec))
+	}
+	return fmt.Errorf("%s is not a kind of a topology", obj.Kind)
+}
+
+// CheckFileResp turns a failure answer of the API server into an error
+func CheckFileResp(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		result := struct {
+			Result string `json:"result"`
+		}{}
+		body, _ := io.ReadAll(resp.Body)
+		if json.Unmarshal(body, &result) == nil && result.Result != "" {
+			return fmt.Errorf("%s: %s", resp.Status, result.Result)
+		}
+		return fmt.Errorf("%s", resp.Status)
+	}
+	return nil
+}
@@ -31,6 +31,8 @@ import (
 
 type CreateFirewallOptions struct {
 	FirewallRule []string
+	Match        string
+	Pref         uint16
 	Redirect     []string
 	SnatArgs     []string
 	Allow        bool
@@ -45,10 +47,18 @@ func NewCreateFirewallCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := CreateFirewallOptions{}
 
 	var createFirewallCmd = &cobra.Command{
-		Use:   "firewall --firewallRule=<ruleKey>:<ruleValue>, [--allow] [--drop] [--trap] [--record] [--egress] [--redirect=<PortName>] [--setmark=<FwMark>]",
+		Use:   "firewall (--match=<expression> [--pref=<preference>] | --firewallRule=<ruleKey>:<ruleValue>,) [--allow] [--drop] [--trap] [--record] [--egress] [--redirect=<PortName>] [--setmark=<FwMark>]",
 		Short: "Create a Firewall",
 		Long: `Create a Firewall using LoxiLB
 
+--match expression fields
+src <ip|cidr> - Source IP or CIDR
+dst <ip|cidr> - Destination IP or CIDR
+sport <port|min-max> - Source port or port range
+dport <port|min-max> - Destination port or port range
+proto <name|number> - tcp, udp, icmp, icmpv6, sctp, any or the protocol number
+in <portName> - the incoming port
+
 --<ruleKey>s of firewallRule
 sourceIP(string) - Source IP in CIDR notation
 destinationIP(string) - Destination IP in CIDR notation	
@@ -56,12 +66,14 @@ minSourcePort(int) - Minimum source port range
 maxSourcePort(int) - Maximum source port range	
 minDestinationPort(int) - Minimum destination port range	
 maxDestinationPort(int) - Maximum source port range	
-protocol(int) - the protocol	
+protocol(int|string) - the protocol number or name	
 portName(string) - the incoming port	
 preference(int) - User preference for ordering	
 
 
-ex) loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.3.1.2/32,preference:200" --allow
+ex) loxicmd create firewall --match="src 10.0.0.0/8 dst 1.2.3.4 dport 80-90 proto tcp in eth0" --pref=200 --allow
+    loxicmd create firewall --match="src 3ffe::/64 proto icmpv6" --drop
+    loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.3.1.2/32,preference:200" --allow
     loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.3.1.2/32,preference:200" --allow --record
     loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.3.1.2/32,preference:200" --allow --setmark=10
     loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.3.1.2/32,preference:200" --drop
@@ -74,7 +86,7 @@ ex) loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.
 `,
 		Aliases: []string{"Firewall", "fw", "firewalls"},
 		PreRun: func(cmd *cobra.Command, args []string) {
-			if len(o.FirewallRule) == 0 {
+			if len(o.FirewallRule) == 0 && !cmd.Flags().Changed("match") {
 				cmd.Help()
 				os.Exit(0)
 			}
@@ -82,7 +94,15 @@ ex) loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.
 		Run: func(cmd *cobra.Command, args []string) {
 			var FirewallMods api.FwRuleMod
 			// Make FirewallMod
-			if err := GetFirewallRulePairList(&FirewallMods, o.FirewallRule); err != nil {
+			if cmd.Flags().Changed("match") {
+				rule, err := api.ParseFwMatch(o.Match)
+				if err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+				rule.Pref = o.Pref
+				FirewallMods.Rule = rule
+			} else if err := GetFirewallRulePairList(&FirewallMods, o.FirewallRule); err != nil {
 				fmt.Printf("Error: %s\n", err.Error())
 				return
 			}
@@ -108,6 +128,8 @@ ex) loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.
 	}
 
 	createFirewallCmd.Flags().StringSliceVar(&o.FirewallRule, "firewallRule", o.FirewallRule, "Information related to firewall rule")
+	createFirewallCmd.Flags().StringVar(&o.Match, "match", o.Match, "Match expression of the firewall rule (ex. \"src 10.0.0.0/8 dport 80-90 proto tcp\")")
+	createFirewallCmd.Flags().Uint16Var(&o.Pref, "pref", o.Pref, "User preference for ordering (used with --match)")
 	createFirewallCmd.Flags().StringSliceVar(&o.Redirect, "redirect", o.Redirect, "Redirect any matching rule")
 	createFirewallCmd.Flags().BoolVarP(&o.Allow, "allow", "", false, "Allow any matching rule")
 	createFirewallCmd.Flags().BoolVarP(&o.Drop, "drop", "", false, "Drop any matching rule")
@@ -116,33 +138,27 @@ ex) loxicmd create firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.
 	createFirewallCmd.Flags().Uint32VarP(&o.Mark, "setmark", "", 0, " Add a fw mark")
 	createFirewallCmd.Flags().StringSliceVar(&o.SnatArgs, "snat", o.SnatArgs, "SNAT any matching rule")
 	createFirewallCmd.Flags().BoolVarP(&o.Egress, "egress", "", false, "Specify that this an egress rule (to be used with snat)")
-	createFirewallCmd.MarkFlagRequired("firewallRule")
+	createFirewallCmd.MarkFlagsMutuallyExclusive("match", "firewallRule")
+	createFirewallCmd.MarkFlagsMutuallyExclusive("pref", "firewallRule")
 	return createFirewallCmd
 }
 
 func GetFirewallRulePairList(o *api.FwRuleMod, FWrule []string) error {
 	for _, FirewallArg := range FWrule {
-		FirewallArgsPair := strings.Split(FirewallArg, ":")
+		// Split only on the first ':' so IPv6 addresses stay intact
+		FirewallArgsPair := strings.SplitN(FirewallArg, ":", 2)
 		if len(FirewallArgsPair) < 2 {
 			return fmt.Errorf("FirewallArgs '%s' is invalid format", FWrule)
 		} else if FirewallArgsPair[0] == "protocol" {
-			protocol, err := strconv.Atoi(FirewallArgsPair[1])
+			protocol, err := api.ParseFwProto(FirewallArgsPair[1])
 			if err != nil {
 				return err
 			}
-			o.Rule.Proto = uint8(protocol)
+			o.Rule.Proto = protocol
 		} else if FirewallArgsPair[0] == "sourceIP" {
-			if len(FirewallArgsPair) > 2 {
-				o.Rule.SrcIP = strings.Join(FirewallArgsPair[1:], ":")
-			} else {
-				o.Rule.SrcIP = FirewallArgsPair[1]
-			}
+			o.Rule.SrcIP = FirewallArgsPair[1]
 		} else if FirewallArgsPair[0] == "destinationIP" {
-			if len(FirewallArgsPair) > 2 {
-				o.Rule.DstIP = strings.Join(FirewallArgsPair[1:], ":")
-			} else {
-				o.Rule.DstIP = FirewallArgsPair[1]
-			}
+			o.Rule.DstIP = FirewallArgsPair[1]
 		} else if FirewallArgsPair[0] == "portName" {
 			o.Rule.InPort = FirewallArgsPair[1]
 		} else if FirewallArgsPair[0] == "minSourcePort" {
@@ -78,6 +78,7 @@ func ReadCreateLoadBalancerOptions(o *CreateLoadBalancerOptions, args []string)
 	return nil
 }
 
+// SelectToNum returns the numeric select algorithm or -1 if sel is unknown.
 func SelectToNum(sel string) int {
 	var ret int
 	switch sel {
@@ -96,11 +97,12 @@ func SelectToNum(sel string) int {
 	case "n3":
 		ret = 6
 	default:
-		ret = 0
+		ret = -1
 	}
 	return ret
 }
 
+// ModeToNum returns the numeric NAT mode or -1 if sel is unknown.
 func ModeToNum(sel string) int {
 	var ret int
 	switch sel {
@@ -114,12 +116,15 @@ func ModeToNum(sel string) int {
 		ret = 4
 	case "hostonearm":
 		ret = 5
-	default:
+	case "", "default":
 		ret = 0
+	default:
+		ret = -1
 	}
 	return ret
 }
 
+// SecStringToNum returns the numeric security mode or -1 if sec is unknown.
 func SecStringToNum(sec string) int {
 	var ret int
 	switch sec {
@@ -131,8 +136,10 @@ func SecStringToNum(sec string) int {
 		ret = 2
 	case "e2etls":
 		ret = 2
-	default:
+	case "", "none":
 		ret = 0
+	default:
+		ret = -1
 	}
 	return ret
 }
@@ -141,7 +148,7 @@ func NewCreateLoadBalancerCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := CreateLoadBalancerOptions{}
 
 	var createLbCmd = &cobra.Command{
-		Use:   "lb IP [--select=<rr|hash|priority|persist>] [--tcp=<ports>:<targetPorts>] [--udp=<ports>:<targetPorts>] [--sctp=<ports>:<targetPorts>] [--icmp] [--mark=<val>] [--secips=<ip>,] [--sources=<ip>,] [--endpoints=<ip>:<weight>,] [--mode=<onearm|fullnat>] [--bgp] [--monitor] [--inatimeout=<to>] [--name=<service-name>] [--attachEP] [--detachEP] [--security=<https|e2ehttps|none>] [--host=<url>] [--ppv2en] [--egress]",
+		Use:   "lb IP [--select=<rr|hash|priority|persist>] [--tcp=<ports>:<targetPorts>] [--udp=<ports>:<targetPorts>] [--sctp=<ports>:<targetPorts>] [--icmp] [--mark=<val>] [--secips=<ip>,] [--sources=<ip>,] [--endpoints=<ip>:<weight>,] [--mode=<onearm|fullnat>] [--bgp] [--monitor] [--inatimeout=<to>] [--name=<service-name>] [--attachEP] [--detachEP] [--security=<https|tls|e2ehttps|e2etls|none>] [--host=<url>] [--ppv2en] [--egress]",
 		Short: "Create a LoadBalancer",
 		Long: `Create a LoadBalancer
 
@@ -213,6 +220,18 @@ ex)
 				fmt.Printf("Secondary IPs allowed in SCTP only\n")
 				return
 			}
+			if SelectToNum(o.Select) < 0 {
+				fmt.Printf("Error: select '%s' is not supported (rr, hash, priority, persist, lc, n2, n3)\n", o.Select)
+				return
+			}
+			if ModeToNum(o.Mode) < 0 {
+				fmt.Printf("Error: mode '%s' is not supported (onearm, fullnat, dsr, fullproxy, hostonearm)\n", o.Mode)
+				return
+			}
+			if SecStringToNum(o.Security) < 0 {
+				fmt.Printf("Error: security '%s' is not supported (https, tls, e2ehttps, e2etls, none)\n", o.Security)
+				return
+			}
 
 			fmt.Printf("ProtoPortpair: %v\n", ProtoPortpair)
 			// Commom Part of the load balancer.
@@ -280,10 +299,6 @@ ex)
 				for endpoint, weight := range endpointPair {
 					targetPorts := portTargetPorts[startSPort]
 					for _, targetPort := range targetPorts {
-						if o.Mode == "dsr" && targetPort != startSPort {
-							fmt.Printf("Error: No port-translation in dsr mode\n")
-							return
-						}
 						ep := api.LoadBalancerEndpoint{
 							EndpointIP: endpoint,
 							TargetPort: targetPort,
@@ -307,6 +322,11 @@ ex)
 					lbModel.SrcIPs = append(lbModel.SrcIPs, sp)
 				}
 
+				if err := lbModel.Validation(); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+
 				resp, err := LoadbalancerAPICall(restOptions, lbModel)
 				if err != nil {
 					fmt.Printf("Error: %s\n", err.Error())
@@ -391,6 +411,9 @@ func GetPortPairList(portPairStrList []string) (map[uint16][]uint16, error) {
 			if err != nil {
 				return nil, fmt.Errorf("port '%s' is not integer", servicePort)
 			}
+			if port < 0 || port > 65535 {
+				return nil, fmt.Errorf("port '%s' is out of range (0-65535)", servicePort)
+			}
 			portList = append(portList, port)
 		}
 
@@ -413,11 +436,17 @@ func GetPortPairList(portPairStrList []string) (map[uint16][]uint16, error) {
 			if endTP < startTP {
 				return nil, fmt.Errorf("targetPort2 '%s' <  targetPort2 '%s'", targetPortRange[1], targetPortRange[0])
 			}
+			if startTP < 0 || endTP > 65535 {
+				return nil, fmt.Errorf("targetPort '%s' is out of range (0-65535)", portPair[1])
+			}
 		} else {
 			startTP, err = strconv.Atoi(targetPortRange[0])
 			if err != nil {
 				return nil, fmt.Errorf("targetPort0 '%s' is not integer", targetPortRange[0])
 			}
+			if startTP < 0 || startTP > 65535 {
+				return nil, fmt.Errorf("targetPort '%s' is out of range (0-65535)", targetPortRange[0])
+			}
 			endTP = startTP
 		}
 
@@ -440,9 +469,9 @@ func GetEndpointWeightPairList(endpointsList []string) (map[string]uint8, error)
 			return nil, fmt.Errorf("endpoint '%s' is invalid format", endpointStr)
 		}
 		// 0 is endpoint IP, 1 is weight
-		weight, err := strconv.Atoi(endpointStr[weightIdx+1:])
+		weight, err := strconv.ParseUint(endpointStr[weightIdx+1:], 10, 8)
 		if err != nil {
-			return nil, fmt.Errorf("endpoint's weight '%s' is invalid format", endpointStr[weightIdx+1:])
+			return nil, fmt.Errorf("endpoint's weight '%s' is invalid format (0-255)", endpointStr[weightIdx+1:])
 		}
 		result[endpointStr[:weightIdx]] = uint8(weight)
 	}
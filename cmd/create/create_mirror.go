@@ -17,6 +17,7 @@ package create
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"loxicmd/pkg/api"
@@ -30,18 +31,44 @@ import (
 )
 
 type CreateMirrorOptions struct {
-	MirrID    string
-	MirrInfo  []string
-	TargerObj []string
+	MirrID     string
+	MirrInfo   []string
+	TargerObj  []string
+	Type       string
+	ToPort     string
+	Vlan       int
+	RemoteIP   string
+	SourceIP   string
+	TunnelID   int
+	AttachPort string
+	AttachRule string
+}
+
+// namedFlags returns true when any of the named mirror flags is used.
+func (o CreateMirrorOptions) namedFlags(cmd *cobra.Command) bool {
+	for _, f := range []string{"type", "to-port", "vlan", "remote-ip", "source-ip", "tunnel-id", "attach-port", "attach-rule"} {
+		if cmd.Flags().Changed(f) {
+			return true
+		}
+	}
+	return false
 }
 
 func NewCreateMirrorCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := CreateMirrorOptions{}
 
 	var createmirrorCmd = &cobra.Command{
-		Use:   "mirror <mirrorIdent> --mirrorInfo=<InfoOption>:<InfoValue>,... --targetObject=attachement:<port1,rule2>,mirrObjName:<ObjectName>",
+		Use:   "mirror <mirrorIdent> --type=<span|rspan|erspan> [--to-port=<port>] [--vlan=<vlan>] [--remote-ip=<ip>] [--source-ip=<ip>] [--tunnel-id=<id>] --attach-port=<port>|--attach-rule=<lb name>",
 		Short: "Create a Mirror",
 		Long: `Create a Mirror using LoxiLB
+--type : Mirroring type, span(default), rspan or erspan
+--to-port : The port where mirrored traffic needs to be sent (required for span and rspan)
+--vlan : Vlan of tagged mirror traffic (required for rspan)
+--remote-ip, --source-ip, --tunnel-id : Tunnel of mirror traffic (required for erspan)
+--attach-port : Port to be mirrored
+--attach-rule : Name of the load balancer rule to be mirrored
+
+The raw --mirrorInfo and --targetObject options are still accepted
 --<infoOption>s of mirrorInfo
 type(int) : Mirroring type as like 0 == SPAN, 1 == RSPAN, 2 == ERSPAN 
 port(string) : The port where mirrored traffic needs to be sent
@@ -51,7 +78,10 @@ sourceIP(string): For ERSPAN we may need to send tunnelled mirror traffic
 tunnelID(int): For ERSPAN we may need to send tunnelled mirror traffic
 
 
-ex) loxicmd create mirror mirr-1 --mirrorInfo="type:0,port:hs0" --targetObject="attachement:1,mirrObjName:hs1"
+ex) loxicmd create mirror mirr-1 --type=span --to-port=hs0 --attach-port=hs1
+    loxicmd create mirror mirr-2 --type=rspan --to-port=hs0 --vlan=100 --attach-rule=k8s-web
+    loxicmd create mirror mirr-3 --type=erspan --remote-ip=2001::2 --source-ip=2001::1 --tunnel-id=10 --attach-port=hs1
+    loxicmd create mirror mirr-1 --mirrorInfo="type:0,port:hs0" --targetObject="attachement:1,mirrObjName:hs1"
 
 `,
 		Aliases: []string{"mirror", "mirr", "mirrors"},
@@ -68,11 +98,26 @@ ex) loxicmd create mirror mirr-1 --mirrorInfo="type:0,port:hs0" --targetObject="
 				fmt.Printf("Error: %s\n", err.Error())
 				return
 			}
-			if err := GetMirrorInfoPairList(&mirrorMods, o.MirrInfo); err != nil {
-				fmt.Printf("Error: %s\n", err.Error())
-				return
+			if o.namedFlags(cmd) {
+				if len(o.MirrInfo) > 0 || len(o.TargerObj) > 0 {
+					fmt.Printf("Error: --mirrorInfo and --targetObject can't be used with the named mirror options\n")
+					return
+				}
+				if err := o.MakeMirrorMod(restOptions, &mirrorMods); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+			} else {
+				if err := GetMirrorInfoPairList(&mirrorMods, o.MirrInfo); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+				if err := GetTargetObjPairList(&mirrorMods, o.TargerObj); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
 			}
-			if err := GetTargetObjPairList(&mirrorMods, o.TargerObj); err != nil {
+			if err := mirrorMods.Validation(); err != nil {
 				fmt.Printf("Error: %s\n", err.Error())
 				return
 			}
@@ -94,6 +139,15 @@ ex) loxicmd create mirror mirr-1 --mirrorInfo="type:0,port:hs0" --targetObject="
 
 	createmirrorCmd.Flags().StringSliceVar(&o.MirrInfo, "mirrorInfo", o.MirrInfo, "Information about the mirror")
 	createmirrorCmd.Flags().StringSliceVar(&o.TargerObj, "targetObject", o.TargerObj, "Information about object to which mirror needs to be attached")
+	createmirrorCmd.Flags().StringVarP(&o.Type, "type", "", "span", "Mirroring type (span, rspan, erspan)")
+	createmirrorCmd.Flags().StringVarP(&o.ToPort, "to-port", "", "", "Port where mirrored traffic needs to be sent")
+	createmirrorCmd.Flags().IntVarP(&o.Vlan, "vlan", "", 0, "Vlan of tagged mirror traffic (rspan)")
+	createmirrorCmd.Flags().StringVarP(&o.RemoteIP, "remote-ip", "", "", "Remote IP of tunnelled mirror traffic (erspan)")
+	createmirrorCmd.Flags().StringVarP(&o.SourceIP, "source-ip", "", "", "Source IP of tunnelled mirror traffic (erspan)")
+	createmirrorCmd.Flags().IntVarP(&o.TunnelID, "tunnel-id", "", 0, "Tunnel ID of tunnelled mirror traffic (erspan)")
+	createmirrorCmd.Flags().StringVarP(&o.AttachPort, "attach-port", "", "", "Port to be mirrored")
+	createmirrorCmd.Flags().StringVarP(&o.AttachRule, "attach-rule", "", "", "Name of the load balancer rule to be mirrored")
+	createmirrorCmd.MarkFlagsMutuallyExclusive("attach-port", "attach-rule")
 
 	return createmirrorCmd
 }
@@ -109,9 +163,92 @@ func ReadCreateMirrorOptions(o *api.MirrMod, args []string) error {
 	return nil
 }
 
+// MakeMirrorMod fills the mirror info and target from the named options.
+func (o CreateMirrorOptions) MakeMirrorMod(restOptions *api.RESTOptions, mirr *api.MirrMod) error {
+	mirrType, err := api.MirrTypeFromString(o.Type)
+	if err != nil {
+		return err
+	}
+	mirr.Info = api.MirrInfo{
+		MirrType: mirrType,
+		MirrPort: o.ToPort,
+		MirrVlan: o.Vlan,
+		MirrRip:  o.RemoteIP,
+		MirrSip:  o.SourceIP,
+		MirrTid:  o.TunnelID,
+	}
+	switch {
+	case o.AttachPort != "":
+		mirr.Target = api.MirrObj{MirrObjName: o.AttachPort, AttachMent: api.MirrAttachPort}
+	case o.AttachRule != "":
+		target, err := LbRuleMirrorTarget(restOptions, o.AttachRule)
+		if err != nil {
+			return err
+		}
+		mirr.Target = target
+	default:
+		return errors.New("mirror needs --attach-port or --attach-rule")
+	}
+	return nil
+}
+
+// LbRuleMirrorTarget returns the mirror target that attaches to the load
+// balancer rule with the service name. loxilb identifies rule targets by the
+// service name, so the lookup only checks that exactly one rule has it.
+func LbRuleMirrorTarget(restOptions *api.RESTOptions, name string) (api.MirrObj, error) {
+	service, err := ResolveLbRuleName(restOptions, name)
+	if err != nil {
+		return api.MirrObj{}, err
+	}
+	return api.MirrObj{MirrObjName: service.Name, AttachMent: api.MirrAttachRule}, nil
+}
+
+// ResolveLbRuleName returns the load balancer rule with the service name.
+// It fails when no rule or more than one rule has the name.
+func ResolveLbRuleName(restOptions *api.RESTOptions, name string) (api.LoadBalancerService, error) {
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.LoadBalancerAll().Get(ctx)
+	if err != nil {
+		return api.LoadBalancerService{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return api.LoadBalancerService{}, fmt.Errorf("failed to get load balancer rules: status %d", resp.StatusCode)
+	}
+	lbresp := api.LbRuleModGet{}
+	if err := json.NewDecoder(resp.Body).Decode(&lbresp); err != nil {
+		return api.LoadBalancerService{}, fmt.Errorf("failed to unmarshal HTTP response: (%s)", err.Error())
+	}
+
+	var found []api.LoadBalancerService
+	for _, lb := range lbresp.LbRules {
+		if lb.Service.Name == name {
+			found = append(found, lb.Service)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return api.LoadBalancerService{}, fmt.Errorf("load balancer rule '%s' is not found", name)
+	case 1:
+		return found[0], nil
+	}
+	var keys []string
+	for _, svc := range found {
+		keys = append(keys, fmt.Sprintf("%s:%s/%d", svc.ExternalIP, svc.Protocol, svc.Port))
+	}
+	return api.LoadBalancerService{}, fmt.Errorf("load balancer rule name '%s' matches %d rules (%s)", name, len(found), strings.Join(keys, ", "))
+}
+
 func GetMirrorInfoPairList(o *api.MirrMod, MirrInfo []string) error {
 	for _, mirrorArg := range MirrInfo {
-		mirrorArgsPair := strings.Split(mirrorArg, ":")
+		// split on the first ':' only so that IPv6 addresses are kept
+		mirrorArgsPair := strings.SplitN(mirrorArg, ":", 2)
 		if len(mirrorArgsPair) != 2 {
 			return fmt.Errorf("mirrorArgs '%s' is invalid format", MirrInfo)
 		} else if mirrorArgsPair[0] == "type" {
@@ -146,7 +283,7 @@ func GetMirrorInfoPairList(o *api.MirrMod, MirrInfo []string) error {
 
 func GetTargetObjPairList(o *api.MirrMod, TargerObj []string) error {
 	for _, mirrorArg := range TargerObj {
-		mirrorArgsPair := strings.Split(mirrorArg, ":")
+		mirrorArgsPair := strings.SplitN(mirrorArg, ":", 2)
 		if len(mirrorArgsPair) != 2 {
 			return fmt.Errorf("TargerObj '%s' is invalid format", TargerObj)
 		} else if mirrorArgsPair[0] == "mirrObjName" {
@@ -24,7 +24,6 @@ import (
 	"loxicmd/pkg/api"
 	"net/http"
 	"os"
-	"strconv"
 	"strings"
 	"time"
 
@@ -37,7 +36,7 @@ type CreatePolicyOptions struct {
 	Block   string
 	Target  string
 	Color   bool
-	PolType int
+	PolType string
 }
 
 type CreatePolicyResult struct {
@@ -63,17 +62,17 @@ func GetRatePair(body *api.PolMod, RateBlock string) error {
 	if len(RatePair) != 2 {
 		return errors.New("lots of args for rate")
 	}
-	Peak, err := strconv.Atoi(RatePair[0])
+	Peak, err := api.ParseRate(RatePair[0])
 	if err != nil {
-		return fmt.Errorf("peak '%s' is not integer", RatePair[0])
+		return fmt.Errorf("peak %s", err.Error())
 	}
 
-	Commited, err := strconv.Atoi(RatePair[1])
+	Commited, err := api.ParseRate(RatePair[1])
 	if err != nil {
-		return fmt.Errorf("commited '%s' is not integer", RatePair[1])
+		return fmt.Errorf("commited %s", err.Error())
 	}
-	body.Info.CommittedInfoRate = uint64(Commited)
-	body.Info.PeakInfoRate = uint64(Peak)
+	body.Info.CommittedInfoRate = Commited
+	body.Info.PeakInfoRate = Peak
 	return nil
 }
 
@@ -85,32 +84,41 @@ func GetBlockPair(body *api.PolMod, Block string) error {
 	if len(BlockPair) != 2 {
 		return errors.New("error in block size args")
 	}
-	Excess, err := strconv.Atoi(BlockPair[0])
+	Excess, err := api.ParseSize(BlockPair[0])
 	if err != nil {
-		return fmt.Errorf("excess '%s' is not integer", BlockPair[0])
+		return fmt.Errorf("excess %s", err.Error())
 	}
 
-	Commited, err := strconv.Atoi(BlockPair[1])
+	Commited, err := api.ParseSize(BlockPair[1])
 	if err != nil {
-		return fmt.Errorf("commited '%s' is not integer", BlockPair[1])
+		return fmt.Errorf("commited %s", err.Error())
 	}
-	body.Info.ExcessBlkSize = uint64(Excess)
-	body.Info.CommittedBlkSize = uint64(Commited)
+	body.Info.ExcessBlkSize = Excess
+	body.Info.CommittedBlkSize = Commited
 	return nil
 }
 
-func GetTargetPair(body *api.PolMod, Block string) error {
-	BlockPair := strings.Split(Block, ":")
-	if len(BlockPair) != 2 {
+// GetTargetPair parses '<ObjectName>:<Attachment>'. An LB rule target is
+// looked up by its service name so that it must exist.
+func GetTargetPair(restOptions *api.RESTOptions, body *api.PolMod, Block string) error {
+	idx := strings.LastIndex(Block, ":")
+	if idx <= 0 {
 		return errors.New("error in target args")
 	}
 
-	AttachMent, err := strconv.Atoi(BlockPair[1])
+	AttachMent, err := api.ParsePolObjType(Block[idx+1:])
 	if err != nil {
-		return fmt.Errorf("AttachMent '%s' is not integer", BlockPair[1])
+		return err
+	}
+	body.Target.PolObjName = Block[:idx]
+	body.Target.AttachMent = AttachMent
+	if AttachMent == api.PolAttachLbRule {
+		service, err := ResolveLbRuleName(restOptions, body.Target.PolObjName)
+		if err != nil {
+			return err
+		}
+		body.Target.PolObjName = service.Name
 	}
-	body.Target.PolObjName = BlockPair[0]
-	body.Target.AttachMent = api.PolObjType(AttachMent)
 	return nil
 }
 
@@ -122,13 +130,17 @@ func NewCreatePolicyCmd(restOptions *api.RESTOptions) *cobra.Command {
 		Short: "Create a Policy",
 		Long: `Create a Policy 
 Ex) loxicmd create policy pol-hs0 --rate=100:100 --target=hs0:1
+    loxicmd create policy pol-hs0 --rate=1Gbps:500Mbps --target=hs0:port
     loxicmd create policy pol-hs1 --rate=100:100 --target=hs0:1 --block-size=12000:6000
+    loxicmd create policy pol-hs1 --rate=100:100 --target=hs0:1 --block-size=64KiB:32KiB
     loxicmd create policy pol-hs1 --rate=100:100 --target=hs0:1 --color
-    loxicmd create policy pol-hs1 --rate=100:100 --target=hs0:1 --color --pol-type 0
+    loxicmd create policy pol-hs1 --rate=100:100 --target=hs0:1 --color --pol-type srTCM
+    loxicmd create policy pol-web --rate=200Mbps:100Mbps --target=k8s-web:lb-rule
 
-rate unit : Mbps
-block-size unit : bps
-Policy type(pol-type) 0 : TrTCM,  1 : SrTCM
+rate unit : bps, Kbps, Mbps, Gbps or Tbps (a bare number is Mbps, the rate must be a multiple of 1Mbps)
+block-size unit : B, KB, KiB, MB, MiB, GB or GiB (a bare number is bytes)
+Policy type(pol-type) trTCM(0), srTCM(1)
+Attachment port(1), lb-rule(2). An lb-rule target is the service name of an existing LB rule.
 
 	`,
 		Aliases: []string{"pol", "policys", "pols", "polices"},
@@ -156,12 +168,21 @@ Policy type(pol-type) 0 : TrTCM,  1 : SrTCM
 				return
 			}
 
-			if err := GetTargetPair(&body, o.Target); err != nil {
+			if err := GetTargetPair(restOptions, &body, o.Target); err != nil {
 				fmt.Printf("Target Error: %s\n", err.Error())
 				return
 			}
+			polType, err := api.ParsePolType(o.PolType)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
 			body.Info.ColorAware = o.Color
-			body.Info.PolType = o.PolType
+			body.Info.PolType = polType
+			if err := body.Validation(); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
 			resp, err := PolicyAPICall(restOptions, body)
 			if err != nil {
 				fmt.Printf("Error: %s\n", err.Error())
@@ -179,10 +200,10 @@ Policy type(pol-type) 0 : TrTCM,  1 : SrTCM
 	}
 
 	createPolCmd.Flags().StringVar(&o.Rate, "rate", o.Rate, "Rate pairs can be specified as '<Peak>:<Commited>'")
-	createPolCmd.Flags().StringVar(&o.Block, "block-size", o.Block, "Block Size pairs can be specified as '<Excess>:<Committed>'")
-	createPolCmd.Flags().StringVar(&o.Target, "target", o.Target, "Target Interface pairs can be specified as '<ObjectName>:<Attachment>'")
+	createPolCmd.Flags().StringVar(&o.Block, "block-size", o.Block, "Block Size pairs can be specified as '<Excess>:<Committed>' (ex) 64KiB:32KiB")
+	createPolCmd.Flags().StringVar(&o.Target, "target", o.Target, "Target pairs can be specified as '<ObjectName>:<port|lb-rule>'")
 	createPolCmd.Flags().BoolVarP(&o.Color, "color", "", false, "Policy color enbale or not")
-	createPolCmd.Flags().IntVar(&o.PolType, "pol-type", o.PolType, "Target Interface pairs can be specified as '<ObjectName>:<Attachment>'")
+	createPolCmd.Flags().StringVar(&o.PolType, "pol-type", "trTCM", "Policy type trTCM or srTCM")
 
 	return createPolCmd
 }
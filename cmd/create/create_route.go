@@ -20,7 +20,6 @@ import (
 	"errors"
 	"fmt"
 	"loxicmd/pkg/api"
-	"net"
 	"net/http"
 	"os"
 	"time"
@@ -38,10 +37,14 @@ func NewCreateRouteCmd(restOptions *api.RESTOptions) *cobra.Command {
 		Use:   "route <DestinationIPNet> <gateway> --proto=<protocol>",
 		Short: "Create a Route",
 		Long: `Create a Route using LoxiLB. It is working as "ip route add <DestinationIPNet> via <gateway> proto <protocol>"
+A route has a single gateway as the route API of LoxiLB has no ECMP next-hops.
+DestinationIPNet and gateway can be IPv4 or IPv6 of the same family.
 	
 ex) loxicmd create route 192.168.212.0/24 172.17.0.254 --proto=static
     loxicmd create route 192.168.212.0/24 172.17.0.254
+    loxicmd create route 2001:db8::/64 fe80::1
 `,
+		Args: cobra.MaximumNArgs(2),
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				cmd.Help()
@@ -74,26 +77,19 @@ ex) loxicmd create route 192.168.212.0/24 172.17.0.254 --proto=static
 }
 
 func ReadCreateRouteOptions(o *api.Routev4Get, args []string, opts CreateRouteStaticOptions) error {
-	if len(args) > 3 {
-		return errors.New("create Route command get so many args")
-	} else if len(args) <= 1 {
+	if len(args) <= 1 {
 		return errors.New("create Route need <DestinationIPNet> and <gateway> args")
 	}
 
-	if _, _, err := net.ParseCIDR(args[0]); err != nil {
-		return fmt.Errorf("DestinationIPNet '%s' is invalid format", args[0])
-	}
-	o.Dst = args[0]
-
-	if val := net.ParseIP(args[1]); val != nil {
-		o.Gw = args[1]
-	} else {
-		return fmt.Errorf("gateway IP '%s' is invalid format", args[1])
-	}
-
+	protocol := ""
 	if opts.StaticProto == "static" {
-		o.Protocol = opts.StaticProto
+		protocol = opts.StaticProto
+	}
+	route, err := api.MakeRoute(args[0], args[1], protocol)
+	if err != nil {
+		return err
 	}
+	*o = route
 
 	return nil
 }
@@ -23,8 +23,6 @@ import (
 	"net"
 	"net/http"
 	"os"
-	"strconv"
-	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -35,27 +33,54 @@ type CreateSessionOptions struct {
 	SessionIP string
 	ANTunnel  string
 	CNTunnel  string
+	File      string
+	Workers   int
 }
 
 func NewCreateSessionCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := CreateSessionOptions{}
 
 	var createSessionCmd = &cobra.Command{
-		Use:   "session <userID> <sessionIP> --accessNetworkTunnel=<TeID>:<TunnelIP> --coreNetworkTunnel=<TeID>:<TunnelIP>",
+		Use:   "session <userID> <sessionIP> --accessNetworkTunnel=<TeID>:<TunnelIP> --coreNetworkTunnel=<TeID>:<TunnelIP> | --file=<file.csv|file.yaml> [--workers=<N>]",
 		Short: "Create a Session",
 		Long: `Create a Session using LoxiLB
+An IPv6 tunnel IP is given as '<TeID>:<IPv6>' or '<TeID>:[<IPv6>]'.
+
+--file creates many sessions with a pool of --workers.
+  CSV  - one session per line as either of
+           <userID>,<sessionIP>,<accessTeID>,<accessTunnelIP>,<coreTeID>,<coreTunnelIP>
+           <userID>,<sessionIP>,<accessTeID>:<accessTunnelIP>,<coreTeID>:<coreTunnelIP>
+         A first line of these column names is skipped as the header.
+         CSV is read while the sessions are created, so it suits a very large file.
+  YAML - a list of sessions as "get session -o json" shows them. It is read as a whole first.
+           - ident: user1
+             sessionIP: 192.168.20.1
+             accessNetworkTunnel: {teID: 1, tunnelIP: 1.232.16.1}
+             coreNetworkTunnel: {teID: 1, tunnelIP: 1.233.16.1}
 		
 ex) loxicmd create session user1 192.168.20.1 --accessNetworkTunnel=1:1.232.16.1 --coreNetworkTunnel=1:1.233.16.1
+    loxicmd create session user2 2001:db8:20::1 --accessNetworkTunnel=2:2001:db8:16::1 --coreNetworkTunnel=2:[2001:db8:17::1]
+    loxicmd create session --file=ues.csv --workers=32
 
 		`,
 		Aliases: []string{"session", "sessions"},
 		PreRun: func(cmd *cobra.Command, args []string) {
-			if len(args) == 0 {
+			if len(args) == 0 && o.File == "" {
 				cmd.Help()
 				os.Exit(0)
 			}
 		},
 		Run: func(cmd *cobra.Command, args []string) {
+			if o.File != "" {
+				if len(args) > 0 {
+					fmt.Printf("Error: --file can't be used with <userID> <sessionIP> args\n")
+					return
+				}
+				if err := CreateSessionBulk(restOptions, o.File, o.Workers); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+				}
+				return
+			}
 			var SessionMod api.SessionMod
 			// Make SessionMod
 			if err := ReadCreateSessionOptions(&SessionMod, args); err != nil {
@@ -89,6 +114,8 @@ ex) loxicmd create session user1 192.168.20.1 --accessNetworkTunnel=1:1.232.16.1
 
 	createSessionCmd.Flags().StringVarP(&o.ANTunnel, "accessNetworkTunnel", "", "", "accessNetworkTunnel has pairs that can be specified as '<TeID>:<IP>'")
 	createSessionCmd.Flags().StringVarP(&o.CNTunnel, "coreNetworkTunnel", "", "", "coreNetworkTunnel has pairs that can be specified as '<TeID>:<IP>'")
+	createSessionCmd.Flags().StringVarP(&o.File, "file", "f", "", "CSV or YAML file of sessions to create")
+	createSessionCmd.Flags().IntVarP(&o.Workers, "workers", "", 16, "Number of parallel create requests with --file")
 
 	return createSessionCmd
 }
@@ -110,30 +137,14 @@ func ReadCreateSessionOptions(o *api.SessionMod, args []string) error {
 }
 
 func GetNetworkTunnelPairList(o *api.SessionMod, networkTunnel string, An bool) error {
-	networkTunnelPair := strings.Split(networkTunnel, ":")
-	if len(networkTunnelPair) != 2 {
-		return fmt.Errorf("networkTunnel '%s' is invalid format", networkTunnel)
-	}
-
-	// 0 is TeID, 1 is TunnelIP
-	TeID, err := strconv.Atoi(networkTunnelPair[0])
+	tun, err := api.ParseSessTun(networkTunnel)
 	if err != nil {
-		return fmt.Errorf("networkTunnel's TeID '%s' is invalid format", networkTunnelPair[0])
+		return fmt.Errorf("networkTunnel '%s' is invalid format: %s", networkTunnel, err.Error())
 	}
 	if An {
-		o.AnTun.TeID = uint32(TeID)
-		if val := net.ParseIP(networkTunnelPair[1]); val != nil {
-			o.AnTun.Addr = val
-		} else {
-			return fmt.Errorf("tunnel IP '%s' is invalid format", networkTunnelPair[1])
-		}
+		o.AnTun = tun
 	} else {
-		o.CnTun.TeID = uint32(TeID)
-		if val := net.ParseIP(networkTunnelPair[1]); val != nil {
-			o.CnTun.Addr = val
-		} else {
-			return fmt.Errorf("tunnel IP '%s' is invalid format", networkTunnelPair[1])
-		}
+		o.CnTun = tun
 	}
 
 	return nil
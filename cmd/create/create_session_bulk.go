@@ -0,0 +1,246 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package create
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"loxicmd/pkg/api"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v2"
+)
+
+// sessionBulkProgress - interval to print the progress of a bulk creation
+const sessionBulkProgress = 5 * time.Second
+
+type sessionJob struct {
+	line    int
+	session api.SessionMod
+}
+
+// CreateSessionBulk creates the sessions of a CSV or YAML file with a pool of workers.
+// A CSV file is read while the sessions are created, so a CSV file of any size is fine.
+// A YAML file is a single list, which is read into memory as a whole first.
+func CreateSessionBulk(restOptions *api.RESTOptions, file string, workers int) error {
+	if workers <= 0 {
+		workers = 1
+	}
+	// Keep a connection to the API server per worker instead of reconnecting per session
+	if t, ok := http.DefaultTransport.(*http.Transport); ok && restOptions.Transport == nil {
+		t = t.Clone()
+		t.MaxIdleConnsPerHost = workers
+		opts := *restOptions
+		opts.Transport = t
+		restOptions = &opts
+	}
+
+	var created, failed, invalid int64
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan sessionJob, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if err := CheckFileResp(SessionAPICall(restOptions, job.session)); err != nil {
+					atomic.AddInt64(&failed, 1)
+					mutex.Lock()
+					fmt.Printf("Error: line %d: failed to create session '%s': %s\n", job.line, job.session.Ident, err.Error())
+					mutex.Unlock()
+					continue
+				}
+				atomic.AddInt64(&created, 1)
+			}
+		}()
+	}
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(sessionBulkProgress)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				mutex.Lock()
+				fmt.Printf("%d created, %d failed so far\n", atomic.LoadInt64(&created), atomic.LoadInt64(&failed))
+				mutex.Unlock()
+			}
+		}
+	}()
+
+	idents := map[string]int{}
+	err := ReadSessionFile(file, func(line int, s api.SessionMod, err error) {
+		if err == nil {
+			err = s.Validation()
+		}
+		if err == nil {
+			if prev, ok := idents[s.Ident]; ok {
+				err = fmt.Errorf("ident '%s' is a duplicate of line %d", s.Ident, prev)
+			}
+		}
+		if err != nil {
+			atomic.AddInt64(&invalid, 1)
+			mutex.Lock()
+			fmt.Printf("Error: line %d: %s\n", line, err.Error())
+			mutex.Unlock()
+			return
+		}
+		idents[s.Ident] = line
+		jobs <- sessionJob{line: line, session: s}
+	})
+	close(jobs)
+	wg.Wait()
+	close(done)
+	if err != nil && created+failed+invalid == 0 {
+		return err
+	}
+
+	elapsed := time.Since(start)
+	fmt.Printf("%d created, %d failed, %d invalid in %s (%.0f/s)\n", created, failed, invalid,
+		elapsed.Round(time.Millisecond), float64(created)/elapsed.Seconds())
+	return err
+}
+
+// ReadSessionFile reads the sessions of a CSV file, or of a YAML file by the extension.
+// fn is called with the line number, or the entry number for YAML, of each session.
+// CSV is read line by line, while the YAML list is unmarshaled at once.
+func ReadSessionFile(file string, fn func(line int, s api.SessionMod, err error)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yaml", ".yml":
+		var sessions []api.SessionMod
+		buf, err := io.ReadAll(f)
+		if err != nil {
+			return err
+		}
+		if err := yaml.Unmarshal(buf, &sessions); err != nil {
+			return fmt.Errorf("failed to read %s: %s", file, err.Error())
+		}
+		for i, s := range sessions {
+			fn(i+1, s, nil)
+		}
+		return nil
+	}
+	return readSessionCSV(f, fn)
+}
+
+func readSessionCSV(r io.Reader, fn func(line int, s api.SessionMod, err error)) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	first := true
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				fn(parseErr.Line, api.SessionMod{}, parseErr.Err)
+				continue
+			}
+			return err
+		}
+		line, _ := reader.FieldPos(0)
+		if first && isSessionCSVHeader(record) {
+			first = false
+			continue
+		}
+		first = false
+		s, err := makeSessionOfRecord(record)
+		fn(line, s, err)
+	}
+}
+
+// sessionCSVHeader - column names of a CSV header by the number of columns.
+// A name matches case-insensitively without spaces, '_', '-' and ':'.
+var sessionCSVHeader = map[int][][]string{
+	4: {{"userid", "ident"}, {"sessionip"}, {"accessteidaccesstunnelip", "accessnetworktunnel", "accesstunnel"},
+		{"coreteidcoretunnelip", "corenetworktunnel", "coretunnel"}},
+	6: {{"userid", "ident"}, {"sessionip"}, {"accessteid"}, {"accesstunnelip"}, {"coreteid"}, {"coretunnelip"}},
+}
+
+// isSessionCSVHeader returns true when every column of the record is a known column name
+func isSessionCSVHeader(record []string) bool {
+	names, ok := sessionCSVHeader[len(record)]
+	if !ok {
+		return false
+	}
+	normalize := strings.NewReplacer(" ", "", "_", "", "-", "", ":", "")
+	for i, field := range record {
+		field = strings.ToLower(normalize.Replace(strings.TrimSpace(field)))
+		known := false
+		for _, name := range names[i] {
+			if field == name {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return false
+		}
+	}
+	return true
+}
+
+func makeSessionOfRecord(record []string) (api.SessionMod, error) {
+	s := api.SessionMod{}
+	var err error
+	switch len(record) {
+	case 4:
+		if s.AnTun, err = api.ParseSessTun(record[2]); err != nil {
+			return s, err
+		}
+		if s.CnTun, err = api.ParseSessTun(record[3]); err != nil {
+			return s, err
+		}
+	case 6:
+		if s.AnTun, err = api.MakeSessTun(record[2], record[3]); err != nil {
+			return s, err
+		}
+		if s.CnTun, err = api.MakeSessTun(record[4], record[5]); err != nil {
+			return s, err
+		}
+	default:
+		return s, fmt.Errorf("%d fields. it should have 4 or 6 fields", len(record))
+	}
+	s.Ident = strings.TrimSpace(record[0])
+	if s.Ip = net.ParseIP(strings.TrimSpace(record[1])); s.Ip == nil {
+		return s, fmt.Errorf("session IP '%s' is invalid format", record[1])
+	}
+	return s, nil
+}
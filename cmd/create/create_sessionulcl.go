@@ -42,8 +42,10 @@ func NewCreateSessionUlClCmd(restOptions *api.RESTOptions) *cobra.Command {
 		Use:   "sessionulcl <userID> --ulclArgs=<QFI>:<ulclIP>,... ",
 		Short: "Create a Session UlCl",
 		Long: `Create a Session UlCl using LoxiLB
+An IPv6 ulclIP is given as '<QFI>:<IPv6>' or '<QFI>:[<IPv6>]'.
 
 ex) loxicmd create sessionulcl user1 --ulclArgs=16:192.33.125.1
+    loxicmd create sessionulcl user1 --ulclArgs=17:2001:db8::1,18:[2001:db8::2]
 		`,
 		Aliases: []string{"ulcl", "sessionulcls", "ulcls"},
 		PreRun: func(cmd *cobra.Command, args []string) {
@@ -73,11 +75,11 @@ ex) loxicmd create sessionulcl user1 --ulclArgs=16:192.33.125.1
 				defer resp.Body.Close()
 
 				fmt.Printf("Debug: response.StatusCode: %d\n", resp.StatusCode)
-				if resp.StatusCode == http.StatusOK {
-					PrintCreateResult(resp, *restOptions)
+				if resp.StatusCode != http.StatusOK {
+					fmt.Printf("Error: failed to create ULCL %s: %s\n", SessionMod.Args.Addr.String(), resp.Status)
 					return
 				}
-
+				PrintCreateResult(resp, *restOptions)
 			}
 
 		},
@@ -105,21 +107,21 @@ func ReadCreateSessionUlClOptions(o *api.UlclInformationGet, args []string, numU
 
 func GetUlClArgsPairList(o *api.UlclInformationGet, ulclArgs []string) error {
 	for i, ulclArg := range ulclArgs {
-		ulclArgsPair := strings.Split(ulclArg, ":")
-		if len(ulclArgsPair) != 2 {
+		// Split on the first colon only as an IPv6 ulclIP has colons
+		qfiStr, ipStr, ok := strings.Cut(ulclArg, ":")
+		if !ok {
 			return fmt.Errorf("ulclArgs '%s' is invalid format", ulclArgs)
 		}
 
-		// 0 is TeID, 1 is TunnelIP
-		qfi, err := strconv.Atoi(ulclArgsPair[0])
+		qfi, err := strconv.ParseUint(qfiStr, 10, 8)
 		if err != nil {
-			return fmt.Errorf("ulclArgs's QFI '%s' is invalid format", ulclArgsPair[0])
+			return fmt.Errorf("ulclArgs's QFI '%s' is invalid format", qfiStr)
 		}
 		o.UlclInfo[i].Args.Qfi = uint8(qfi)
-		if val := net.ParseIP(ulclArgsPair[1]); val != nil {
+		if val := net.ParseIP(strings.Trim(ipStr, "[]")); val != nil {
 			o.UlclInfo[i].Args.Addr = val
 		} else {
-			return fmt.Errorf("ulclArgs's IP '%s' is invalid format", ulclArgsPair[1])
+			return fmt.Errorf("ulclArgs's IP '%s' is invalid format", ipStr)
 		}
 
 	}
@@ -41,16 +41,15 @@ Delete - Service type external load-balancer, Vlan, Vxlan, Qos Policies,
 					fmt.Printf("Configuration applied - %s\n", NormalConfigFile)
 				}
 			}
-			if len(NormalConfigFile) == 0 && len(args) == 0 {
+			if len(NormalConfigFile) == 0 {
+				// Only one of Run and RunE is run by cobra, so an unknown command is handled here
+				if len(args) > 0 {
+					fmt.Printf("Error: unknown command \"%v\"for \"loxicmd\" \nRun \"loxicmd --help\" for usage.\n", args)
+				}
 				cmd.Help()
 			}
 
 		},
-		RunE: func(cmd *cobra.Command, args []string) (err error) {
-			fmt.Printf("Error: unknown command \"%v\"for \"loxicmd\" \nRun \"loxicmd --help\" for usage.\n", args)
-			cmd.Help()
-			return err
-		},
 	}
 	deleteCmd.AddCommand(NewDeleteLoadBalancerCmd(restOptions))
 	deleteCmd.AddCommand(NewDeleteSessionCmd(restOptions))
@@ -68,6 +67,7 @@ Delete - Service type external load-balancer, Vlan, Vxlan, Qos Policies,
 	deleteCmd.AddCommand(NewDeleteFirewallCmd(restOptions))
 	deleteCmd.AddCommand(NewDeleteEndPointCmd(restOptions))
 	deleteCmd.AddCommand(NewDeleteBGPNeighborCmd(restOptions))
+	deleteCmd.AddCommand(NewDeleteBGPCmd(restOptions))
 	deleteCmd.AddCommand(NewDeleteBFDCmd(restOptions))
 
 	deleteCmd.Flags().StringVarP(&NormalConfigFile, "file", "f", "", "Config file to apply as like K8s")
@@ -0,0 +1,87 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package delete
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"loxicmd/pkg/api"
+
+	"github.com/spf13/cobra"
+)
+
+func NewDeleteBGPCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var deleteBGPCmd = &cobra.Command{
+		Use:   "bgp",
+		Short: "Delete BGP features",
+		Long: `Delete BGP features in the LoxiLB.
+	policy - import and export policies
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+		},
+	}
+
+	deleteBGPCmd.AddCommand(NewDeleteBGPPolicyCmd(restOptions))
+	return deleteBGPCmd
+}
+
+func NewDeleteBGPPolicyCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var deleteBGPPolicyCmd = &cobra.Command{
+		Use:   "policy <name>",
+		Short: "Delete a BGP policy",
+		Long: `Delete a BGP import or export policy in the LoxiLB.
+
+ex) loxicmd delete bgp policy vip-med
+`,
+		Aliases: []string{"policies", "pol"},
+		Args:    cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			resp, err := client.BGPPolicy().SubResources([]string{"name", args[0]}).Delete(ctx)
+			if err != nil {
+				fmt.Printf("Error: Failed to delete BGP policy : %s\n", args[0])
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				PrintDeleteResult(resp, *restOptions)
+				return
+			}
+			fmt.Printf("Error: failed to delete BGP policy %s: %s\n", args[0], resp.Status)
+		},
+	}
+
+	return deleteBGPPolicyCmd
+}
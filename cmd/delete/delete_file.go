@@ -22,6 +22,7 @@ import (
 	"strconv"
 	"time"
 
+	"loxicmd/cmd/create"
 	"loxicmd/pkg/api"
 
 	"gopkg.in/yaml.v2"
@@ -75,6 +76,12 @@ func DeleteFileConfig(file string, restOptions *api.RESTOptions) error {
 		err = VxlanDeleteWithFile(restOptions, byteBuf)
 	case "bfd", "BFD":
 		err = BFDDeleteWithFile(restOptions, byteBuf)
+	case "BGPNeighbor", "bgpneighbor", "bgpnei", "bgpneigh":
+		err = BGPNeighborDeleteWithFile(restOptions, byteBuf)
+	case "BGPPolicy", "bgppolicy", "bgp-policy":
+		err = BGPPolicyDeleteWithFile(restOptions, byteBuf)
+	case "Topology", "topology", "topo":
+		err = TopologyDeleteWithFile(restOptions, byteBuf)
 	default:
 		fmt.Printf("Not Supported\n")
 	}
@@ -161,15 +168,18 @@ func IPv4AddressDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) err
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
+	return deleteIPv4Address(restOptions, c.Spec)
+}
+
+func deleteIPv4Address(restOptions *api.RESTOptions, spec api.Ipv4AddrMod) error {
 	client, ctx, cancel := GetClientWithCtx(restOptions)
 	if restOptions.Timeout > 0 {
 		defer cancel()
 	}
 	subResources := []string{
-		c.Spec.IP, "dev", c.Spec.Dev,
+		spec.IP, "dev", spec.Dev,
 	}
-	_, err := client.IPv4Address().SubResources(subResources).Delete(ctx)
-	if err != nil {
+	if err := create.CheckFileResp(client.IPv4Address().SubResources(subResources).Delete(ctx)); err != nil {
 		fmt.Printf("Error: Failed to delete IPv4Address\n")
 		return err
 	}
@@ -357,15 +367,18 @@ func VlanDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
+	return deleteVlan(restOptions, c.Spec)
+}
+
+func deleteVlan(restOptions *api.RESTOptions, spec api.VlanBridgeMod) error {
 	client, ctx, cancel := GetClientWithCtx(restOptions)
 	if restOptions.Timeout > 0 {
 		defer cancel()
 	}
 	subResources := []string{
-		strconv.Itoa(c.Spec.Vid),
+		strconv.Itoa(spec.Vid),
 	}
-	_, err := client.Vlan().SubResources(subResources).Delete(ctx)
-	if err != nil {
+	if err := create.CheckFileResp(client.Vlan().SubResources(subResources).Delete(ctx)); err != nil {
 		fmt.Printf("Error: Failed to delete Vlan\n")
 		return err
 	}
@@ -377,16 +390,19 @@ func VlanMemberDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) erro
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
+	return deleteVlanMember(restOptions, c.ObjectMeta.VlanID, c.Spec)
+}
+
+func deleteVlanMember(restOptions *api.RESTOptions, vlanID int, spec api.VlanMemberMod) error {
 	client, ctx, cancel := GetClientWithCtx(restOptions)
 	if restOptions.Timeout > 0 {
 		defer cancel()
 	}
-	Tagged := fmt.Sprintf("%v", c.Spec.Tagged)
+	Tagged := fmt.Sprintf("%v", spec.Tagged)
 	subResources := []string{
-		strconv.Itoa(c.ObjectMeta.VlanID), "member", c.Spec.Dev, "tagged", Tagged,
+		strconv.Itoa(vlanID), "member", spec.Dev, "tagged", Tagged,
 	}
-	_, err := client.Vlan().SubResources(subResources).Delete(ctx)
-	if err != nil {
+	if err := create.CheckFileResp(client.Vlan().SubResources(subResources).Delete(ctx)); err != nil {
 		fmt.Printf("Error: Failed to delete Vlan\n")
 		return err
 	}
@@ -398,16 +414,19 @@ func VxlanDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
+	return deleteVxlan(restOptions, c.Spec)
+}
+
+func deleteVxlan(restOptions *api.RESTOptions, spec api.VxlanBridgeMod) error {
 	client, ctx, cancel := GetClientWithCtx(restOptions)
 	if restOptions.Timeout > 0 {
 		defer cancel()
 	}
 
 	subResources := []string{
-		strconv.Itoa(c.Spec.VxLanID),
+		strconv.Itoa(spec.VxLanID),
 	}
-	_, err := client.Vxlan().SubResources(subResources).Delete(ctx)
-	if err != nil {
+	if err := create.CheckFileResp(client.Vxlan().SubResources(subResources).Delete(ctx)); err != nil {
 		fmt.Printf("Error: Failed to delete Vxlan\n")
 		return err
 	}
@@ -419,16 +438,19 @@ func VxlanPeerDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) error
 	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
 		return err
 	}
+	return deleteVxlanPeer(restOptions, c.ObjectMeta.VxlanID, c.Spec)
+}
+
+func deleteVxlanPeer(restOptions *api.RESTOptions, vxlanID int, spec api.VxlanPeerMod) error {
 	client, ctx, cancel := GetClientWithCtx(restOptions)
 	if restOptions.Timeout > 0 {
 		defer cancel()
 	}
 
 	subResources := []string{
-		strconv.Itoa(c.ObjectMeta.VxlanID), "peer", c.Spec.PeerIP,
+		strconv.Itoa(vxlanID), "peer", spec.PeerIP,
 	}
-	_, err := client.Vxlan().SubResources(subResources).Delete(ctx)
-	if err != nil {
+	if err := create.CheckFileResp(client.Vxlan().SubResources(subResources).Delete(ctx)); err != nil {
 		fmt.Printf("Error: Failed to delete Vxlan\n")
 		return err
 	}
@@ -460,3 +482,85 @@ func BFDDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
 	defer resp.Body.Close()
 	return nil
 }
+
+func BGPNeighborDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
+	var c api.ConfigurationBGPFile
+	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
+		return err
+	}
+	client, ctx, cancel := GetClientWithCtx(restOptions)
+	if restOptions.Timeout > 0 {
+		defer cancel()
+	}
+	qmap := map[string]string{"remoteAs": strconv.Itoa(c.Spec.RemoteAs)}
+	_, err := client.BGPNeighbor().SubResources([]string{c.Spec.IPaddress}).Query(qmap).Delete(ctx)
+	if err != nil {
+		fmt.Printf("Error: Failed to delete BGPNeighbor\n")
+		return err
+	}
+	return nil
+}
+
+func BGPPolicyDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
+	var c api.ConfigurationBGPPolicyFile
+	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
+		return err
+	}
+	client, ctx, cancel := GetClientWithCtx(restOptions)
+	if restOptions.Timeout > 0 {
+		defer cancel()
+	}
+	_, err := client.BGPPolicy().SubResources([]string{"name", c.Spec.Name}).Delete(ctx)
+	if err != nil {
+		fmt.Printf("Error: Failed to delete BGP policy\n")
+		return err
+	}
+	return nil
+}
+
+// TopologyDeleteWithFile tears down a topology in the reverse order of the creation
+func TopologyDeleteWithFile(restOptions *api.RESTOptions, byteBuf []byte) error {
+	var c api.ConfigurationTopologyFile
+	if err := yaml.Unmarshal(byteBuf, &c); err != nil {
+		return err
+	}
+	if err := c.Spec.Validation(); err != nil {
+		return err
+	}
+	return DeleteTopologyObjects(restOptions, c.Spec.Objects())
+}
+
+// DeleteTopologyObjects deletes the objects of a topology in the reverse order.
+// It goes on after a failure so that a partly created topology is torn down as much as possible.
+func DeleteTopologyObjects(restOptions *api.RESTOptions, objs []api.TopologyObject) error {
+	failed := 0
+	for i := len(objs) - 1; i >= 0; i-- {
+		obj := objs[i]
+		if err := deleteTopologyObject(restOptions, obj); err != nil {
+			fmt.Printf("Error: failed to delete %s: %s\n", obj.Name, err.Error())
+			failed++
+			continue
+		}
+		fmt.Printf("%s deleted\n", obj.Name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d objects", failed, len(objs))
+	}
+	return nil
+}
+
+func deleteTopologyObject(restOptions *api.RESTOptions, obj api.TopologyObject) error {
+	switch c := obj.File.(type) {
+	case api.ConfigurationVlanFile:
+		return deleteVlan(restOptions, c.Spec)
+	case api.ConfigurationVlanMemberFile:
+		return deleteVlanMember(restOptions, c.ObjectMeta.VlanID, c.Spec)
+	case api.ConfigurationVxlanFile:
+		return deleteVxlan(restOptions, c.Spec)
+	case api.ConfigurationVxlanPeerFile:
+		return deleteVxlanPeer(restOptions, c.ObjectMeta.VxlanID, c.Spec)
+	case api.ConfigurationIPv4File:
+		return deleteIPv4Address(restOptions, c.Spec)
+	}
+	return fmt.Errorf("%s is not a kind of a topology", obj.Kind)
+}
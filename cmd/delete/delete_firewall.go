@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -30,14 +31,24 @@ import (
 
 type DeleteFirewallOptions struct {
 	FirewallRule []string
+	Match        string
+	Pref         uint16
 }
 
 func NewDeleteFirewallCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := DeleteFirewallOptions{}
 	var deleteFirewallCmd = &cobra.Command{
-		Use:   "firewall --firewallRule=<ruleKey>:<ruleValue>",
+		Use:   "firewall (--match=<expression> [--pref=<preference>] | --firewallRule=<ruleKey>:<ruleValue>)",
 		Short: "Delete a Firewall",
-		Long: `Delete a Firewall using ruleKey in the LoxiLB.
+		Long: `Delete a Firewall using ruleKey or match expression in the LoxiLB.
+
+--match expression fields
+src <ip|cidr> - Source IP or CIDR
+dst <ip|cidr> - Destination IP or CIDR
+sport <port|min-max> - Source port or port range
+dport <port|min-max> - Destination port or port range
+proto <name|number> - tcp, udp, icmp, icmpv6, sctp, any or the protocol number
+in <portName> - the incoming port
 
 --<ruleKey>s of firewallRule
 sourceIP(string) - Source IP in CIDR notation
@@ -46,15 +57,16 @@ minSourcePort(int) - Minimum source port range
 maxSourcePort(int) - Maximum source port range	
 minDestinationPort(int) - Minimum destination port range	
 maxDestinationPort(int) - Maximum source port range	
-protocol(int) - the protocol	
+protocol(int|string) - the protocol number or name	
 portName(string) - the incoming port	
 preference(int) - User preference for ordering	
 
-ex) loxicmd delete firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.3.1.2/32,preference:200"
+ex) loxicmd delete firewall --match="src 10.0.0.0/8 dst 1.2.3.4 dport 80-90 proto tcp in eth0" --pref=200
+    loxicmd delete firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.3.1.2/32,preference:200"
 		`,
 		Aliases: []string{"Firewall", "fw", "firewalls"},
 		PreRun: func(cmd *cobra.Command, args []string) {
-			if len(o.FirewallRule) == 0 {
+			if len(o.FirewallRule) == 0 && !cmd.Flags().Changed("match") {
 				cmd.Help()
 				os.Exit(0)
 			}
@@ -68,10 +80,22 @@ ex) loxicmd delete firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.
 				defer cancel()
 			}
 
-			query, err := MakefirewallDeleteQuery(o.FirewallRule)
-			if err != nil {
-				fmt.Printf("Error: Failed to delete Firewall")
-				return
+			var query map[string]string
+			if cmd.Flags().Changed("match") {
+				rule, err := api.ParseFwMatch(o.Match)
+				if err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+				rule.Pref = o.Pref
+				query = MakeFirewallMatchDeleteQuery(rule)
+			} else {
+				var err error
+				query, err = MakefirewallDeleteQuery(o.FirewallRule)
+				if err != nil {
+					fmt.Printf("Error: Failed to delete Firewall")
+					return
+				}
 			}
 			resp, err := client.Firewall().Query(query).Delete(ctx)
 			if err != nil {
@@ -89,23 +113,58 @@ ex) loxicmd delete firewall --firewallRule="sourceIP:1.2.3.2/32,destinationIP:2.
 	}
 
 	deleteFirewallCmd.Flags().StringSliceVar(&o.FirewallRule, "firewallRule", o.FirewallRule, "Information related to firewall rule")
+	deleteFirewallCmd.Flags().StringVar(&o.Match, "match", o.Match, "Match expression of the firewall rule (ex. \"src 10.0.0.0/8 dport 80-90 proto tcp\")")
+	deleteFirewallCmd.Flags().Uint16Var(&o.Pref, "pref", o.Pref, "User preference for ordering (used with --match)")
+	deleteFirewallCmd.MarkFlagsMutuallyExclusive("match", "firewallRule")
+	deleteFirewallCmd.MarkFlagsMutuallyExclusive("pref", "firewallRule")
 	return deleteFirewallCmd
 }
 
 func MakefirewallDeleteQuery(FirewallRule []string) (map[string]string, error) {
 	query := map[string]string{}
 	for _, v := range FirewallRule {
-		firewallArgsPair := strings.Split(v, ":")
+		firewallArgsPair := strings.SplitN(v, ":", 2)
 		if len(firewallArgsPair) < 2 {
 			return nil, fmt.Errorf("error: Failed to delete Firewall - format error")
 		}
 		value := firewallArgsPair[1]
-		if firewallArgsPair[0] == "sourceIP" || firewallArgsPair[0] == "destinationIP" {
-			if len(firewallArgsPair) > 2 {
-				value = strings.Join(firewallArgsPair[1:], ":")
+		if firewallArgsPair[0] == "protocol" {
+			proto, err := api.ParseFwProto(value)
+			if err != nil {
+				return nil, err
 			}
+			value = strconv.Itoa(int(proto))
 		}
 		query[firewallArgsPair[0]] = value
 	}
 	return query, nil
 }
+
+// MakeFirewallMatchDeleteQuery makes the delete query from a rule parsed by --match
+func MakeFirewallMatchDeleteQuery(rule api.FwRuleArg) map[string]string {
+	query := map[string]string{}
+	if rule.SrcIP != "" {
+		query["sourceIP"] = rule.SrcIP
+	}
+	if rule.DstIP != "" {
+		query["destinationIP"] = rule.DstIP
+	}
+	if rule.SrcPortMin != 0 || rule.SrcPortMax != 0 {
+		query["minSourcePort"] = strconv.Itoa(int(rule.SrcPortMin))
+		query["maxSourcePort"] = strconv.Itoa(int(rule.SrcPortMax))
+	}
+	if rule.DstPortMin != 0 || rule.DstPortMax != 0 {
+		query["minDestinationPort"] = strconv.Itoa(int(rule.DstPortMin))
+		query["maxDestinationPort"] = strconv.Itoa(int(rule.DstPortMax))
+	}
+	if rule.Proto != 0 {
+		query["protocol"] = strconv.Itoa(int(rule.Proto))
+	}
+	if rule.InPort != "" {
+		query["portName"] = rule.InPort
+	}
+	if rule.Pref != 0 {
+		query["preference"] = strconv.Itoa(int(rule.Pref))
+	}
+	return query
+}
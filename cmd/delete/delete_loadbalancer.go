@@ -16,15 +16,19 @@
 package delete
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"loxicmd/cmd/get"
 	"loxicmd/pkg/api"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -57,11 +61,24 @@ func NewDeleteLoadBalancerCmd(restOptions *api.RESTOptions) *cobra.Command {
 
 	var externalIP string
 	//var endpointList []string
+	bulk := DeleteLoadBalancerBulkOptions{}
 
 	var deleteLbCmd = &cobra.Command{
-		Use:   "lb <EXTERNAL-IP> [--tcp portNumber] [--udp portNumber] [--sctp portNumber] [--icmp portNumber] [--bgp] [--mark=<val>] [--name=<service-name>] [--host=<url>]",
+		Use:   "lb <EXTERNAL-IP> [--tcp portNumber] [--udp portNumber] [--sctp portNumber] [--icmp portNumber] [--bgp] [--mark=<val>] [--name=<service-name>] [--host=<url>] | [--selector=<key><op><value>,...] [--ip=<ip|cidr>] [--proto=<proto>] [--mode=<mode>] [--all] [--except-managed] [--yes]",
 		Short: "Delete a LoadBalancer",
-		Long:  `Delete a LoadBalancer.`,
+		Long: `Delete a LoadBalancer.
+
+Several rules can be deleted at once with --selector, --ip, --proto, --mode or --all.
+The rules are selected client-side from the current rule list, shown, and deleted
+after a confirmation unless --yes is given.
+
+ex)
+	loxicmd delete lb 192.168.0.200 --tcp=80
+	loxicmd delete lb --name=http-service
+	loxicmd delete lb --selector name=~"^k8s-"
+	loxicmd delete lb --ip 10.0.0.0/24 --proto tcp --yes
+	loxicmd delete lb --all --except-managed
+`,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			//if len(args) == 0 {
 			//	cmd.Help()
@@ -69,6 +86,14 @@ func NewDeleteLoadBalancerCmd(restOptions *api.RESTOptions) *cobra.Command {
 			//}
 		},
 		Run: func(cmd *cobra.Command, args []string) {
+			if bulk.IsBulk() {
+				if len(args) > 0 {
+					fmt.Printf("Error: EXTERNAL-IP can't be used with bulk delete options\n")
+					return
+				}
+				DeleteLoadBalancerBulk(restOptions, bulk)
+				return
+			}
 
 			client := api.NewLoxiClient(restOptions)
 			ctx := context.TODO()
@@ -178,10 +203,160 @@ func NewDeleteLoadBalancerCmd(restOptions *api.RESTOptions) *cobra.Command {
 	deleteLbCmd.Flags().Uint16VarP(&Mark, "mark", "", 0, "Specify the mark num to segregate a load-balancer VIP service")
 	deleteLbCmd.Flags().StringVarP(&Name, "name", "", Name, "Name for load balancer rule")
 	deleteLbCmd.Flags().StringVarP(&Host, "host", "", Host, "Ingress Host URL Path")
+	deleteLbCmd.Flags().StringVarP(&bulk.Selector, "selector", "l", bulk.Selector, "Delete rules selected by '<key><op><value>,...' (ex) name=~\"^k8s-\"")
+	deleteLbCmd.Flags().StringVarP(&bulk.IP, "ip", "", bulk.IP, "Delete rules whose external IP is in the IP or CIDR")
+	deleteLbCmd.Flags().StringVarP(&bulk.Protocol, "proto", "", bulk.Protocol, "Delete rules with the protocol")
+	deleteLbCmd.Flags().StringVarP(&bulk.Mode, "mode", "", bulk.Mode, "Delete rules with the NAT mode")
+	deleteLbCmd.Flags().BoolVarP(&bulk.All, "all", "", false, "Delete all rules")
+	deleteLbCmd.Flags().BoolVarP(&bulk.ExceptManaged, "except-managed", "", false, "Skip rules managed by an external controller (ex) kube-loxilb")
+	deleteLbCmd.Flags().BoolVarP(&bulk.Yes, "yes", "y", false, "Don't ask for confirmation")
+	deleteLbCmd.Flags().IntVarP(&bulk.Workers, "workers", "", 8, "Number of parallel delete requests")
 
 	return deleteLbCmd
 }
 
+type DeleteLoadBalancerBulkOptions struct {
+	get.GetLoadBalancerOptions
+	All           bool
+	ExceptManaged bool
+	Yes           bool
+	Workers       int
+}
+
+// IsBulk returns true when rules are to be selected from the rule list.
+func (o DeleteLoadBalancerBulkOptions) IsBulk() bool {
+	return o.All || o.ExceptManaged || o.Selector != "" || o.IP != "" || o.Protocol != "" || o.Mode != ""
+}
+
+func DeleteLoadBalancerBulk(restOptions *api.RESTOptions, o DeleteLoadBalancerBulkOptions) {
+	sel, err := o.MakeLbSelector()
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return
+	}
+	sel.ExceptManaged = o.ExceptManaged
+	if sel.Empty() && !o.All {
+		fmt.Printf("Error: use --all to delete every rule\n")
+		return
+	}
+
+	client, ctx, cancel := GetClientWithCtx(restOptions)
+	if restOptions.Timeout > 0 {
+		defer cancel()
+	}
+	resp, err := client.LoadBalancerAll().Get(ctx)
+	if err != nil {
+		fmt.Printf("Error: %s\n", err.Error())
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		fmt.Printf("Error: Failed to get LoadBalancer rules (status %d)\n", resp.StatusCode)
+		return
+	}
+	lbresp := api.LbRuleModGet{}
+	if err := json.NewDecoder(resp.Body).Decode(&lbresp); err != nil {
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+		return
+	}
+
+	selected := []api.LoadBalancerService{}
+	for _, lb := range lbresp.Filter(sel).LbRules {
+		// SNAT rules are internal to loxilb
+		if lb.Service.Snat {
+			continue
+		}
+		selected = append(selected, lb.Service)
+	}
+	if len(selected) == 0 {
+		fmt.Printf("No LoadBalancer rule selected\n")
+		return
+	}
+
+	for _, service := range selected {
+		fmt.Printf("%s\t%s\n", service.Key(), service.Name)
+	}
+	if !o.Yes && !confirm(fmt.Sprintf("Delete %d LoadBalancer rule(s)?", len(selected))) {
+		fmt.Printf("Aborted\n")
+		return
+	}
+
+	workers := o.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+	jobs := make(chan api.LoadBalancerService)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	failed := 0
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for service := range jobs {
+				err := LoadBalancerDeleteAPICall(restOptions, service)
+				mutex.Lock()
+				if err != nil {
+					failed++
+					fmt.Printf("Error: Failed to delete %s: %s\n", service.Key(), err.Error())
+				} else {
+					fmt.Printf("Deleted %s\n", service.Key())
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+	for _, service := range selected {
+		jobs <- service
+	}
+	close(jobs)
+	wg.Wait()
+
+	fmt.Printf("%d deleted, %d failed\n", len(selected)-failed, failed)
+}
+
+// LoadBalancerDeleteAPICall deletes one rule identified by its service arguments.
+func LoadBalancerDeleteAPICall(restOptions *api.RESTOptions, service api.LoadBalancerService) error {
+	client, ctx, cancel := GetClientWithCtx(restOptions)
+	if restOptions.Timeout > 0 {
+		defer cancel()
+	}
+	host := service.Host
+	if host == "" {
+		host = "any"
+	}
+	subResources := []string{
+		"hosturl", host,
+		"externalipaddress", service.ExternalIP,
+		"port", strconv.Itoa(int(service.Port)),
+		"portmax", strconv.Itoa(int(service.PortMax)),
+		"protocol", service.Protocol,
+	}
+	qmap := map[string]string{}
+	qmap["bgp"] = fmt.Sprintf("%v", service.BGP)
+	qmap["block"] = fmt.Sprintf("%v", service.Block)
+	resp, err := client.LoadBalancer().SubResources(subResources).Query(qmap).Delete(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		resultByte, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("status %d %s", resp.StatusCode, strings.TrimSpace(string(resultByte)))
+	}
+	return nil
+}
+
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
 func PrintDeleteResult(resp *http.Response, o api.RESTOptions) {
 	result := DeleteLoadBalancerResult{}
 	resultByte, err := io.ReadAll(resp.Body)
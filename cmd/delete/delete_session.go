@@ -21,8 +21,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
+	"loxicmd/cmd/get"
 	"loxicmd/pkg/api"
 
 	"github.com/spf13/cobra"
@@ -41,11 +43,17 @@ func DeleteSessionValidation(args []string) error {
 
 func NewDeleteSessionCmd(restOptions *api.RESTOptions) *cobra.Command {
 	var UserID string
+	var Cascade bool
 
 	var deleteSessionCmd = &cobra.Command{
-		Use:   "session <UserID>",
+		Use:   "session <UserID> [--cascade]",
 		Short: "Delete a Session",
-		Long:  `Delete a Session using USERID in the LoxiLB.`,
+		Long: `Delete a Session using USERID in the LoxiLB.
+--cascade deletes the ULCL classifiers of the session first.
+The session is not deleted when one of them fails. When the session itself
+fails to be deleted after them, the command to re-create the deleted ULCLs is shown.
+
+ex) loxicmd delete session user1 --cascade`,
 		PreRun: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
 				cmd.Help()
@@ -65,12 +73,31 @@ func NewDeleteSessionCmd(restOptions *api.RESTOptions) *cobra.Command {
 				ctx, cancel = context.WithTimeout(ctx, time.Duration(restOptions.Timeout)*time.Second)
 				defer cancel()
 			}
+			var deleted []api.UlClArg
+			if Cascade {
+				sessionresp, err := get.GetSessions(restOptions, api.SessionFilter{Ident: UserID})
+				if err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+				if len(sessionresp.SessionInfo) == 0 {
+					fmt.Printf("Error: session '%s' is not found\n", UserID)
+					return
+				}
+				deleted, err = DeleteSessionUlCls(ctx, restOptions, UserID)
+				if err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					printUlClRestore(UserID, deleted)
+					return
+				}
+			}
 			subResources := []string{
 				"ident", UserID,
 			}
 			resp, err := client.Session().SubResources(subResources).Delete(ctx)
 			if err != nil {
-				fmt.Printf("Error: Failed to delete Session(UserID: %s)", UserID)
+				fmt.Printf("Error: Failed to delete Session(UserID: %s)\n", UserID)
+				printUlClRestore(UserID, deleted)
 				return
 			}
 			defer resp.Body.Close()
@@ -79,9 +106,56 @@ func NewDeleteSessionCmd(restOptions *api.RESTOptions) *cobra.Command {
 				PrintDeleteResult(resp, *restOptions)
 				return
 			}
+			if len(deleted) > 0 {
+				fmt.Printf("Error: failed to delete session %s: %s\n", UserID, resp.Status)
+				printUlClRestore(UserID, deleted)
+			}
 
 		},
 	}
 
+	deleteSessionCmd.Flags().BoolVarP(&Cascade, "cascade", "", false, "Delete the ULCL classifiers of the session too")
+
 	return deleteSessionCmd
 }
+
+// DeleteSessionUlCls deletes all the ULCL classifiers of the session.
+// It returns the ULCLs deleted so far, also when one of them fails.
+func DeleteSessionUlCls(ctx context.Context, restOptions *api.RESTOptions, UserID string) ([]api.UlClArg, error) {
+	deleted := []api.UlClArg{}
+	ulclresp, err := get.GetSessionUlCls(restOptions)
+	if err != nil {
+		return deleted, err
+	}
+	client := api.NewLoxiClient(restOptions)
+	for _, ulcl := range ulclresp.OfSession(UserID) {
+		subResources := []string{
+			"ident", UserID,
+			"ulclAddress", ulcl.Addr.String(),
+		}
+		resp, err := client.SessionUlCL().SubResources(subResources).Delete(ctx)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete ULCL %s of session %s: %s", ulcl.Addr.String(), UserID, err.Error())
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return deleted, fmt.Errorf("failed to delete ULCL %s of session %s: %s", ulcl.Addr.String(), UserID, resp.Status)
+		}
+		deleted = append(deleted, ulcl)
+		fmt.Printf("Deleted ULCL %s (QFI %d) of session %s\n", ulcl.Addr.String(), ulcl.Qfi, UserID)
+	}
+	return deleted, nil
+}
+
+// printUlClRestore shows how to re-create the ULCLs deleted before the session delete failed
+func printUlClRestore(UserID string, deleted []api.UlClArg) {
+	if len(deleted) == 0 {
+		return
+	}
+	args := make([]string, 0, len(deleted))
+	for _, ulcl := range deleted {
+		args = append(args, fmt.Sprintf("%d:%s", ulcl.Qfi, ulcl.Addr.String()))
+	}
+	fmt.Printf("Error: session %s is not deleted but %d of its ULCLs are. Re-create them with\n", UserID, len(deleted))
+	fmt.Printf("  loxicmd create sessionulcl %s --ulclArgs=%s\n", UserID, strings.Join(args, ","))
+}
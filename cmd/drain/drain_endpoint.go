@@ -0,0 +1,356 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package drain
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"loxicmd/cmd/create"
+	"loxicmd/pkg/api"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type DrainEndPointOptions struct {
+	Service   string
+	Wait      time.Duration
+	Interval  time.Duration
+	NoDetach  bool
+	StatePath string
+}
+
+// DrainedRule - LB rule and its endpoint entries as they were before drain
+type DrainedRule struct {
+	Service   api.LoadBalancerService    `json:"serviceArguments"`
+	Endpoints []api.LoadBalancerEndpoint `json:"endpoints"`
+}
+
+// DrainState - state of a drained endpoint which is used by undrain
+type DrainState struct {
+	EndpointIP string        `json:"endpointIP"`
+	Detached   bool          `json:"detached"`
+	Rules      []DrainedRule `json:"rules"`
+}
+
+func NewDrainEndPointCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := DrainEndPointOptions{}
+
+	var drainEndPointCmd = &cobra.Command{
+		Use:   "endpoint IP [--service=<service-name>] [--wait=<duration>] [--interval=<duration>] [--no-detach] [--state-path=<path>]",
+		Short: "Drain a LB endpoint",
+		Long: `Drain a LB endpoint before maintenance.
+
+The endpoint's weight is set to 0 on every LB rule referencing it so no new
+connection is scheduled to it. Then it waits until conntrack shows no active
+flows to the endpoint (or --wait passes) and detaches it from the rules.
+The original weights are saved in the state path and restored by undrain.
+
+ex)
+	loxicmd drain endpoint 10.212.0.1
+	loxicmd drain endpoint 10.212.0.1 --service=http-service --wait=10m
+	loxicmd undrain endpoint 10.212.0.1
+`,
+		Aliases: []string{"ep", "endpoints"},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if net.ParseIP(args[0]) == nil {
+				fmt.Printf("Error: endpoint IP '%s' is invalid format\n", args[0])
+				return
+			}
+			if err := DrainEndPoint(restOptions, args[0], o); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+		},
+	}
+
+	drainEndPointCmd.Flags().StringVarP(&o.Service, "service", "", "", "Drain only on the LB rule with this service name")
+	drainEndPointCmd.Flags().DurationVarP(&o.Wait, "wait", "", 5*time.Minute, "Maximum time to wait for active flows to finish")
+	drainEndPointCmd.Flags().DurationVarP(&o.Interval, "interval", "", 2*time.Second, "Interval between conntrack checks")
+	drainEndPointCmd.Flags().BoolVarP(&o.NoDetach, "no-detach", "", false, "Keep the endpoint attached with weight 0 after the flows finish")
+	drainEndPointCmd.Flags().StringVarP(&o.StatePath, "state-path", "", "/etc/loxilb/", "Directory to save the original weights for undrain")
+
+	return drainEndPointCmd
+}
+
+func NewUndrainEndPointCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := DrainEndPointOptions{}
+
+	var undrainEndPointCmd = &cobra.Command{
+		Use:   "endpoint IP [--state-path=<path>]",
+		Short: "Undrain a LB endpoint",
+		Long: `Undrain a LB endpoint after maintenance.
+
+The endpoint is attached again to the LB rules it was drained from with its original weights.
+
+ex)
+	loxicmd undrain endpoint 10.212.0.1
+`,
+		Aliases: []string{"ep", "endpoints"},
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if net.ParseIP(args[0]) == nil {
+				fmt.Printf("Error: endpoint IP '%s' is invalid format\n", args[0])
+				return
+			}
+			if err := UndrainEndPoint(restOptions, args[0], o); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+		},
+	}
+
+	undrainEndPointCmd.Flags().StringVarP(&o.StatePath, "state-path", "", "/etc/loxilb/", "Directory where drain saved the original weights")
+
+	return undrainEndPointCmd
+}
+
+func drainStateFile(path string, ip string) string {
+	return filepath.Join(path, "drain_"+strings.ReplaceAll(ip, ":", "_")+".json")
+}
+
+func DrainEndPoint(restOptions *api.RESTOptions, ip string, o DrainEndPointOptions) error {
+	file := drainStateFile(o.StatePath, ip)
+	if _, err := os.Stat(file); err == nil {
+		return fmt.Errorf("endpoint %s is already drained (%s), run undrain first", ip, file)
+	}
+
+	lbresp, err := GetLbRules(restOptions)
+	if err != nil {
+		return err
+	}
+
+	state := DrainState{EndpointIP: ip}
+	services := map[string]bool{}
+	for _, lb := range lbresp.LbRules {
+		if lb.Service.Snat || (o.Service != "" && lb.Service.Name != o.Service) {
+			continue
+		}
+		rule := DrainedRule{Service: lb.Service}
+		for _, ep := range lb.Endpoints {
+			if net.ParseIP(ep.EndpointIP).Equal(net.ParseIP(ip)) {
+				ep.State = ""
+				ep.Counter, ep.Packets, ep.Bytes = "", 0, 0
+				rule.Endpoints = append(rule.Endpoints, ep)
+			}
+		}
+		if len(rule.Endpoints) > 0 {
+			state.Rules = append(state.Rules, rule)
+			services[lb.Service.Name] = true
+		}
+	}
+	if len(state.Rules) == 0 {
+		return fmt.Errorf("endpoint %s is not used by any LB rule", ip)
+	}
+
+	if err := writeDrainState(file, state); err != nil {
+		return err
+	}
+	fmt.Printf("Original weights saved in %s\n", file)
+
+	// Stop scheduling new connections to the endpoint
+	for _, rule := range state.Rules {
+		var eps []api.LoadBalancerEndpoint
+		for _, ep := range rule.Endpoints {
+			ep.Weight = 0
+			eps = append(eps, ep)
+		}
+		if err := UpdateEndPoints(restOptions, rule.Service, api.LbOPAttach, eps); err != nil {
+			return fmt.Errorf("failed to set weight 0 on %s: %s", rule.Service.Key(), err.Error())
+		}
+		fmt.Printf("Weight set to 0 on %s %s\n", rule.Service.Key(), rule.Service.Name)
+	}
+
+	// Wait for active flows to finish
+	deadline := time.Now().Add(o.Wait)
+	for {
+		ctresp, err := GetConntrack(restOptions)
+		if err != nil {
+			// Active flows are unknown, so the endpoint is not detached
+			return fmt.Errorf("%s. %s is not detached and keeps weight 0 until \"undrain endpoint %s\"", err.Error(), ip, ip)
+		}
+		flows := ActiveFlows(ctresp, ip, services)
+		if flows == 0 {
+			fmt.Printf("No active flow to %s\n", ip)
+			break
+		}
+		if time.Now().After(deadline) {
+			fmt.Printf("Timeout: %d active flow(s) to %s remain\n", flows, ip)
+			break
+		}
+		fmt.Printf("Waiting for %d active flow(s) to %s\n", flows, ip)
+		time.Sleep(o.Interval)
+	}
+
+	if o.NoDetach {
+		return nil
+	}
+	for _, rule := range state.Rules {
+		if err := UpdateEndPoints(restOptions, rule.Service, api.LbOPDetach, rule.Endpoints); err != nil {
+			return fmt.Errorf("failed to detach from %s: %s", rule.Service.Key(), err.Error())
+		}
+		fmt.Printf("Detached from %s %s\n", rule.Service.Key(), rule.Service.Name)
+	}
+	state.Detached = true
+	return writeDrainState(file, state)
+}
+
+func UndrainEndPoint(restOptions *api.RESTOptions, ip string, o DrainEndPointOptions) error {
+	file := drainStateFile(o.StatePath, ip)
+	byteBuf, err := os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("endpoint %s is not drained (%s not found)", ip, file)
+		}
+		return err
+	}
+	state := DrainState{}
+	if err := json.Unmarshal(byteBuf, &state); err != nil {
+		return fmt.Errorf("failed to unmarshal %s: %s", file, err.Error())
+	}
+
+	for _, rule := range state.Rules {
+		if err := UpdateEndPoints(restOptions, rule.Service, api.LbOPAttach, rule.Endpoints); err != nil {
+			return fmt.Errorf("failed to restore %s: %s", rule.Service.Key(), err.Error())
+		}
+		fmt.Printf("Restored on %s %s\n", rule.Service.Key(), rule.Service.Name)
+	}
+	return os.Remove(file)
+}
+
+// ActiveFlows counts the active connections translated to the endpoint.
+// If services is not empty, only connections of those services are counted.
+func ActiveFlows(ctresp api.CtInformationGet, ip string, services map[string]bool) int {
+	epIP := net.ParseIP(ip)
+	flows := 0
+	for _, ct := range ctresp.CtInfo {
+		if len(services) > 0 && !services[ct.ServName] {
+			continue
+		}
+		if !ct.IsActive() {
+			continue
+		}
+		if epIP.Equal(net.ParseIP(ct.EndpointIP())) || epIP.Equal(net.ParseIP(ct.Dip)) {
+			flows++
+		}
+	}
+	return flows
+}
+
+// UpdateEndPoints attaches or detaches endpoints of an existing LB rule.
+func UpdateEndPoints(restOptions *api.RESTOptions, service api.LoadBalancerService, oper api.LbOP, eps []api.LoadBalancerEndpoint) error {
+	lbModel := api.LoadBalancerModel{
+		Service:   service,
+		Endpoints: eps,
+	}
+	lbModel.Service.Oper = oper
+	if err := lbModel.Validation(); err != nil {
+		return err
+	}
+	resp, err := create.LoadbalancerAPICall(restOptions, lbModel)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func GetLbRules(restOptions *api.RESTOptions) (api.LbRuleModGet, error) {
+	lbresp := api.LbRuleModGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.LoadBalancerAll().Get(ctx)
+	if err != nil {
+		return lbresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return lbresp, fmt.Errorf("failed to get load balancer rules: status %d", resp.StatusCode)
+	}
+	resultByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return lbresp, fmt.Errorf("failed to read HTTP response: (%s)", err.Error())
+	}
+	if err := json.Unmarshal(resultByte, &lbresp); err != nil {
+		return lbresp, fmt.Errorf("failed to unmarshal HTTP response: (%s)", err.Error())
+	}
+	return lbresp, nil
+}
+
+func GetConntrack(restOptions *api.RESTOptions) (api.CtInformationGet, error) {
+	ctresp := api.CtInformationGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Conntrack().Get(ctx)
+	if err != nil {
+		return ctresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ctresp, fmt.Errorf("failed to get conntrack: status %d", resp.StatusCode)
+	}
+	resultByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ctresp, fmt.Errorf("failed to read HTTP response: (%s)", err.Error())
+	}
+	if err := json.Unmarshal(resultByte, &ctresp); err != nil {
+		return ctresp, fmt.Errorf("failed to unmarshal HTTP response: (%s)", err.Error())
+	}
+	return ctresp, nil
+}
+
+func writeDrainState(file string, state DrainState) error {
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return fmt.Errorf("can't create state dir %s: %s", filepath.Dir(file), err.Error())
+	}
+	stateBytes, err := json.MarshalIndent(state, "", "    ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, stateBytes, 0644)
+}
@@ -48,7 +48,25 @@ func ApplyCmd(options *ApplyOptions, restOptions *api.RESTOptions) *cobra.Comman
 	applyCmd := &cobra.Command{
 		Use:   "apply",
 		Short: "Apply configuration",
-		Long:  `Reads and apply configuration from the text file`,
+		Long: `Reads and apply configuration from the text file
+
+A file of kind Topology declares an overlay of VLANs and VXLANs. Its objects are created
+in the order of the dependency and the created ones are deleted again when one fails.
+"loxicmd delete -f" tears the topology down in the reverse order.
+
+  apiVersion: netlox/v1
+  kind: Topology
+  spec:
+    vlans:
+    - vid: 100
+      tagged: [eth1]
+      untagged: [eth2]
+      gateways: [10.10.10.254/24]
+    vxlans:
+    - vxlanID: 5000
+      epIntf: vlan100
+      peers: [10.10.10.1, 10.10.10.2]
+      gateways: [172.30.0.254/24]`,
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd
 			_ = args
@@ -576,6 +594,10 @@ func ApplyLbConfig(file string, restOptions *api.RESTOptions) {
 
 	// POST the dump
 	for _, lb := range lbresp.LbRules {
+		if err := lb.Validation(); err != nil {
+			fmt.Printf("Error: Skip LB rule %s: %s\n", lb.Service.Key(), err.Error())
+			continue
+		}
 		lbModel := api.LoadBalancerModel{}
 		lbService := api.LoadBalancerService{
 			ExternalIP: lb.Service.ExternalIP,
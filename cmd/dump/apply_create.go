@@ -19,6 +19,7 @@ import (
 	"errors"
 	"fmt"
 	"loxicmd/cmd/create"
+	"loxicmd/cmd/delete"
 	"loxicmd/pkg/api"
 	"os"
 
@@ -74,6 +75,20 @@ func ApplyFileConfig(file string, restOptions *api.RESTOptions) error {
 		err = create.VxlanBridgeCreateWithFile(restOptions, byteBuf)
 	case "BFD", "bfd":
 		err = create.BFDCreateWithFile(restOptions, byteBuf)
+	case "BGPNeighbor", "bgpneighbor", "bgpnei", "bgpneigh":
+		err = create.BGPNeighborCreateWithFile(restOptions, byteBuf)
+	case "BGPPolicy", "bgppolicy", "bgp-policy":
+		err = create.BGPPolicyCreateWithFile(restOptions, byteBuf)
+	case "Topology", "topology", "topo":
+		var created []api.TopologyObject
+		created, err = create.TopologyCreateWithFile(restOptions, byteBuf)
+		if err != nil && len(created) > 0 {
+			// Roll back not to leave a half-built overlay
+			fmt.Printf("Rolling back %d created objects\n", len(created))
+			if rerr := delete.DeleteTopologyObjects(restOptions, created); rerr != nil {
+				fmt.Printf("Error: %s\n", rerr.Error())
+			}
+		}
 	default:
 		fmt.Printf("Not Supported\n")
 		return errors.New("not supported")
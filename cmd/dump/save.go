@@ -61,7 +61,7 @@ func SaveCmd(saveOpts *SaveOptions, restOptions *api.RESTOptions) *cobra.Command
 			if _, err := os.Stat(dpath); errors.Is(err, os.ErrNotExist) {
 				err := os.Mkdir(dpath, os.ModePerm)
 				if err != nil {
-					fmt.Println("Can't create config dir %v", dpath)
+					fmt.Println("Can't create config dir", dpath)
 					return
 				}
 			}
@@ -0,0 +1,353 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"loxicmd/pkg/api"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type ExporterOptions struct {
+	Listen   string
+	Interval time.Duration
+	Path     string
+}
+
+// Exporter periodically polls the loxilb API server and keeps the latest
+// metrics snapshot for the HTTP handler.
+type Exporter struct {
+	restOptions *api.RESTOptions
+	client      *api.LoxiClient
+
+	mu       sync.RWMutex
+	snapshot []byte
+}
+
+type scrapeFunc func(ctx context.Context, m *MetricSet) error
+
+func ExporterCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := ExporterOptions{}
+
+	var exporterCmd = &cobra.Command{
+		Use:   "exporter",
+		Short: "Run a Prometheus metrics exporter for the LoxiLB",
+		Long: `Run a long-running Prometheus/OpenMetrics exporter.
+It periodically polls the LoxiLB API (load balancer, port, conntrack, endpoint,
+BFD, BGP neighbor, HA state and route) and exposes the result as metrics.
+
+ex) loxicmd exporter --listen :9411
+    loxicmd exporter --listen 127.0.0.1:9411 --interval 30s --path /metrics
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if o.Interval <= 0 {
+				fmt.Printf("Error: interval must be greater than 0\n")
+				return
+			}
+			if !strings.HasPrefix(o.Path, "/") {
+				o.Path = "/" + o.Path
+			}
+			e := NewExporter(restOptions)
+			if err := e.Run(o); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+			}
+		},
+	}
+
+	exporterCmd.Flags().StringVarP(&o.Listen, "listen", "", ":9411", "Address to expose metrics on")
+	exporterCmd.Flags().DurationVarP(&o.Interval, "interval", "", 15*time.Second, "Interval between polls of the API server")
+	exporterCmd.Flags().StringVarP(&o.Path, "path", "", "/metrics", "HTTP path to expose metrics on")
+
+	return exporterCmd
+}
+
+func NewExporter(restOptions *api.RESTOptions) *Exporter {
+	return &Exporter{
+		restOptions: restOptions,
+		client:      api.NewLoxiClient(restOptions),
+	}
+}
+
+// Run collects metrics every interval and serves the latest snapshot until the
+// HTTP server fails.
+func (e *Exporter) Run(o ExporterOptions) error {
+	e.Collect()
+	go func() {
+		ticker := time.NewTicker(o.Interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			e.Collect()
+		}
+	}()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(o.Path, e.ServeHTTP)
+	if o.Path != "/" {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			fmt.Fprintf(w, "<html><body><a href=\"%s\">metrics</a></body></html>\n", o.Path)
+		})
+	}
+	fmt.Printf("Serving metrics on %s%s\n", o.Listen, o.Path)
+	return http.ListenAndServe(o.Listen, mux)
+}
+
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.RLock()
+	snapshot := e.snapshot
+	e.mu.RUnlock()
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write(snapshot)
+}
+
+// Collect polls every API once and replaces the cached snapshot.
+func (e *Exporter) Collect() {
+	scrapes := []struct {
+		name string
+		fn   scrapeFunc
+	}{
+		{"loadbalancer", e.scrapeLoadBalancer},
+		{"port", e.scrapePort},
+		{"conntrack", e.scrapeConntrack},
+		{"endpoint", e.scrapeEndPoint},
+		{"bfd", e.scrapeBFD},
+		{"bgpneighbor", e.scrapeBGPNeighbor},
+		{"hastate", e.scrapeHAState},
+		{"route", e.scrapeRoute},
+	}
+
+	m := NewMetricSet()
+	for _, s := range scrapes {
+		start := time.Now()
+		err := e.scrape(s.fn, m)
+		if err != nil {
+			fmt.Printf("Error: %s scrape failed: %s\n", s.name, err.Error())
+		}
+		m.Add("loxilb_exporter_scrape_success", "Whether the last poll of the API succeeded.", MetricGauge,
+			boolToFloat(err == nil), "api", s.name)
+		m.Add("loxilb_exporter_scrape_duration_seconds", "Duration of the last poll of the API.", MetricGauge,
+			time.Since(start).Seconds(), "api", s.name)
+	}
+
+	var buf bytes.Buffer
+	m.WriteTo(&buf)
+	e.mu.Lock()
+	e.snapshot = buf.Bytes()
+	e.mu.Unlock()
+}
+
+func (e *Exporter) scrape(fn scrapeFunc, m *MetricSet) error {
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if e.restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(e.restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	return fn(ctx, m)
+}
+
+// get issues the request and returns the response body on 200 OK.
+func get(ctx context.Context, c *api.CommonAPI) (io.ReadCloser, error) {
+	resp, err := c.Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return resp.Body, nil
+}
+
+func getJSON(ctx context.Context, c *api.CommonAPI, v interface{}) error {
+	body, err := get(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+	return json.NewDecoder(body).Decode(v)
+}
+
+func (e *Exporter) scrapeLoadBalancer(ctx context.Context, m *MetricSet) error {
+	body, err := get(ctx, &e.client.LoadBalancerAll().CommonAPI)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	return api.DecodeList(body, "lbAttr", func(lb api.LoadBalancerModel) error {
+		svc := lb.Service
+		m.Add("loxilb_lb_endpoints", "Number of endpoints of the load balancer rule.", MetricGauge,
+			float64(len(lb.Endpoints)),
+			"name", svc.Name, "vip", svc.ExternalIP, "port", strconv.Itoa(int(svc.Port)), "protocol", svc.Protocol)
+		for _, ep := range lb.Endpoints {
+			labels := []string{"name", svc.Name, "vip", svc.ExternalIP, "port", strconv.Itoa(int(svc.Port)),
+				"protocol", svc.Protocol, "endpoint", ep.EndpointIP, "target_port", strconv.Itoa(int(ep.TargetPort))}
+			m.Add("loxilb_lb_endpoint_packets_total", "Packets forwarded to the load balancer endpoint.", MetricCounter,
+				float64(ep.Packets), labels...)
+			m.Add("loxilb_lb_endpoint_bytes_total", "Bytes forwarded to the load balancer endpoint.", MetricCounter,
+				float64(ep.Bytes), labels...)
+			m.Add("loxilb_lb_endpoint_weight", "Weight of the load balancer endpoint.", MetricGauge,
+				float64(ep.Weight), labels...)
+			m.Add("loxilb_lb_endpoint_active", "Whether the load balancer endpoint is active.", MetricGauge,
+				boolToFloat(ep.State == "active"), labels...)
+		}
+		return nil
+	})
+}
+
+func (e *Exporter) scrapePort(ctx context.Context, m *MetricSet) error {
+	ports := api.PortGet{}
+	if err := getJSON(ctx, &e.client.Port().CommonAPI, &ports); err != nil {
+		return err
+	}
+	for _, p := range ports.Ports {
+		labels := []string{"port", p.Name, "zone", p.Zone}
+		m.Add("loxilb_port_up", "Whether the port link is up.", MetricGauge, boolToFloat(p.HInfo.Link && p.HInfo.State), labels...)
+		m.Add("loxilb_port_rx_bytes_total", "Bytes received on the port.", MetricCounter, float64(p.Stats.RxBytes), labels...)
+		m.Add("loxilb_port_tx_bytes_total", "Bytes transmitted on the port.", MetricCounter, float64(p.Stats.TxBytes), labels...)
+		m.Add("loxilb_port_rx_packets_total", "Packets received on the port.", MetricCounter, float64(p.Stats.RxPackets), labels...)
+		m.Add("loxilb_port_tx_packets_total", "Packets transmitted on the port.", MetricCounter, float64(p.Stats.TxPackets), labels...)
+		m.Add("loxilb_port_rx_errors_total", "Receive errors on the port.", MetricCounter, float64(p.Stats.RxError), labels...)
+		m.Add("loxilb_port_tx_errors_total", "Transmit errors on the port.", MetricCounter, float64(p.Stats.TxError), labels...)
+	}
+	return nil
+}
+
+func (e *Exporter) scrapeConntrack(ctx context.Context, m *MetricSet) error {
+	body, err := get(ctx, &e.client.Conntrack().CommonAPI)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	type ctKey struct{ proto, state string }
+	var keys []ctKey
+	counts := map[ctKey]int{}
+	err = api.DecodeList(body, "ctAttr", func(ct api.ConntrackInformation) error {
+		k := ctKey{ct.Proto, ct.CState}
+		if _, ok := counts[k]; !ok {
+			keys = append(keys, k)
+		}
+		counts[k]++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, k := range keys {
+		m.Add("loxilb_conntrack_entries", "Number of conntrack entries by protocol and state.", MetricGauge,
+			float64(counts[k]), "protocol", k.proto, "state", k.state)
+	}
+	return nil
+}
+
+func (e *Exporter) scrapeEndPoint(ctx context.Context, m *MetricSet) error {
+	eps := api.EPInformationGet{}
+	if err := getJSON(ctx, e.client.EndPoint().SetUrl("/config/endpoint/all"), &eps); err != nil {
+		return err
+	}
+	for _, ep := range eps.EPInfo {
+		labels := []string{"endpoint", ep.HostName, "name", ep.Name, "probe", ep.ProbeType}
+		for _, d := range []struct{ stat, value string }{
+			{"min", ep.MinDelay}, {"avg", ep.AvgDelay}, {"max", ep.MaxDelay},
+		} {
+			delay, err := time.ParseDuration(d.value)
+			if err != nil {
+				continue
+			}
+			m.Add("loxilb_endpoint_probe_delay_seconds", "Probe delay of the endpoint.", MetricGauge,
+				delay.Seconds(), append(labels, "stat", d.stat)...)
+		}
+		m.Add("loxilb_endpoint_state", "Current probe state of the endpoint.", MetricGauge,
+			1, append(labels, "state", ep.CurrState)...)
+		m.Add("loxilb_endpoint_up", "Whether the endpoint probe succeeds.", MetricGauge,
+			boolToFloat(ep.CurrState == "ok"), labels...)
+	}
+	return nil
+}
+
+func (e *Exporter) scrapeBFD(ctx context.Context, m *MetricSet) error {
+	sessions := api.BFDSessionGet{}
+	if err := getJSON(ctx, e.client.BFDSession().SetUrl("config/bfd/all"), &sessions); err != nil {
+		return err
+	}
+	for _, s := range sessions.BFDSessionAttr {
+		labels := []string{"instance", s.Instance, "remote_ip", s.RemoteIP, "source_ip", s.SourceIP}
+		m.Add("loxilb_bfd_session_state", "Current state of the BFD session.", MetricGauge,
+			1, append(labels, "state", s.State)...)
+		m.Add("loxilb_bfd_session_up", "Whether the BFD session is up.", MetricGauge,
+			boolToFloat(strings.HasSuffix(strings.ToLower(s.State), "up")), labels...)
+	}
+	return nil
+}
+
+func (e *Exporter) scrapeBGPNeighbor(ctx context.Context, m *MetricSet) error {
+	neighbors := api.BGPNeighborModGet{}
+	if err := getJSON(ctx, e.client.BGPNeighbor().SetUrl("/config/bgp/neigh/all"), &neighbors); err != nil {
+		return err
+	}
+	for _, n := range neighbors.BGPAttr {
+		labels := []string{"neighbor", n.IPaddress, "remote_as", strconv.Itoa(n.RemoteAs)}
+		m.Add("loxilb_bgp_neighbor_state", "Current state of the BGP neighbor.", MetricGauge,
+			1, append(labels, "state", n.State)...)
+		m.Add("loxilb_bgp_neighbor_up", "Whether the BGP session is established.", MetricGauge,
+			boolToFloat(strings.Contains(strings.ToLower(n.State), "established")), labels...)
+	}
+	return nil
+}
+
+func (e *Exporter) scrapeHAState(ctx context.Context, m *MetricSet) error {
+	states := api.HAStateGet{}
+	if err := getJSON(ctx, e.client.HAState().SubResources([]string{"all"}), &states); err != nil {
+		return err
+	}
+	for _, s := range states.HAStateAttr {
+		m.Add("loxilb_ha_state", "Current HA state of the cluster instance.", MetricGauge,
+			1, "instance", s.Instance, "vip", s.Vip, "state", s.State)
+		m.Add("loxilb_ha_master", "Whether the cluster instance is master.", MetricGauge,
+			boolToFloat(strings.EqualFold(s.State, "MASTER")), "instance", s.Instance, "vip", s.Vip)
+	}
+	return nil
+}
+
+func (e *Exporter) scrapeRoute(ctx context.Context, m *MetricSet) error {
+	routes := api.RouteModGet{}
+	if err := getJSON(ctx, e.client.Route().SetUrl("/config/route/all"), &routes); err != nil {
+		return err
+	}
+	for _, r := range routes.RouteAttr {
+		labels := []string{"destination", r.Dst, "gateway", r.Gw, "protocol", r.Protocol}
+		m.Add("loxilb_route_packets_total", "Packets forwarded by the route.", MetricCounter,
+			float64(r.Statistic.Packets), labels...)
+		m.Add("loxilb_route_bytes_total", "Bytes forwarded by the route.", MetricCounter,
+			float64(r.Statistic.Bytes), labels...)
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package firewall
+
+import (
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+type ApplySetOptions struct {
+	DryRun      bool
+	AllowUpdate bool
+}
+
+// FwSetUpdate is a live rule whose options differ in the file
+type FwSetUpdate struct {
+	Old api.FwRuleMod
+	New api.FwRuleMod
+}
+
+// FwSetPlan is the set of changes that turns the live ruleset into the file ruleset
+type FwSetPlan struct {
+	Add    []api.FwRuleMod
+	Update []FwSetUpdate
+	Delete []api.FwRuleMod
+	Keep   int
+}
+
+func NewApplySetCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := ApplySetOptions{}
+
+	var applySetCmd = &cobra.Command{
+		Use:   "apply-set <ruleset file> [--dry-run] [--allow-update]",
+		Short: "Replace the firewall ruleset with the rules of a file",
+		Long: `Replace the firewall ruleset of the LoxiLB with the rules of a file.
+
+The file has the "fwAttr" list of "loxicmd save --firewall", in JSON or YAML:
+
+fwAttr:
+- ruleArguments:
+    sourceIP: 10.0.0.0/8
+    minDestinationPort: 80
+    maxDestinationPort: 90
+    protocol: 6
+    preference: 200
+  opts:
+    allow: true
+
+Rules are matched by their match fields. A rule whose preference or options changed
+is an update. New rules are added first and stale rules are removed last.
+If any change fails, the changes made so far are undone in the reverse order.
+
+LoxiLB keys a rule by its match fields, so an updated rule can't be created next to
+the old one. It is deleted and created again and is not enforced for a moment.
+A ruleset with updates is refused unless --allow-update is given.
+
+ex) loxicmd firewall apply-set rules.yaml --dry-run
+    loxicmd firewall apply-set rules.yaml
+    loxicmd firewall apply-set rules.yaml --allow-update
+`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			desired, err := ReadFwRuleSet(args[0])
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			current, err := getFirewallRules(restOptions)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+
+			plan := MakeFwSetPlan(current, desired)
+			PrintFwSetPlan(plan)
+			for _, e := range MakeFwOrder(desired) {
+				if len(e.Notes) != 0 {
+					fmt.Printf("Warning: rule #%d '%s' %s\n", e.Order, e.Rule.Rule.MatchString(), strings.Join(e.Notes, ", "))
+				}
+			}
+			if o.DryRun {
+				return
+			}
+			if len(plan.Update) > 0 && !o.AllowUpdate {
+				fmt.Printf("Error: %d rules are updated by deleting and creating them again, which leaves them unenforced for a moment. Use --allow-update to apply them\n", len(plan.Update))
+				return
+			}
+			if err := ApplyFwSetPlan(restOptions, plan); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			fmt.Printf("Ruleset applied - %s\n", args[0])
+		},
+	}
+
+	applySetCmd.Flags().BoolVarP(&o.DryRun, "dry-run", "", false, "Only show the changes")
+	applySetCmd.Flags().BoolVarP(&o.AllowUpdate, "allow-update", "", false, "Update rules by deleting and creating them again")
+	return applySetCmd
+}
+
+// MakeFwSetPlan compares the rules by their normalized match fields.
+// The plan lists the rules in evaluation order.
+func MakeFwSetPlan(current, desired api.FWInformationGet) FwSetPlan {
+	plan := FwSetPlan{}
+	live := map[api.FwRuleArg]api.FwRuleMod{}
+	for _, fwrule := range current.FWInfo {
+		live[fwrule.Rule.Normalize()] = fwrule
+	}
+	desired.SortByPref()
+	for _, fwrule := range desired.FWInfo {
+		key := fwrule.Rule.Normalize()
+		old, ok := live[key]
+		if !ok {
+			plan.Add = append(plan.Add, fwrule)
+			continue
+		}
+		delete(live, key)
+		if old.Rule.Pref == fwrule.Rule.Pref && old.Opts.SameAction(fwrule.Opts) {
+			plan.Keep++
+		} else {
+			plan.Update = append(plan.Update, FwSetUpdate{Old: old, New: fwrule})
+		}
+	}
+	current.SortByPref()
+	for _, fwrule := range current.FWInfo {
+		if _, ok := live[fwrule.Rule.Normalize()]; ok {
+			plan.Delete = append(plan.Delete, fwrule)
+		}
+	}
+	return plan
+}
+
+func PrintFwSetPlan(plan FwSetPlan) {
+	show := func(sign string, fwrule api.FwRuleMod) {
+		fmt.Printf("%s %s pref %d %s\n", sign, fwrule.Rule.MatchString(), fwrule.Rule.Pref, get.MakeFirewallOptionToString(fwrule.Opts))
+	}
+	for _, fwrule := range plan.Add {
+		show("+", fwrule)
+	}
+	for _, u := range plan.Update {
+		show("~", u.New)
+		if u.Old.Rule.Pref != u.New.Rule.Pref {
+			fmt.Printf("    pref %d -> %d\n", u.Old.Rule.Pref, u.New.Rule.Pref)
+		}
+	}
+	for _, fwrule := range plan.Delete {
+		show("-", fwrule)
+	}
+	fmt.Printf("%d to add, %d to update, %d to delete, %d unchanged\n",
+		len(plan.Add), len(plan.Update), len(plan.Delete), plan.Keep)
+}
+
+// ApplyFwSetPlan adds the new rules, replaces the updated ones and then deletes the stale ones.
+// When a change fails, the changes made before it are undone so that the ruleset is left as it was.
+func ApplyFwSetPlan(restOptions *api.RESTOptions, plan FwSetPlan) error {
+	var undo []func() error
+	fail := func(err error) error {
+		failed := 0
+		for i := len(undo) - 1; i >= 0; i-- {
+			if uerr := undo[i](); uerr != nil {
+				fmt.Printf("Error: rollback: %s\n", uerr.Error())
+				failed++
+			}
+		}
+		if failed > 0 {
+			return fmt.Errorf("%s - rolling back %d changes failed for %d of them", err.Error(), len(undo), failed)
+		}
+		return fmt.Errorf("%s - %d changes rolled back", err.Error(), len(undo))
+	}
+
+	for _, fwrule := range plan.Add {
+		if err := createFirewallRule(restOptions, fwrule); err != nil {
+			return fail(err)
+		}
+		undo = append(undo, func() error { return deleteFirewallRule(restOptions, fwrule.Rule) })
+	}
+	for _, u := range plan.Update {
+		if err := deleteFirewallRule(restOptions, u.Old.Rule); err != nil {
+			return fail(err)
+		}
+		if err := createFirewallRule(restOptions, u.New); err != nil {
+			undo = append(undo, func() error { return createFirewallRule(restOptions, u.Old) })
+			return fail(err)
+		}
+		undo = append(undo, func() error {
+			if err := deleteFirewallRule(restOptions, u.New.Rule); err != nil {
+				return err
+			}
+			return createFirewallRule(restOptions, u.Old)
+		})
+	}
+	for _, fwrule := range plan.Delete {
+		if err := deleteFirewallRule(restOptions, fwrule.Rule); err != nil {
+			return fail(err)
+		}
+		undo = append(undo, func() error { return createFirewallRule(restOptions, fwrule) })
+	}
+	return nil
+}
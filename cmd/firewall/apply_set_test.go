@@ -0,0 +1,134 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package firewall
+
+import (
+	"loxicmd/pkg/api"
+	"testing"
+)
+
+func TestMakeFwSetPlan(t *testing.T) {
+	web := api.FwRuleMod{Rule: api.FwRuleArg{SrcIP: "10.0.0.0/8", DstPortMin: 80, DstPortMax: 80, Proto: 6, Pref: 200}, Opts: api.FwOptArg{Allow: true}}
+	dns := api.FwRuleMod{Rule: api.FwRuleArg{DstIP: "8.8.8.8/32", DstPortMin: 53, DstPortMax: 53, Proto: 17, Pref: 100}, Opts: api.FwOptArg{Allow: true}}
+	deny := api.FwRuleMod{Rule: api.FwRuleArg{Pref: 10}, Opts: api.FwOptArg{Drop: true}}
+	with := func(fwrule api.FwRuleMod, f func(*api.FwRuleMod)) api.FwRuleMod {
+		f(&fwrule)
+		return fwrule
+	}
+
+	tests := []struct {
+		name    string
+		current []api.FwRuleMod
+		desired []api.FwRuleMod
+		add     []string
+		update  []string
+		delete  []string
+		keep    int
+	}{
+		{
+			name:    "empty to ruleset",
+			desired: []api.FwRuleMod{deny, web, dns},
+			add:     []string{web.Rule.MatchString(), dns.Rule.MatchString(), deny.Rule.MatchString()},
+		},
+		{
+			name:    "ruleset to empty",
+			current: []api.FwRuleMod{dns, deny, web},
+			delete:  []string{web.Rule.MatchString(), dns.Rule.MatchString(), deny.Rule.MatchString()},
+		},
+		{
+			name:    "same ruleset",
+			current: []api.FwRuleMod{web, dns, deny},
+			desired: []api.FwRuleMod{deny, dns, web},
+			keep:    3,
+		},
+		{
+			name: "counters are not a change",
+			current: []api.FwRuleMod{with(web, func(r *api.FwRuleMod) {
+				r.Opts.Counter, r.Opts.Packets, r.Opts.Bytes = "10:1000", 10, 1000
+			})},
+			desired: []api.FwRuleMod{web},
+			keep:    1,
+		},
+		{
+			name:    "equivalent cidrs match",
+			current: []api.FwRuleMod{with(web, func(r *api.FwRuleMod) { r.Rule.SrcIP = "10.1.2.3/8" }), with(deny, func(r *api.FwRuleMod) { r.Rule.DstIP = "0.0.0.0/0" })},
+			desired: []api.FwRuleMod{web, deny},
+			keep:    2,
+		},
+		{
+			name:    "pref change is an update",
+			current: []api.FwRuleMod{web},
+			desired: []api.FwRuleMod{with(web, func(r *api.FwRuleMod) { r.Rule.Pref = 300 })},
+			update:  []string{web.Rule.MatchString()},
+		},
+		{
+			name:    "action change is an update",
+			current: []api.FwRuleMod{web, dns},
+			desired: []api.FwRuleMod{with(web, func(r *api.FwRuleMod) { r.Opts = api.FwOptArg{Drop: true} }), dns},
+			update:  []string{web.Rule.MatchString()},
+			keep:    1,
+		},
+		{
+			name:    "match change is a delete and an add",
+			current: []api.FwRuleMod{web},
+			desired: []api.FwRuleMod{with(web, func(r *api.FwRuleMod) { r.Rule.DstPortMax = 90 })},
+			add:     []string{with(web, func(r *api.FwRuleMod) { r.Rule.DstPortMax = 90 }).Rule.MatchString()},
+			delete:  []string{web.Rule.MatchString()},
+		},
+		{
+			name:    "mixed",
+			current: []api.FwRuleMod{deny, web},
+			desired: []api.FwRuleMod{with(web, func(r *api.FwRuleMod) { r.Opts.Record = true }), dns},
+			add:     []string{dns.Rule.MatchString()},
+			update:  []string{web.Rule.MatchString()},
+			delete:  []string{deny.Rule.MatchString()},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := MakeFwSetPlan(api.FWInformationGet{FWInfo: tt.current}, api.FWInformationGet{FWInfo: tt.desired})
+			var update []string
+			for _, u := range plan.Update {
+				update = append(update, u.New.Rule.MatchString())
+			}
+			checkFwRules(t, "add", plan.Add, tt.add)
+			checkFwRules(t, "delete", plan.Delete, tt.delete)
+			if len(update) != len(tt.update) {
+				t.Fatalf("update %v, want %v", update, tt.update)
+			}
+			for i := range update {
+				if update[i] != tt.update[i] {
+					t.Errorf("update #%d %s, want %s", i, update[i], tt.update[i])
+				}
+			}
+			if plan.Keep != tt.keep {
+				t.Errorf("keep %d, want %d", plan.Keep, tt.keep)
+			}
+		})
+	}
+}
+
+func checkFwRules(t *testing.T, what string, got []api.FwRuleMod, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s %d rules, want %v", what, len(got), want)
+	}
+	for i, fwrule := range got {
+		if fwrule.Rule.MatchString() != want[i] {
+			t.Errorf("%s #%d %s, want %s", what, i, fwrule.Rule.MatchString(), want[i])
+		}
+	}
+}
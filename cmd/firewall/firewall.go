@@ -0,0 +1,120 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"loxicmd/cmd/delete"
+	"loxicmd/pkg/api"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func FirewallCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var firewallCmd = &cobra.Command{
+		Use:   "firewall",
+		Short: "Manage the firewall ruleset of the LoxiLB as a whole",
+		Long: `Manage the firewall ruleset of the LoxiLB as a whole.
+apply-set - Replace the ruleset with the rules of a file
+order     - Show the rules in evaluation order with shadowed or overlapping rules
+test      - Show which rule a packet would match
+`,
+		Aliases: []string{"fw"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			fmt.Printf("Error: unknown command \"%v\"for \"loxicmd\" \nRun \"loxicmd --help\" for usage.\n", args)
+			cmd.Help()
+			return err
+		},
+	}
+
+	firewallCmd.AddCommand(NewApplySetCmd(restOptions))
+	firewallCmd.AddCommand(NewOrderCmd(restOptions))
+	firewallCmd.AddCommand(NewTestCmd(restOptions))
+
+	return firewallCmd
+}
+
+func getFirewallRules(restOptions *api.RESTOptions) (api.FWInformationGet, error) {
+	fwresp := api.FWInformationGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Firewall().SetUrl("/config/firewall/all").Get(ctx)
+	if err != nil {
+		return fwresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fwresp, fmt.Errorf("failed to get firewall rules: %s", resp.Status)
+	}
+	err = api.DecodeList(resp.Body, "fwAttr", func(fwrule api.FwRuleMod) error {
+		fwresp.FWInfo = append(fwresp.FWInfo, fwrule)
+		return nil
+	})
+	return fwresp, err
+}
+
+func createFirewallRule(restOptions *api.RESTOptions, fwrule api.FwRuleMod) error {
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	// Counters are read-only
+	fwrule.Opts.Counter, fwrule.Opts.Packets, fwrule.Opts.Bytes = "", 0, 0
+	resp, err := client.Firewall().Create(ctx, fwrule)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to create rule '%s': %s", fwrule.Rule.MatchString(), resp.Status)
+	}
+	return nil
+}
+
+func deleteFirewallRule(restOptions *api.RESTOptions, rule api.FwRuleArg) error {
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Firewall().Query(delete.MakeFirewallMatchDeleteQuery(rule)).Delete(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to delete rule '%s': %s", rule.MatchString(), resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package firewall
+
+import (
+	"encoding/json"
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// FwOrderEntry is a rule at its position in the evaluation order
+type FwOrderEntry struct {
+	Order int           `json:"order"`
+	Rule  api.FwRuleMod `json:"rule"`
+	Notes []string      `json:"notes,omitempty"`
+}
+
+func NewOrderCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var file string
+
+	var orderCmd = &cobra.Command{
+		Use:   "order [--file=<ruleset file>]",
+		Short: "Show the firewall rules in evaluation order",
+		Long: `Show the firewall rules in the order loxilb evaluates them.
+A rule with a higher preference is evaluated first.
+
+The Note column flags
+  shadowed by #N  - rule #N matches every packet of this rule, so this rule never matches
+  overlaps #N     - some packets match both rules and rule #N takes them with another action
+  same pref as #N - the rules overlap with an equal preference, so their order is not defined
+
+ex) loxicmd firewall order
+    loxicmd firewall order --file rules.yaml
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			var fwresp api.FWInformationGet
+			var err error
+			if file != "" {
+				fwresp, err = ReadFwRuleSet(file)
+			} else {
+				fwresp, err = getFirewallRules(restOptions)
+			}
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			PrintFwOrder(MakeFwOrder(fwresp), *restOptions)
+		},
+	}
+
+	orderCmd.Flags().StringVarP(&file, "file", "f", "", "Ruleset file to show instead of the live rules")
+	return orderCmd
+}
+
+// ReadFwRuleSet reads a ruleset file in the "fwAttr" format of "loxicmd save --firewall",
+// either in JSON or YAML, and validates every rule.
+func ReadFwRuleSet(file string) (api.FWInformationGet, error) {
+	fwresp := api.FWInformationGet{}
+	byteBuf, err := os.ReadFile(file)
+	if err != nil {
+		return fwresp, err
+	}
+	if err := yaml.Unmarshal(byteBuf, &fwresp); err != nil {
+		return fwresp, fmt.Errorf("failed to parse %s: %s", file, err.Error())
+	}
+	seen := map[api.FwRuleArg]int{}
+	for i, fwrule := range fwresp.FWInfo {
+		if err := fwrule.Rule.Validation(); err != nil {
+			return fwresp, fmt.Errorf("rule %d of %s: %s", i+1, file, err.Error())
+		}
+		key := fwrule.Rule.Normalize()
+		if j, ok := seen[key]; ok {
+			return fwresp, fmt.Errorf("rule %d of %s is the same as rule %d", i+1, file, j+1)
+		}
+		seen[key] = i
+	}
+	return fwresp, nil
+}
+
+// MakeFwOrder sorts the rules in evaluation order and notes the rules
+// shadowed by or overlapping with an earlier rule.
+func MakeFwOrder(fwresp api.FWInformationGet) []FwOrderEntry {
+	fwresp.SortByPref()
+	entries := make([]FwOrderEntry, len(fwresp.FWInfo))
+	for j, fwrule := range fwresp.FWInfo {
+		entries[j] = FwOrderEntry{Order: j + 1, Rule: fwrule}
+		for i := 0; i < j; i++ {
+			earlier := fwresp.FWInfo[i]
+			if !earlier.Rule.Overlaps(fwrule.Rule) {
+				continue
+			}
+			if earlier.Rule.Pref == fwrule.Rule.Pref {
+				entries[j].Notes = append(entries[j].Notes, fmt.Sprintf("same pref as #%d", i+1))
+			} else if earlier.Rule.Covers(fwrule.Rule) {
+				entries[j].Notes = append(entries[j].Notes, fmt.Sprintf("shadowed by #%d", i+1))
+			} else if !earlier.Opts.SameAction(fwrule.Opts) {
+				entries[j].Notes = append(entries[j].Notes, fmt.Sprintf("overlaps #%d", i+1))
+			}
+		}
+	}
+	return entries
+}
+
+func PrintFwOrder(entries []FwOrderEntry, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(entries, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	var data [][]string
+	table := get.TableInit()
+	table.SetHeader(get.FIREWALL_ORDER_TITLE)
+	for _, e := range entries {
+		data = append(data, []string{fmt.Sprintf("%d", e.Order), e.Rule.Rule.MatchString(),
+			fmt.Sprintf("%d", e.Rule.Rule.Pref), get.MakeFirewallOptionToString(e.Rule.Opts),
+			strings.Join(e.Notes, ", ")})
+	}
+	get.TableShow(data, table)
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package firewall
+
+import (
+	"loxicmd/pkg/api"
+	"strings"
+	"testing"
+)
+
+func TestMakeFwOrder(t *testing.T) {
+	fwresp := api.FWInformationGet{FWInfo: []api.FwRuleMod{
+		{Rule: api.FwRuleArg{Pref: 10}, Opts: api.FwOptArg{Drop: true}},
+		{Rule: api.FwRuleArg{SrcIP: "10.0.0.0/8", Proto: 6, Pref: 300}, Opts: api.FwOptArg{Allow: true}},
+		{Rule: api.FwRuleArg{SrcIP: "10.1.0.0/16", Proto: 6, Pref: 200}, Opts: api.FwOptArg{Drop: true}},
+		{Rule: api.FwRuleArg{SrcIP: "10.0.0.0/8", DstPortMin: 80, DstPortMax: 80, Pref: 250}, Opts: api.FwOptArg{Drop: true}},
+		{Rule: api.FwRuleArg{SrcIP: "10.0.0.0/8", DstPortMin: 80, DstPortMax: 80, Pref: 240}, Opts: api.FwOptArg{Allow: true}},
+		{Rule: api.FwRuleArg{SrcIP: "11.0.0.0/8", Pref: 300}, Opts: api.FwOptArg{Trap: true}},
+		{Rule: api.FwRuleArg{SrcIP: "11.0.0.0/8", Proto: 17, Pref: 300}, Opts: api.FwOptArg{Allow: true}},
+	}}
+	want := []struct {
+		src   string
+		pref  uint16
+		notes string
+	}{
+		{"10.0.0.0/8", 300, ""},
+		{"11.0.0.0/8", 300, ""},
+		{"11.0.0.0/8", 300, "same pref as #2"},
+		{"10.0.0.0/8", 250, "overlaps #1"},
+		{"10.0.0.0/8", 240, "shadowed by #4"},
+		{"10.1.0.0/16", 200, "shadowed by #1, overlaps #5"},
+		// #4 and #6 drop too, so they are not noted
+		{"", 10, "overlaps #1, overlaps #2, overlaps #3, overlaps #5"},
+	}
+
+	entries := MakeFwOrder(fwresp)
+	if len(entries) != len(want) {
+		t.Fatalf("%d entries, want %d", len(entries), len(want))
+	}
+	for i, e := range entries {
+		if e.Order != i+1 {
+			t.Errorf("entry %d has order %d", i, e.Order)
+		}
+		if e.Rule.Rule.SrcIP != want[i].src || e.Rule.Rule.Pref != want[i].pref {
+			t.Errorf("#%d is %s pref %d, want %s pref %d", i+1, e.Rule.Rule.SrcIP, e.Rule.Rule.Pref, want[i].src, want[i].pref)
+		}
+		if notes := strings.Join(e.Notes, ", "); notes != want[i].notes {
+			t.Errorf("#%d notes %q, want %q", i+1, notes, want[i].notes)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package firewall
+
+import (
+	"encoding/json"
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"net"
+
+	"github.com/spf13/cobra"
+)
+
+type FwTestOptions struct {
+	SrcIP   string
+	DstIP   string
+	SrcPort uint16
+	DstPort uint16
+	Proto   string
+	InPort  string
+	File    string
+}
+
+// FwTestResult is the first matching rule of a packet and the later rules it also matches
+type FwTestResult struct {
+	Matched    *FwOrderEntry  `json:"matched"`
+	NotReached []FwOrderEntry `json:"notReached,omitempty"`
+}
+
+func NewTestCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := FwTestOptions{}
+
+	var testCmd = &cobra.Command{
+		Use:   "test --src=<ip> --dst=<ip> [--sport=<port>] [--dport=<port>] [--proto=<name|number>] [--in=<portName>] [--file=<ruleset file>]",
+		Short: "Show which firewall rule a packet would match",
+		Long: `Evaluate the firewall rules for a packet in loxicmd and show the rule that matches
+first and its action. Later rules that match the packet too are shown as not reached.
+
+The live rules are used unless --file gives a ruleset file or a "loxicmd save --firewall" dump.
+
+ex) loxicmd firewall test --src 10.1.1.5 --dst 20.0.0.1 --dport 443 --proto tcp --in eth0
+    loxicmd firewall test --src 10.1.1.5 --dst 20.0.0.1 --proto icmp --file /etc/loxilb/FWconfig.txt
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			pkt, err := MakeFwPacket(o)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			var fwresp api.FWInformationGet
+			if o.File != "" {
+				fwresp, err = ReadFwRuleSet(o.File)
+			} else {
+				fwresp, err = getFirewallRules(restOptions)
+			}
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			PrintFwTestResult(TestFwPacket(fwresp, pkt), *restOptions)
+		},
+	}
+
+	testCmd.Flags().StringVarP(&o.SrcIP, "src", "", "", "Source IP of the packet")
+	testCmd.Flags().StringVarP(&o.DstIP, "dst", "", "", "Destination IP of the packet")
+	testCmd.Flags().Uint16VarP(&o.SrcPort, "sport", "", 0, "Source port of the packet")
+	testCmd.Flags().Uint16VarP(&o.DstPort, "dport", "", 0, "Destination port of the packet")
+	testCmd.Flags().StringVarP(&o.Proto, "proto", "", "tcp", "Protocol name or number of the packet")
+	testCmd.Flags().StringVarP(&o.InPort, "in", "", "", "Incoming port of the packet")
+	testCmd.Flags().StringVarP(&o.File, "file", "f", "", "Ruleset file to test instead of the live rules")
+	testCmd.MarkFlagRequired("src")
+	testCmd.MarkFlagRequired("dst")
+	return testCmd
+}
+
+func MakeFwPacket(o FwTestOptions) (api.FwPacket, error) {
+	pkt := api.FwPacket{SrcPort: o.SrcPort, DstPort: o.DstPort, InPort: o.InPort}
+	if pkt.SrcIP = net.ParseIP(o.SrcIP); pkt.SrcIP == nil {
+		return pkt, fmt.Errorf("src '%s' is not a valid IP", o.SrcIP)
+	}
+	if pkt.DstIP = net.ParseIP(o.DstIP); pkt.DstIP == nil {
+		return pkt, fmt.Errorf("dst '%s' is not a valid IP", o.DstIP)
+	}
+	if (pkt.SrcIP.To4() == nil) != (pkt.DstIP.To4() == nil) {
+		return pkt, fmt.Errorf("src %s and dst %s are not the same IP family", o.SrcIP, o.DstIP)
+	}
+	proto, err := api.ParseFwProto(o.Proto)
+	if err != nil {
+		return pkt, err
+	}
+	if proto == 0 {
+		return pkt, fmt.Errorf("a packet needs a protocol")
+	}
+	pkt.Proto = proto
+	return pkt, nil
+}
+
+// TestFwPacket evaluates the rules in evaluation order for the packet
+func TestFwPacket(fwresp api.FWInformationGet, pkt api.FwPacket) FwTestResult {
+	result := FwTestResult{}
+	for _, e := range MakeFwOrder(fwresp) {
+		if !e.Rule.Rule.Matches(pkt) {
+			continue
+		}
+		if result.Matched == nil {
+			matched := e
+			result.Matched = &matched
+		} else {
+			result.NotReached = append(result.NotReached, e)
+		}
+	}
+	return result
+}
+
+func PrintFwTestResult(result FwTestResult, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(result, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+	if result.Matched == nil {
+		fmt.Printf("No rule matches. The firewall doesn't act on the packet.\n")
+		return
+	}
+
+	var data [][]string
+	table := get.TableInit()
+	table.SetHeader(get.FIREWALL_TEST_TITLE)
+	row := func(e FwOrderEntry, res string) []string {
+		return []string{fmt.Sprintf("%d", e.Order), e.Rule.Rule.MatchString(),
+			fmt.Sprintf("%d", e.Rule.Rule.Pref), get.MakeFirewallOptionToString(e.Rule.Opts), res}
+	}
+	data = append(data, row(*result.Matched, "match"))
+	for _, e := range result.NotReached {
+		data = append(data, row(e, "not reached"))
+	}
+	get.TableShow(data, table)
+	fmt.Printf("Action: %s\n", get.MakeFirewallOptionToString(result.Matched.Rule.Opts))
+}
@@ -0,0 +1,101 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package firewall
+
+import (
+	"loxicmd/pkg/api"
+	"testing"
+)
+
+func TestMakeFwPacket(t *testing.T) {
+	tests := []struct {
+		name  string
+		o     FwTestOptions
+		proto uint8
+		isErr bool
+	}{
+		{"tcp", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.1", DstPort: 443, Proto: "tcp"}, 6, false},
+		{"protocol number", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.1", Proto: "132"}, 132, false},
+		{"v6", FwTestOptions{SrcIP: "2001:db8::1", DstIP: "2001:db8::2", Proto: "icmpv6"}, 58, false},
+		{"bad src", FwTestOptions{SrcIP: "10.1.1", DstIP: "20.0.0.1", Proto: "tcp"}, 0, true},
+		{"bad dst", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "", Proto: "tcp"}, 0, true},
+		{"mixed families", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "2001:db8::2", Proto: "tcp"}, 0, true},
+		{"any protocol", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.1", Proto: "any"}, 0, true},
+		{"no protocol", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.1"}, 0, true},
+		{"unknown protocol", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.1", Proto: "gre"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkt, err := MakeFwPacket(tt.o)
+			if (err != nil) != tt.isErr {
+				t.Fatalf("err = %v, want error %v", err, tt.isErr)
+			}
+			if err == nil && pkt.Proto != tt.proto {
+				t.Errorf("proto = %d, want %d", pkt.Proto, tt.proto)
+			}
+		})
+	}
+}
+
+var testFwRules = api.FWInformationGet{FWInfo: []api.FwRuleMod{
+	{Rule: api.FwRuleArg{Pref: 100}, Opts: api.FwOptArg{Drop: true}},
+	{Rule: api.FwRuleArg{SrcIP: "10.0.0.0/8", DstPortMin: 80, DstPortMax: 90, Proto: 6, Pref: 200}, Opts: api.FwOptArg{Allow: true}},
+	{Rule: api.FwRuleArg{SrcIP: "10.1.0.0/16", DstPortMin: 85, DstPortMax: 85, Proto: 6, Pref: 150}, Opts: api.FwOptArg{Drop: true}},
+	{Rule: api.FwRuleArg{DstIP: "20.0.0.1/32", Proto: 17, Pref: 300}, Opts: api.FwOptArg{Trap: true}},
+}}
+
+func TestTestFwPacket(t *testing.T) {
+	tests := []struct {
+		name       string
+		o          FwTestOptions
+		matched    uint16 // preference of the matched rule, 0 for no match
+		notReached []uint16
+	}{
+		{"range start", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.2", DstPort: 80, Proto: "tcp"}, 200, []uint16{100}},
+		{"higher pref wins", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.2", DstPort: 85, Proto: "tcp"}, 200, []uint16{150, 100}},
+		{"outside range", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.2", DstPort: 91, Proto: "tcp"}, 100, nil},
+		{"other protocol", FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.1", DstPort: 80, Proto: "udp"}, 300, []uint16{100}},
+		{"v6 only hits any-address rules", FwTestOptions{SrcIP: "2001:db8::1", DstIP: "2001:db8::2", DstPort: 80, Proto: "tcp"}, 100, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkt, err := MakeFwPacket(tt.o)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result := TestFwPacket(testFwRules, pkt)
+			if result.Matched == nil {
+				t.Fatalf("no rule matched, want pref %d", tt.matched)
+			}
+			if result.Matched.Rule.Rule.Pref != tt.matched {
+				t.Errorf("matched pref %d, want %d", result.Matched.Rule.Rule.Pref, tt.matched)
+			}
+			if len(result.NotReached) != len(tt.notReached) {
+				t.Fatalf("%d rules not reached, want %d", len(result.NotReached), len(tt.notReached))
+			}
+			for i, e := range result.NotReached {
+				if e.Rule.Rule.Pref != tt.notReached[i] {
+					t.Errorf("not reached #%d has pref %d, want %d", i, e.Rule.Rule.Pref, tt.notReached[i])
+				}
+			}
+		})
+	}
+
+	pkt, _ := MakeFwPacket(FwTestOptions{SrcIP: "10.1.1.5", DstIP: "20.0.0.1", Proto: "tcp"})
+	if result := TestFwPacket(api.FWInformationGet{}, pkt); result.Matched != nil {
+		t.Errorf("empty ruleset matched %+v", result.Matched)
+	}
+}
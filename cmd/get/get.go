@@ -18,6 +18,8 @@ package get
 import (
 	"fmt"
 	"os"
+	"strings"
+	"text/tabwriter"
 
 	"loxicmd/pkg/api"
 
@@ -67,6 +69,7 @@ func GetCmd(restOptions *api.RESTOptions) *cobra.Command {
 	GetCmd.AddCommand(NewGetEndPointCmd(restOptions))
 	GetCmd.AddCommand(NewGetLogLevelCmd(restOptions))
 	GetCmd.AddCommand(NewGetBGPNeighborCmd(restOptions))
+	GetCmd.AddCommand(NewGetBGPCmd(restOptions))
 	GetCmd.AddCommand(NewGetHaStateCmd(restOptions))
 	GetCmd.AddCommand(NewGetBFDCmd(restOptions))
 	GetCmd.AddCommand(NewGetVersionCmd(restOptions))
@@ -74,15 +77,78 @@ func GetCmd(restOptions *api.RESTOptions) *cobra.Command {
 	return GetCmd
 }
 
-func TableInit() *tablewriter.Table {
+// Table is a tablewriter table that remembers its header
+type Table struct {
+	*tablewriter.Table
+	header []string
+}
+
+func (t *Table) SetHeader(keys []string) {
+	t.header = keys
+	t.Table.SetHeader(keys)
+}
+
+// TableRows - a table shown while the tables are collected
+type TableRows struct {
+	Header []string
+	Rows   [][]string
+}
+
+// tableCollector receives the tables of TableShow instead of stdout when set
+var tableCollector func(TableRows)
+
+// CollectTables makes TableShow pass the tables to fn instead of printing them
+// until the returned function is called.
+func CollectTables(fn func(TableRows)) (restore func()) {
+	prev := tableCollector
+	tableCollector = fn
+	return func() { tableCollector = prev }
+}
+
+func TableInit() *Table {
 	// Table Init
 	table := tablewriter.NewWriter(os.Stdout)
 	table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	table.SetCenterSeparator("|")
-	return table
+	return &Table{Table: table}
 }
 
-func TableShow(data [][]string, table *tablewriter.Table) {
+func TableShow(data [][]string, table *Table) {
+	if tableCollector != nil {
+		tableCollector(TableRows{Header: table.header, Rows: data})
+		return
+	}
 	table.AppendBulk(data)
 	table.Render()
 }
+
+// StreamTable prints rows as soon as they are appended instead of rendering
+// the whole table at once. Columns are aligned per block of rows.
+type StreamTable struct {
+	w    *tabwriter.Writer
+	rows int
+}
+
+const streamTableBlock = 256
+
+func StreamTableInit(header []string) *StreamTable {
+	t := &StreamTable{w: tabwriter.NewWriter(os.Stdout, 8, 0, 2, ' ', 0)}
+	upper := make([]string, len(header))
+	for i, h := range header {
+		upper[i] = strings.ToUpper(strings.ReplaceAll(h, "\n", " "))
+	}
+	fmt.Fprintln(t.w, strings.Join(upper, "\t"))
+	return t
+}
+
+func (t *StreamTable) Append(row []string) {
+	fmt.Fprintln(t.w, strings.Join(row, "\t"))
+	t.rows++
+	if t.rows%streamTableBlock == 0 {
+		t.w.Flush()
+	}
+}
+
+func (t *StreamTable) Flush() {
+	t.w.Flush()
+}
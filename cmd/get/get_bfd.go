@@ -25,19 +25,59 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
+type GetBFDOptions struct {
+	Watch         bool
+	WatchInterval time.Duration
+}
+
+// BFDTransition - a state change of a BFD session seen by loxicmd
+type BFDTransition struct {
+	Time     time.Time `json:"time"`
+	Instance string    `json:"instance"`
+	RemoteIP string    `json:"remoteIp"`
+	From     string    `json:"from"`
+	To       string    `json:"to"`
+	// Duration - how long the session was in the previous state. It is at least
+	// this long when the previous state was already there when watching started.
+	Duration time.Duration `json:"duration"`
+	AtLeast  bool          `json:"atLeast,omitempty"`
+}
+
+type bfdWatchEntry struct {
+	state       string
+	since       time.Time
+	seenSince   bool
+	transitions int
+}
+
 func NewGetBFDCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := GetBFDOptions{}
+
 	var GetBFDCmd = &cobra.Command{
-		Use:   "bfd",
+		Use:   "bfd [--watch [--watch-interval=<duration>]]",
 		Short: "Get all BFD sessions",
-		Long:  `It shows BFD Sessions in the LoxiLB`,
+		Long: `It shows BFD Sessions in the LoxiLB
+--watch keeps polling the sessions and prints each state transition with the time spent
+in the previous state. The history is recorded by loxicmd and summarized at Ctrl-C.
+
+ex) loxicmd get bfd -o wide
+    loxicmd get bfd --watch --watch-interval=500ms`,
 
 		Run: func(cmd *cobra.Command, args []string) {
+			if o.Watch {
+				if err := WatchBFD(restOptions, o.WatchInterval); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+				}
+				return
+			}
 			client := api.NewLoxiClient(restOptions)
 			ctx := context.TODO()
 			var cancel context.CancelFunc
@@ -58,12 +98,14 @@ func NewGetBFDCmd(restOptions *api.RESTOptions) *cobra.Command {
 		},
 	}
 
+	GetBFDCmd.Flags().BoolVarP(&o.Watch, "watch", "w", false, "Watch the state transitions of the sessions")
+	GetBFDCmd.Flags().DurationVarP(&o.WatchInterval, "watch-interval", "", 2*time.Second, "Polling interval of --watch")
+
 	return GetBFDCmd
 }
 
 func PrintGetBFDResult(resp *http.Response, o api.RESTOptions) {
 	BFDresp := api.BFDSessionGet{}
-	var data [][]string
 	resultByte, err := io.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
@@ -82,6 +124,11 @@ func PrintGetBFDResult(resp *http.Response, o api.RESTOptions) {
 		return
 	}
 
+	printBFDSessions(BFDresp, o)
+}
+
+func printBFDSessions(BFDresp api.BFDSessionGet, o api.RESTOptions) {
+	var data [][]string
 	// Table Init
 	table := TableInit()
 
@@ -90,7 +137,8 @@ func PrintGetBFDResult(resp *http.Response, o api.RESTOptions) {
 		if o.PrintOption == "wide" {
 			table.SetHeader(BFD_WIDE_TITLE)
 			data = append(data, []string{bfd.Instance, bfd.RemoteIP, bfd.SourceIP,
-				fmt.Sprintf("%d", bfd.Port), fmt.Sprintf("%d us", bfd.Interval), fmt.Sprintf("%d", bfd.RetryCount), bfd.State})
+				fmt.Sprintf("%d", bfd.Port), api.FormatBFDInterval(bfd.Interval), api.FormatBFDInterval(bfd.MinRxInterval),
+				fmt.Sprintf("%d", bfd.RetryCount), bfd.DetectTime().String(), bfd.State})
 		} else {
 			table.SetHeader(BFD_TITLE)
 			data = append(data, []string{bfd.Instance, bfd.RemoteIP, bfd.State})
@@ -100,6 +148,160 @@ func PrintGetBFDResult(resp *http.Response, o api.RESTOptions) {
 	TableShow(data, table)
 }
 
+// GetBFDSessions gets all BFD sessions
+func GetBFDSessions(restOptions *api.RESTOptions) (api.BFDSessionGet, error) {
+	BFDresp := api.BFDSessionGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.BFDSession().SetUrl("config/bfd/all").Get(ctx)
+	if err != nil {
+		return BFDresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return BFDresp, fmt.Errorf("failed to get BFD sessions: %s", resp.Status)
+	}
+	resultByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return BFDresp, err
+	}
+	err = json.Unmarshal(resultByte, &BFDresp)
+	return BFDresp, err
+}
+
+// WatchBFD polls the BFD sessions and prints their state transitions until Ctrl-C
+func WatchBFD(restOptions *api.RESTOptions, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval should be more than 0")
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	sessions, err := GetBFDSessions(restOptions)
+	if err != nil {
+		return err
+	}
+	if restOptions.PrintOption != "json" {
+		printBFDSessions(sessions, *restOptions)
+		fmt.Printf("Watching BFD sessions every %s. Press Ctrl-C to stop.\n", interval)
+	}
+	entries := map[string]*bfdWatchEntry{}
+	var order []string
+	now := time.Now()
+	for _, bfd := range sessions.BFDSessionAttr {
+		entries[bfd.Key()] = &bfdWatchEntry{state: bfd.State, since: now}
+		order = append(order, bfd.Key())
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastErr := ""
+	for {
+		select {
+		case <-sigCh:
+			printBFDHistory(entries, order, *restOptions)
+			return nil
+		case <-ticker.C:
+		}
+		sessions, err := GetBFDSessions(restOptions)
+		if err != nil {
+			// Print the same error only once
+			if err.Error() != lastErr {
+				fmt.Printf("%s Warning: %s\n", time.Now().Format(time.DateTime), err.Error())
+				lastErr = err.Error()
+			}
+			continue
+		}
+		lastErr = ""
+		now := time.Now()
+		seen := map[string]bool{}
+		for _, bfd := range sessions.BFDSessionAttr {
+			key := bfd.Key()
+			seen[key] = true
+			e, ok := entries[key]
+			if !ok {
+				e = &bfdWatchEntry{state: "-", since: now, seenSince: true}
+				entries[key] = e
+				order = append(order, key)
+			}
+			if e.state == bfd.State {
+				continue
+			}
+			printBFDTransition(e.transition(bfd.Instance, bfd.RemoteIP, bfd.State, now), *restOptions)
+		}
+		for _, key := range order {
+			e := entries[key]
+			if seen[key] || e.state == "-" {
+				continue
+			}
+			instance, remoteIP, _ := strings.Cut(key, "/")
+			printBFDTransition(e.transition(instance, remoteIP, "-", now), *restOptions)
+		}
+	}
+}
+
+func (e *bfdWatchEntry) transition(instance, remoteIP, state string, now time.Time) BFDTransition {
+	t := BFDTransition{
+		Time:     now,
+		Instance: instance,
+		RemoteIP: remoteIP,
+		From:     e.state,
+		To:       state,
+		Duration: now.Sub(e.since).Round(time.Millisecond),
+		AtLeast:  !e.seenSince,
+	}
+	e.state, e.since, e.seenSince = state, now, true
+	e.transitions++
+	return t
+}
+
+// printBFDTransition prints a transition. "-" is a session which is created or deleted.
+func printBFDTransition(t BFDTransition, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		result, _ := json.Marshal(t)
+		fmt.Println(string(result))
+		return
+	}
+	duration := t.Duration.String()
+	if t.AtLeast {
+		duration = ">=" + duration
+	}
+	switch {
+	case t.From == "-":
+		fmt.Printf("%s %s/%s created as %s\n", t.Time.Format(time.DateTime), t.Instance, t.RemoteIP, t.To)
+	case t.To == "-":
+		fmt.Printf("%s %s/%s deleted (%s in %s)\n", t.Time.Format(time.DateTime), t.Instance, t.RemoteIP, duration, t.From)
+	default:
+		fmt.Printf("%s %s/%s %s -> %s (%s in %s)\n", t.Time.Format(time.DateTime), t.Instance, t.RemoteIP, t.From, t.To, duration, t.From)
+	}
+}
+
+func printBFDHistory(entries map[string]*bfdWatchEntry, order []string, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		return
+	}
+	fmt.Println()
+	var data [][]string
+	table := TableInit()
+	table.SetHeader(BFD_HISTORY_TITLE)
+	for _, key := range order {
+		e := entries[key]
+		instance, remoteIP, _ := strings.Cut(key, "/")
+		last := "-"
+		if e.transitions > 0 {
+			last = e.since.Format(time.DateTime)
+		}
+		data = append(data, []string{instance, remoteIP, e.state, fmt.Sprintf("%d", e.transitions), last})
+	}
+	TableShow(data, table)
+}
+
 func BFDdump(restOptions *api.RESTOptions, path string) (string, error) {
 	BFDresp := api.BFDSessionGet{}
 
@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package get
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"loxicmd/pkg/api"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewGetBGPCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var GetBGPCmd = &cobra.Command{
+		Use:   "bgp",
+		Short: "Get BGP routes, advertisements and policies",
+		Long: `It shows BGP information in the LoxiLB.
+	routes     - BGP table, optionally of one neighbor
+	advertised - prefixes advertised to a neighbor
+	policy     - import and export policies
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+		},
+	}
+
+	GetBGPCmd.AddCommand(NewGetBGPRoutesCmd(restOptions))
+	GetBGPCmd.AddCommand(NewGetBGPAdvertisedCmd(restOptions))
+	GetBGPCmd.AddCommand(NewGetBGPPolicyCmd(restOptions))
+	return GetBGPCmd
+}
+
+func NewGetBGPRoutesCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var peer string
+
+	var GetBGPRoutesCmd = &cobra.Command{
+		Use:   "routes [--peer=<PeerIP>]",
+		Short: "Get the BGP table",
+		Long: `It shows the BGP table in the LoxiLB. --peer shows only the paths received from the neighbor.
+-o wide also shows MED, local preference, communities and age.
+
+ex) loxicmd get bgp routes
+    loxicmd get bgp routes --peer 192.168.10.2 -o wide
+`,
+		Aliases: []string{"route", "rib"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if peer != "" && net.ParseIP(peer) == nil {
+				fmt.Printf("Error: peer IP '%s' is invalid format\n", peer)
+				return
+			}
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			req := client.BGPRoute().SubResources([]string{"all"})
+			if peer != "" {
+				req = req.Query(map[string]string{"peer": peer})
+			}
+			resp, err := req.Get(ctx)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				PrintGetBGPRouteResult(resp, *restOptions)
+				return
+			}
+			fmt.Printf("Error: failed to get BGP routes: %s\n", resp.Status)
+		},
+	}
+
+	GetBGPRoutesCmd.Flags().StringVarP(&peer, "peer", "", "", "Show only the paths received from the neighbor")
+	return GetBGPRoutesCmd
+}
+
+func NewGetBGPAdvertisedCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var peer string
+
+	var GetBGPAdvertisedCmd = &cobra.Command{
+		Use:   "advertised --peer=<PeerIP>",
+		Short: "Get the prefixes advertised to a BGP neighbor",
+		Long: `It shows the prefixes the LoxiLB advertises to a BGP neighbor after the export policies,
+including the VIPs of load balancer rules with BGP enabled.
+
+ex) loxicmd get bgp advertised --peer 192.168.10.2
+`,
+		Aliases: []string{"adv"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if net.ParseIP(peer) == nil {
+				fmt.Printf("Error: peer IP '%s' is invalid format\n", peer)
+				return
+			}
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			resp, err := client.BGPNeighbor().SubResources([]string{peer, "advertised"}).Get(ctx)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				PrintGetBGPRouteResult(resp, *restOptions)
+				return
+			}
+			fmt.Printf("Error: failed to get advertised prefixes of %s: %s\n", peer, resp.Status)
+		},
+	}
+
+	GetBGPAdvertisedCmd.Flags().StringVarP(&peer, "peer", "", "", "BGP neighbor to show")
+	GetBGPAdvertisedCmd.MarkFlagRequired("peer")
+	return GetBGPAdvertisedCmd
+}
+
+func PrintGetBGPRouteResult(resp *http.Response, o api.RESTOptions) {
+	routeresp := api.BGPRouteGet{}
+	var data [][]string
+	if err := json.NewDecoder(resp.Body).Decode(&routeresp); err != nil {
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+		return
+	}
+
+	// if json options enable, it print as a json format.
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(routeresp, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	routeresp.Sort()
+
+	// Table Init
+	table := TableInit()
+
+	for _, r := range routeresp.BGPRouteAttr {
+		best := ""
+		if r.Best {
+			best = "*"
+		}
+		peer := r.Peer
+		if peer == "" {
+			peer = "local"
+		}
+		if o.PrintOption == "wide" {
+			table.SetHeader(BGP_ROUTE_WIDE_TITLE)
+			data = append(data, []string{best, r.Prefix, r.NextHop, peer, r.AsPathString(), fmt.Sprintf("%d", r.Med),
+				fmt.Sprintf("%d", r.LocalPref), strings.Join(r.Communities, " "), r.Age})
+		} else {
+			table.SetHeader(BGP_ROUTE_TITLE)
+			data = append(data, []string{best, r.Prefix, r.NextHop, peer, r.AsPathString()})
+		}
+	}
+	TableShow(data, table)
+}
+
+func NewGetBGPPolicyCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var GetBGPPolicyCmd = &cobra.Command{
+		Use:     "policy",
+		Short:   "Get the BGP policies",
+		Long:    `It shows the BGP import and export policies in the LoxiLB.`,
+		Aliases: []string{"policies", "pol"},
+		Run: func(cmd *cobra.Command, args []string) {
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			resp, err := client.BGPPolicy().SubResources([]string{"all"}).Get(ctx)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				PrintGetBGPPolicyResult(resp, *restOptions)
+				return
+			}
+			fmt.Printf("Error: failed to get BGP policies: %s\n", resp.Status)
+		},
+	}
+
+	return GetBGPPolicyCmd
+}
+
+func PrintGetBGPPolicyResult(resp *http.Response, o api.RESTOptions) {
+	polresp := api.BGPPolicyModGet{}
+	var data [][]string
+	if err := json.NewDecoder(resp.Body).Decode(&polresp); err != nil {
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+		return
+	}
+
+	// if json options enable, it print as a json format.
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(polresp, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	polresp.Sort()
+
+	// Table Init
+	table := TableInit()
+	for _, pol := range polresp.BGPPolicyAttr {
+		table.SetHeader(BGP_POLICY_TITLE)
+		peers := strings.Join(pol.Peers, "\n")
+		if peers == "" {
+			peers = "all"
+		}
+		var prefixes []string
+		for _, p := range pol.Prefixes {
+			prefixes = append(prefixes, p.String())
+		}
+		if len(prefixes) == 0 {
+			prefixes = []string{"all"}
+		}
+		data = append(data, []string{pol.Name, pol.Direction, peers, strings.Join(prefixes, "\n"), pol.Action, MakeBGPPolicySetString(pol)})
+	}
+	TableShow(data, table)
+}
+
+// MakeBGPPolicySetString renders the attributes a policy sets
+func MakeBGPPolicySetString(pol api.BGPPolicyMod) string {
+	var set []string
+	if len(pol.SetCommunities) != 0 {
+		set = append(set, "community "+strings.Join(pol.SetCommunities, " "))
+	}
+	if pol.SetMed != nil {
+		set = append(set, fmt.Sprintf("med %d", *pol.SetMed))
+	}
+	if pol.SetLocalPref != nil {
+		set = append(set, fmt.Sprintf("local-pref %d", *pol.SetLocalPref))
+	}
+	return strings.Join(set, "\n")
+}
@@ -29,9 +29,11 @@ import (
 
 func NewGetBGPNeighborCmd(restOptions *api.RESTOptions) *cobra.Command {
 	var GetBGPNeighborCmd = &cobra.Command{
-		Use:     "bgpneighbor",
-		Short:   "Get a BGP neighbor",
-		Long:    `It shows BGP neighbor Information in the LoxiLB`,
+		Use:   "bgpneighbor",
+		Short: "Get a BGP neighbor",
+		Long: `It shows BGP neighbor Information in the LoxiLB.
+-o wide also shows the prefixes received from and advertised to each neighbor and the last error.
+`,
 		Aliases: []string{"bgpnei", "bgpneigh"},
 		Run: func(cmd *cobra.Command, args []string) {
 			client := api.NewLoxiClient(restOptions)
@@ -86,8 +88,14 @@ func PrintGetBGPNeighborResult(resp *http.Response, o api.RESTOptions) {
 	// Making BGPNeighbor data
 	for _, BGPNeighbor := range BGPNeighborresp.BGPAttr {
 
-		table.SetHeader(BGPNEIGHBOR_TITLE)
-		data = append(data, []string{BGPNeighbor.IPaddress, fmt.Sprintf("%d", BGPNeighbor.RemoteAs), BGPNeighbor.UpDownTime, BGPNeighbor.State})
+		if o.PrintOption == "wide" {
+			table.SetHeader(BGPNEIGHBOR_WIDE_TITLE)
+			data = append(data, []string{BGPNeighbor.IPaddress, fmt.Sprintf("%d", BGPNeighbor.RemoteAs), BGPNeighbor.UpDownTime, BGPNeighbor.State,
+				fmt.Sprintf("%d", BGPNeighbor.PrefixesReceived), fmt.Sprintf("%d", BGPNeighbor.PrefixesAdvertised), BGPNeighbor.LastError})
+		} else {
+			table.SetHeader(BGPNEIGHBOR_TITLE)
+			data = append(data, []string{BGPNeighbor.IPaddress, fmt.Sprintf("%d", BGPNeighbor.RemoteAs), BGPNeighbor.UpDownTime, BGPNeighbor.State})
+		}
 
 	}
 	// Rendering the BGPNeighbor data to table
@@ -18,24 +18,94 @@ package get
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"loxicmd/pkg/api"
 	"net/http"
+	"os"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
+type GetConntrackOptions struct {
+	Src     string
+	Dst     string
+	SPort   uint16
+	DPort   uint16
+	Port    uint16
+	Proto   string
+	State   string
+	SortBy  string
+	Limit   int
+	GroupBy string
+	Top     int
+	Stream  bool
+}
+
+// MakeCtFilter builds the client-side conntrack filter from the flags.
+func (o GetConntrackOptions) MakeCtFilter(serviceName string) (api.CtFilter, error) {
+	var err error
+	f := api.CtFilter{
+		ServName: serviceName,
+		SPort:    o.SPort,
+		DPort:    o.DPort,
+		Port:     o.Port,
+		Proto:    o.Proto,
+		State:    o.State,
+	}
+	if o.Src != "" {
+		if f.Src, err = api.ParseCtPrefix(o.Src); err != nil {
+			return f, fmt.Errorf("src %s", err.Error())
+		}
+	}
+	if o.Dst != "" {
+		if f.Dst, err = api.ParseCtPrefix(o.Dst); err != nil {
+			return f, fmt.Errorf("dst %s", err.Error())
+		}
+	}
+	switch o.SortBy {
+	case "", "bytes", "packets":
+	case "flows":
+		if o.GroupBy == "" {
+			return f, errors.New("sort-by 'flows' needs --group-by")
+		}
+	default:
+		return f, fmt.Errorf("sort-by '%s' is not supported (bytes, packets, flows)", o.SortBy)
+	}
+	return f, nil
+}
+
 func NewGetConntrackCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := GetConntrackOptions{}
+
 	var GetctCmd = &cobra.Command{
-		Use:     "conntrack",
+		Use:     "conntrack [--src=<cidr>] [--dst=<cidr>] [--sport=<port>] [--dport=<port>] [--port=<port>] [--proto=<proto>] [--state=<state>] [--sort-by=<bytes|packets>] [--limit=<n>] [--group-by=<service|endpoint|state|src|dst|proto>] [--top=<n>] [--stream]",
 		Aliases: []string{"ct", "conntracks", "cts"},
 		Short:   "Get a Conntrack",
-		Long:    `It shows connection track Information`,
+		Long: `It shows connection track Information
+
+Entries are filtered client-side by --servName, --src, --dst, --sport, --dport, --port, --proto and --state.
+--group-by shows the number of flows, packets and bytes per service, endpoint, state, source IP,
+destination or protocol instead of each entry. --top=<n> shows the top-N source IPs by bytes.
+--stream prints each entry as soon as it is received instead of sorting the whole table first.
+
+ex)
+	loxicmd get conntrack --src 10.0.0.0/8 --proto tcp --state est
+	loxicmd get conntrack --sort-by bytes --limit 20
+	loxicmd get conntrack --group-by service
+	loxicmd get conntrack --servName http-service --group-by endpoint
+	loxicmd get conntrack --top 10
+	loxicmd get conntrack --stream --limit 1000
+`,
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd
 			_ = args
+			f, err := o.MakeCtFilter(restOptions.ServiceName)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
 			client := api.NewLoxiClient(restOptions)
 			ctx := context.TODO()
 			var cancel context.CancelFunc
@@ -49,30 +119,80 @@ func NewGetConntrackCmd(restOptions *api.RESTOptions) *cobra.Command {
 				return
 			}
 			if resp.StatusCode == http.StatusOK {
-				PrintGetCTResult(resp, *restOptions)
+				PrintGetCTResult(resp, *restOptions, o, f)
 				return
 			}
 
 		},
 	}
 	GetctCmd.Flags().StringVarP(&restOptions.ServiceName, "servName", "", restOptions.ServiceName, "Name for load balancer rule")
+	GetctCmd.Flags().StringVarP(&o.Src, "src", "", "", "Filter by source IP or CIDR")
+	GetctCmd.Flags().StringVarP(&o.Dst, "dst", "", "", "Filter by destination IP or CIDR")
+	GetctCmd.Flags().Uint16VarP(&o.SPort, "sport", "", 0, "Filter by source port")
+	GetctCmd.Flags().Uint16VarP(&o.DPort, "dport", "", 0, "Filter by destination port")
+	GetctCmd.Flags().Uint16VarP(&o.Port, "port", "", 0, "Filter by source or destination port")
+	GetctCmd.Flags().StringVarP(&o.Proto, "proto", "", "", "Filter by protocol")
+	GetctCmd.Flags().StringVarP(&o.State, "state", "", "", "Filter by conntrack state")
+	GetctCmd.Flags().StringVarP(&o.SortBy, "sort-by", "", "", "Sort by bytes or packets, or by flows with --group-by (descending)")
+	GetctCmd.Flags().IntVarP(&o.Limit, "limit", "", 0, "Show only the first N entries")
+	GetctCmd.Flags().StringVarP(&o.GroupBy, "group-by", "", "", "Aggregate by service, endpoint, state, src, dst or proto")
+	GetctCmd.Flags().IntVarP(&o.Top, "top", "", 0, "Show the top N source IPs by bytes")
+	GetctCmd.Flags().BoolVarP(&o.Stream, "stream", "", false, "Print entries as they are received without sorting")
 	return GetctCmd
 }
 
-func PrintGetCTResult(resp *http.Response, o api.RESTOptions) {
+// errStreamDone stops decoding once enough entries are streamed.
+var errStreamDone = errors.New("stream done")
+
+func PrintGetCTResult(resp *http.Response, o api.RESTOptions, cto GetConntrackOptions, f api.CtFilter) {
 	ctresp := api.CtInformationGet{}
 	var data [][]string
-	resultByte, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
+
+	if cto.Top > 0 {
+		cto.GroupBy = "src"
+		cto.SortBy = "bytes"
+		cto.Limit = cto.Top
+	}
+	if cto.GroupBy != "" {
+		agg, err := api.NewCtAggregator(cto.GroupBy)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			return
+		}
+		err = api.DecodeList(resp.Body, "ctAttr", func(ct api.ConntrackInformation) error {
+			if f.Match(ct) {
+				agg.Add(ct)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+			return
+		}
+		PrintCTAggregate(o, cto, agg.Result(cto.SortBy))
+		return
+	}
+	if cto.Stream && cto.SortBy == "" {
+		PrintCTStream(resp, o, cto, f)
 		return
 	}
 
-	if err := json.Unmarshal(resultByte, &ctresp); err != nil {
+	err := api.DecodeList(resp.Body, "ctAttr", func(ct api.ConntrackInformation) error {
+		if f.Match(ct) {
+			ctresp.CtInfo = append(ctresp.CtInfo, ct)
+		}
+		return nil
+	})
+	if err != nil {
 		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
 		return
 	}
 
+	ctresp.SortBy(cto.SortBy)
+	if cto.Limit > 0 && len(ctresp.CtInfo) > cto.Limit {
+		ctresp.CtInfo = ctresp.CtInfo[:cto.Limit]
+	}
+
 	// if json options enable, it print as a json format.
 	if o.PrintOption == "json" {
 		resultIndent, _ := json.MarshalIndent(ctresp, "", "    ")
@@ -80,36 +200,98 @@ func PrintGetCTResult(resp *http.Response, o api.RESTOptions) {
 		return
 	}
 
-	ctresp.Sort()
-
 	// Table Init
 	table := TableInit()
-	table.SetHeader([]string{"Service Name", "destIP", "srcIP", "dport", "sport", "proto", "ident", "state", "act", "packets", "bytes"})
+	table.SetHeader(CONNTRACK_SERVICE_TITLE)
 	// Making load balance data
-	data = makeConntrackData(o, ctresp)
+	data = makeConntrackData(ctresp)
 
 	// Rendering the load balance data to table
 	TableShow(data, table)
 }
 
-func makeConntrackData(o api.RESTOptions, ctresp api.CtInformationGet) (data [][]string) {
-	for _, conntrack := range ctresp.CtInfo {
-		if o.ServiceName != "" && o.ServiceName != conntrack.ServName {
-			continue
+// PrintCTStream prints each conntrack entry as soon as it is decoded.
+func PrintCTStream(resp *http.Response, o api.RESTOptions, cto GetConntrackOptions, f api.CtFilter) {
+	var lw *api.ListWriter
+	var table *StreamTable
+	if o.PrintOption == "json" {
+		lw = api.NewListWriter(os.Stdout, "ctAttr", true)
+	} else {
+		table = StreamTableInit(CONNTRACK_SERVICE_TITLE)
+	}
+
+	count := 0
+	err := api.DecodeList(resp.Body, "ctAttr", func(ct api.ConntrackInformation) error {
+		if !f.Match(ct) {
+			return nil
+		}
+		if lw != nil {
+			if err := lw.Write(ct); err != nil {
+				return err
+			}
+		} else {
+			table.Append(makeConntrackRow(ct))
 		}
+		count++
+		if cto.Limit > 0 && count >= cto.Limit {
+			return errStreamDone
+		}
+		return nil
+	})
+	if lw != nil {
+		lw.Close()
+	} else {
+		table.Flush()
+	}
+	if err != nil && err != errStreamDone {
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+	}
+}
+
+func PrintCTAggregate(o api.RESTOptions, cto GetConntrackOptions, aggs []api.CtAggregate) {
+	if cto.Limit > 0 && len(aggs) > cto.Limit {
+		aggs = aggs[:cto.Limit]
+	}
+
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(aggs, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	var data [][]string
+	table := TableInit()
+	table.SetHeader([]string{cto.GroupBy, "flows", "packets", "bytes"})
+	for _, agg := range aggs {
 		data = append(data, []string{
-			conntrack.ServName,
-			conntrack.Dip,
-			conntrack.Sip,
-			fmt.Sprintf("%d", conntrack.Dport),
-			fmt.Sprintf("%d", conntrack.Sport),
-			conntrack.Proto,
-			conntrack.Ident,
-			conntrack.CState,
-			conntrack.CAct,
-			fmt.Sprintf("%v", conntrack.Pkts),
-			fmt.Sprintf("%v", conntrack.Bytes),
+			agg.Key,
+			fmt.Sprintf("%v", agg.Flows),
+			fmt.Sprintf("%v", agg.Pkts),
+			fmt.Sprintf("%v", agg.Bytes),
 		})
 	}
+	TableShow(data, table)
+}
+
+func makeConntrackData(ctresp api.CtInformationGet) (data [][]string) {
+	for _, conntrack := range ctresp.CtInfo {
+		data = append(data, makeConntrackRow(conntrack))
+	}
 	return data
 }
+
+func makeConntrackRow(conntrack api.ConntrackInformation) []string {
+	return []string{
+		conntrack.ServName,
+		conntrack.Dip,
+		conntrack.Sip,
+		fmt.Sprintf("%d", conntrack.Dport),
+		fmt.Sprintf("%d", conntrack.Sport),
+		conntrack.Proto,
+		conntrack.Ident,
+		conntrack.CState,
+		conntrack.CAct,
+		fmt.Sprintf("%v", conntrack.Pkts),
+		fmt.Sprintf("%v", conntrack.Bytes),
+	}
+}
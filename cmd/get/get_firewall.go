@@ -32,14 +32,28 @@ import (
 )
 
 func NewGetFirewallCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var stream bool
+	var sortBy string
+
 	var GetfwCmd = &cobra.Command{
-		Use:     "firewall",
+		Use:     "firewall [--stream] [--sort-by=packets|bytes]",
 		Short:   "Get a firewall",
 		Aliases: []string{"Firewall", "fw", "firewalls"},
-		Long:    `It shows Load balancer Information`,
+		Long: `It shows Firewall rule Information
+
+--stream prints each rule as soon as it is received instead of sorting the whole table first.
+--sort-by sorts rules by their packets or bytes counter in descending order.
+
+The Match column can be pasted back to "create firewall --match" or "delete firewall --match"
+together with --pref. Use -o wide to see each rule field in its own column.
+`,
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd
 			_ = args
+			if sortBy != "" && sortBy != "packets" && sortBy != "bytes" {
+				fmt.Printf("Error: sort-by '%s' is not supported (packets, bytes)\n", sortBy)
+				return
+			}
 			client := api.NewLoxiClient(restOptions)
 			ctx := context.TODO()
 			var cancel context.CancelFunc
@@ -53,26 +67,30 @@ func NewGetFirewallCmd(restOptions *api.RESTOptions) *cobra.Command {
 				return
 			}
 			if resp.StatusCode == http.StatusOK {
-				PrintGetFWResult(resp, *restOptions)
+				if stream {
+					PrintGetFWStream(resp, *restOptions)
+					return
+				}
+				PrintGetFWResult(resp, *restOptions, sortBy)
 				return
 			}
 
 		},
 	}
 
+	GetfwCmd.Flags().BoolVarP(&stream, "stream", "", false, "Print rules as they are received without sorting")
+	GetfwCmd.Flags().StringVarP(&sortBy, "sort-by", "", "", "Sort rules by packets or bytes")
 	return GetfwCmd
 }
 
-func PrintGetFWResult(resp *http.Response, o api.RESTOptions) {
+func PrintGetFWResult(resp *http.Response, o api.RESTOptions, sortBy string) {
 	fwresp := api.FWInformationGet{}
 	var data [][]string
-	resultByte, err := io.ReadAll(resp.Body)
+	err := api.DecodeList(resp.Body, "fwAttr", func(fwrule api.FwRuleMod) error {
+		fwresp.FWInfo = append(fwresp.FWInfo, fwrule)
+		return nil
+	})
 	if err != nil {
-		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
-		return
-	}
-
-	if err := json.Unmarshal(resultByte, &fwresp); err != nil {
 		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
 		return
 	}
@@ -84,24 +102,66 @@ func PrintGetFWResult(resp *http.Response, o api.RESTOptions) {
 		return
 	}
 
-	fwresp.Sort()
+	fwresp.SortBy(sortBy)
 
 	// Table Init
 	table := TableInit()
 
 	// Making load balance data
 	for _, fwrule := range fwresp.FWInfo {
-		table.SetHeader(FIREWALL_TITLE)
-		data = append(data, []string{fwrule.Rule.SrcIP, fwrule.Rule.DstIP, fmt.Sprintf("%d", fwrule.Rule.SrcPortMin), fmt.Sprintf("%d", fwrule.Rule.SrcPortMax),
-			fmt.Sprintf("%d", fwrule.Rule.DstPortMin), fmt.Sprintf("%d", fwrule.Rule.DstPortMax), fmt.Sprintf("%d", fwrule.Rule.Proto),
-			fwrule.Rule.InPort, fmt.Sprintf("%d", fwrule.Rule.Pref), MakeFirewallOptionToString(fwrule.Opts), fmt.Sprintf("%s", fwrule.Opts.Counter)})
-
+		table.SetHeader(firewallTitle(o))
+		data = append(data, makeFirewallRow(fwrule, o))
 	}
 
 	// Rendering the load balance data to table
 	TableShow(data, table)
 }
 
+// PrintGetFWStream prints each firewall rule as soon as it is decoded.
+func PrintGetFWStream(resp *http.Response, o api.RESTOptions) {
+	var lw *api.ListWriter
+	var table *StreamTable
+	if o.PrintOption == "json" {
+		lw = api.NewListWriter(os.Stdout, "fwAttr", true)
+	} else {
+		table = StreamTableInit(firewallTitle(o))
+	}
+
+	err := api.DecodeList(resp.Body, "fwAttr", func(fwrule api.FwRuleMod) error {
+		if lw != nil {
+			return lw.Write(fwrule)
+		}
+		table.Append(makeFirewallRow(fwrule, o))
+		return nil
+	})
+	if lw != nil {
+		lw.Close()
+	} else {
+		table.Flush()
+	}
+	if err != nil {
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+	}
+}
+
+func firewallTitle(o api.RESTOptions) []string {
+	if o.PrintOption == "wide" {
+		return FIREWALL_WIDE_TITLE
+	}
+	return FIREWALL_TITLE
+}
+
+func makeFirewallRow(fwrule api.FwRuleMod, o api.RESTOptions) []string {
+	if o.PrintOption != "wide" {
+		return []string{fwrule.Rule.MatchString(), fmt.Sprintf("%d", fwrule.Rule.Pref), MakeFirewallOptionToString(fwrule.Opts),
+			fmt.Sprintf("%d", fwrule.Opts.Packets), fmt.Sprintf("%d", fwrule.Opts.Bytes)}
+	}
+	return []string{fwrule.Rule.SrcIP, fwrule.Rule.DstIP, fmt.Sprintf("%d", fwrule.Rule.SrcPortMin), fmt.Sprintf("%d", fwrule.Rule.SrcPortMax),
+		fmt.Sprintf("%d", fwrule.Rule.DstPortMin), fmt.Sprintf("%d", fwrule.Rule.DstPortMax), fmt.Sprintf("%d", fwrule.Rule.Proto),
+		fwrule.Rule.InPort, fmt.Sprintf("%d", fwrule.Rule.Pref), MakeFirewallOptionToString(fwrule.Opts),
+		fmt.Sprintf("%d", fwrule.Opts.Packets), fmt.Sprintf("%d", fwrule.Opts.Bytes)}
+}
+
 func MakeFirewallOptionToString(t api.FwOptArg) (ret string) {
 	if t.Allow {
 		ret = "Allow"
@@ -167,12 +227,12 @@ func FWdump(restOptions *api.RESTOptions, path string) (string, error) {
 		fmt.Printf("Error: %s\n", err.Error())
 		return "", err
 	}
-	resultByte, err := io.ReadAll(resp.Body)
-	if err != nil {
+	defer resp.Body.Close()
+	// Write
+	if _, err := io.Copy(f, resp.Body); err != nil {
 		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
+		return "", err
 	}
-	// Write
-	f.Write(resultByte)
 
 	cfile := path + "FWconfig.txt"
 	if _, err := os.Stat(cfile); errors.Is(err, os.ErrNotExist) {
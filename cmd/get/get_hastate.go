@@ -83,10 +83,18 @@ func PrintGetHAStateResult(resp *http.Response, o api.RESTOptions) {
 
 	// Table Init
 	table := TableInit()
-	table.SetHeader(HASTATE_TITLE)
+	if o.PrintOption == "wide" {
+		table.SetHeader(HASTATE_WIDE_TITLE)
+	} else {
+		table.SetHeader(HASTATE_TITLE)
+	}
 	// Making load balance data
 	for _, HAState := range HAStateresp.HAStateAttr {
-		data = append(data, []string{HAState.Instance, HAState.State})
+		if o.PrintOption == "wide" {
+			data = append(data, []string{HAState.Instance, HAState.State, HAState.SyncString(), HAState.Vip})
+		} else {
+			data = append(data, []string{HAState.Instance, HAState.State})
+		}
 	}
 	// Rendering the load balance data to table
 	TableShow(data, table)
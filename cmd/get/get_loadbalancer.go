@@ -16,11 +16,11 @@
 package get
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
 	"loxicmd/pkg/api"
 	"net/http"
 	"os"
@@ -31,15 +31,88 @@ import (
 	"github.com/spf13/cobra"
 )
 
+type GetLoadBalancerOptions struct {
+	Selector string
+	IP       string
+	Protocol string
+	Mode     string
+	Stream   bool
+	SortBy   string
+	Stats    bool
+	Since    bool
+	// SnapshotFile stores the counters between --since invocations
+	SnapshotFile string
+}
+
+// MakeLbSelector builds the client-side selector from the filter flags.
+func (o GetLoadBalancerOptions) MakeLbSelector() (api.LbSelector, error) {
+	sel, err := api.ParseLbSelector(o.Selector)
+	if err != nil {
+		return sel, err
+	}
+	if o.IP != "" {
+		if err := sel.Add("ip", "=", o.IP); err != nil {
+			return sel, err
+		}
+	}
+	if o.Protocol != "" {
+		if err := sel.Add("proto", "=", o.Protocol); err != nil {
+			return sel, err
+		}
+	}
+	if o.Mode != "" {
+		if err := sel.Add("mode", "=", o.Mode); err != nil {
+			return sel, err
+		}
+	}
+	return sel, nil
+}
+
 func NewGetLoadBalancerCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := GetLoadBalancerOptions{}
+
 	var GetLbCmd = &cobra.Command{
-		Use:     "loadbalancer",
+		Use:     "loadbalancer [--selector=<key><op><value>,...] [--ip=<ip|cidr>] [--proto=<proto>] [--mode=<mode>] [--stream] [--sort-by=packets|bytes] [--stats [--since]]",
 		Short:   "Get a LoadBalancer",
 		Aliases: []string{"lb", "loadbalancers", "lbs"},
-		Long:    `It shows Load balancer Information`,
+		Long: `It shows Load balancer Information
+
+--selector filters rules client-side. Terms are separated by ',' and all of them must match.
+	Quote a value that contains ',' (ex) name=~"^web-[0-9]{1,3}$".
+	keys: name, ip, port, proto, mode, sel, host, endpoint, managed
+	operators: = (equal, CIDR contains for ip/endpoint), != , =~ (regex), !~ (regex not match)
+--stream prints each rule as soon as it is received instead of sorting the whole table first.
+--sort-by sorts rules by their total packets or bytes and the endpoints of each rule likewise.
+--stats shows per-service traffic totals and the share of each endpoint next to its share of the weights.
+--since shows the traffic since the previous --since invocation. The counters are kept in --snapshot-file.
+
+ex)
+	loxicmd get lb --selector name=~"^k8s-"
+	loxicmd get lb --ip 10.0.0.0/24 --proto tcp --mode fullnat
+	loxicmd get lb --selector endpoint=10.212.0.1,port=80
+	loxicmd get lb -o wide --sort-by bytes
+	loxicmd get lb --stats --selector name=k8s-web
+	loxicmd get lb --since
+`,
 		Run: func(cmd *cobra.Command, args []string) {
 			_ = cmd
 			_ = args
+			sel, err := o.MakeLbSelector()
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			if o.SortBy != "" && o.SortBy != "packets" && o.SortBy != "bytes" {
+				fmt.Printf("Error: sort-by '%s' is not supported (packets, bytes)\n", o.SortBy)
+				return
+			}
+			if o.Since {
+				o.Stats = true
+			}
+			if o.Stream && o.Stats {
+				fmt.Printf("Error: --stream can't be used with --stats\n")
+				return
+			}
 			client := api.NewLoxiClient(restOptions)
 			ctx := context.TODO()
 			var cancel context.CancelFunc
@@ -53,13 +126,30 @@ func NewGetLoadBalancerCmd(restOptions *api.RESTOptions) *cobra.Command {
 				return
 			}
 			if resp.StatusCode == http.StatusOK {
-				PrintGetLbResult(resp, *restOptions)
+				if o.Stream {
+					PrintGetLbStream(resp, *restOptions, sel)
+					return
+				}
+				if o.Stats {
+					PrintGetLbStats(resp, *restOptions, o, sel)
+					return
+				}
+				PrintGetLbResult(resp, *restOptions, o, sel)
 				return
 			}
 
 		},
 	}
 	GetLbCmd.Flags().StringVarP(&restOptions.ServiceName, "servName", "", restOptions.ServiceName, "Name for load balancer rule")
+	GetLbCmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Filter rules as '<key><op><value>,...' (ex) name=~\"^k8s-\",proto=tcp")
+	GetLbCmd.Flags().StringVarP(&o.IP, "ip", "", o.IP, "Filter rules by external IP or CIDR")
+	GetLbCmd.Flags().StringVarP(&o.Protocol, "proto", "", o.Protocol, "Filter rules by protocol")
+	GetLbCmd.Flags().StringVarP(&o.Mode, "mode", "", o.Mode, "Filter rules by NAT mode")
+	GetLbCmd.Flags().BoolVarP(&o.Stream, "stream", "", false, "Print rules as they are received without sorting")
+	GetLbCmd.Flags().StringVarP(&o.SortBy, "sort-by", "", "", "Sort rules and endpoints by packets or bytes")
+	GetLbCmd.Flags().BoolVarP(&o.Stats, "stats", "", false, "Show per-service traffic totals and endpoint shares")
+	GetLbCmd.Flags().BoolVarP(&o.Since, "since", "", false, "Show traffic since the previous --since invocation")
+	GetLbCmd.Flags().StringVarP(&o.SnapshotFile, "snapshot-file", "", defaultLbSnapshotFile(), "File, or directory holding a file per API server, keeping the counters for --since")
 	return GetLbCmd
 }
 
@@ -137,16 +227,22 @@ func BoolToMon(mon bool) string {
 	return ret
 }
 
-func PrintGetLbResult(resp *http.Response, o api.RESTOptions) {
+// decodeLbRules returns the rules of the response selected by sel.
+func decodeLbRules(resp *http.Response, sel api.LbSelector) (api.LbRuleModGet, error) {
 	lbresp := api.LbRuleModGet{}
+	err := api.DecodeList(resp.Body, "lbAttr", func(lbrule api.LoadBalancerModel) error {
+		if sel.Match(lbrule) {
+			lbresp.LbRules = append(lbresp.LbRules, lbrule)
+		}
+		return nil
+	})
+	return lbresp, err
+}
+
+func PrintGetLbResult(resp *http.Response, o api.RESTOptions, lbo GetLoadBalancerOptions, sel api.LbSelector) {
 	var data [][]string
-	resultByte, err := io.ReadAll(resp.Body)
+	lbresp, err := decodeLbRules(resp, sel)
 	if err != nil {
-		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
-		return
-	}
-
-	if err := json.Unmarshal(resultByte, &lbresp); err != nil {
 		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
 		return
 	}
@@ -158,7 +254,7 @@ func PrintGetLbResult(resp *http.Response, o api.RESTOptions) {
 		return
 	}
 
-	lbresp.Sort()
+	lbresp.SortBy(lbo.SortBy)
 
 	// Table Init
 	table := TableInit()
@@ -168,69 +264,118 @@ func PrintGetLbResult(resp *http.Response, o api.RESTOptions) {
 		if o.ServiceName != "" && o.ServiceName != lbrule.Service.Name || lbrule.Service.Snat {
 			continue
 		}
-		protocolStr := lbrule.Service.Protocol
-		if lbrule.Service.Security != 0 {
-			protocolStr += fmt.Sprintf(":%s", NumToSecurty(int(lbrule.Service.Security)))
+		data = append(data, makeLbRows(o, lbrule)...)
+	}
+	if len(data) > 0 {
+		table.SetHeader(lbTitle(o))
+	}
+
+	// Rendering the load balance data to table
+	TableShow(data, table)
+}
+
+// PrintGetLbStream prints each load balancer rule as soon as it is decoded.
+func PrintGetLbStream(resp *http.Response, o api.RESTOptions, sel api.LbSelector) {
+	var lw *api.ListWriter
+	var table *StreamTable
+	if o.PrintOption == "json" {
+		lw = api.NewListWriter(os.Stdout, "lbAttr", true)
+	} else {
+		table = StreamTableInit(lbTitle(o))
+	}
+
+	err := api.DecodeList(resp.Body, "lbAttr", func(lbrule api.LoadBalancerModel) error {
+		if !sel.Match(lbrule) {
+			return nil
 		}
-		if o.PrintOption == "wide" {
-			table.SetHeader(LOADBALANCER_WIDE_TITLE)
-			secIPs := ""
-			if len(lbrule.SecondaryIPs) > 0 {
-				secIPs = lbrule.SecondaryIPs[0].SecondaryIP
-				for i := 1; i < len(lbrule.SecondaryIPs); i++ {
-					secIPs = secIPs + ", " + lbrule.SecondaryIPs[i].SecondaryIP
-				}
+		if lw != nil {
+			return lw.Write(lbrule)
+		}
+		if o.ServiceName != "" && o.ServiceName != lbrule.Service.Name || lbrule.Service.Snat {
+			return nil
+		}
+		for _, row := range makeLbRows(o, lbrule) {
+			table.Append(row)
+		}
+		return nil
+	})
+	if lw != nil {
+		lw.Close()
+	} else {
+		table.Flush()
+	}
+	if err != nil {
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+	}
+}
+
+func lbTitle(o api.RESTOptions) []string {
+	if o.PrintOption == "wide" {
+		return LOADBALANCER_WIDE_TITLE
+	}
+	return LOADBALANCER_TITLE
+}
+
+// makeLbRows returns the table rows of one load balancer rule.
+func makeLbRows(o api.RESTOptions, lbrule api.LoadBalancerModel) (data [][]string) {
+	protocolStr := lbrule.Service.Protocol
+	if lbrule.Service.Security != 0 {
+		protocolStr += fmt.Sprintf(":%s", NumToSecurty(int(lbrule.Service.Security)))
+	}
+	if o.PrintOption == "wide" {
+		secIPs := ""
+		if len(lbrule.SecondaryIPs) > 0 {
+			secIPs = lbrule.SecondaryIPs[0].SecondaryIP
+			for i := 1; i < len(lbrule.SecondaryIPs); i++ {
+				secIPs = secIPs + ", " + lbrule.SecondaryIPs[i].SecondaryIP
 			}
+		}
 
-			sources := ""
-			if len(lbrule.SrcIPs) > 0 {
-				sources = lbrule.SrcIPs[0].Prefix
-				for i := 1; i < len(lbrule.SecondaryIPs); i++ {
-					sources = sources + ", " + lbrule.SrcIPs[i].Prefix
-				}
+		sources := ""
+		if len(lbrule.SrcIPs) > 0 {
+			sources = lbrule.SrcIPs[0].Prefix
+			for i := 1; i < len(lbrule.SecondaryIPs); i++ {
+				sources = sources + ", " + lbrule.SrcIPs[i].Prefix
 			}
+		}
 
-			if lbrule.Service.Monitor {
-				for i, eps := range lbrule.Endpoints {
-					if i == 0 {
-						if lbrule.Service.PortMax == 0 {
-							data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d", lbrule.Service.Port), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
-								eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), eps.State, eps.Counter})
-						} else {
-							data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d-%d", lbrule.Service.Port, lbrule.Service.PortMax), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
-								eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), eps.State, eps.Counter})
-						}
+		if lbrule.Service.Monitor {
+			for i, eps := range lbrule.Endpoints {
+				if i == 0 {
+					if lbrule.Service.PortMax == 0 {
+						data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d", lbrule.Service.Port), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
+							eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), eps.State, fmt.Sprintf("%d", eps.Packets), fmt.Sprintf("%d", eps.Bytes)})
 					} else {
-						data = append(data, []string{"", "", "", "", "", "", "", "", "", "", eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), eps.State, eps.Counter})
+						data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d-%d", lbrule.Service.Port, lbrule.Service.PortMax), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
+							eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), eps.State, fmt.Sprintf("%d", eps.Packets), fmt.Sprintf("%d", eps.Bytes)})
 					}
+				} else {
+					data = append(data, []string{"", "", "", "", "", "", "", "", "", "", eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), eps.State, fmt.Sprintf("%d", eps.Packets), fmt.Sprintf("%d", eps.Bytes)})
 				}
-			} else {
-				for i, eps := range lbrule.Endpoints {
-					if i == 0 {
-						if lbrule.Service.PortMax == 0 {
-							data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d", lbrule.Service.Port), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
-								eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), "-", eps.Counter})
-						} else {
-							data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d-%d", lbrule.Service.Port, lbrule.Service.PortMax), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
-								eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), "-", eps.Counter})
-						}
+			}
+		} else {
+			for i, eps := range lbrule.Endpoints {
+				if i == 0 {
+					if lbrule.Service.PortMax == 0 {
+						data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d", lbrule.Service.Port), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
+							eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), "-", fmt.Sprintf("%d", eps.Packets), fmt.Sprintf("%d", eps.Bytes)})
 					} else {
-						data = append(data, []string{"", "", "", "", "", "", "", "", "", "", eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), "-", eps.Counter})
+						data = append(data, []string{lbrule.Service.ExternalIP, secIPs, sources, lbrule.Service.Host, fmt.Sprintf("%d-%d", lbrule.Service.Port, lbrule.Service.PortMax), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress),
+							eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), "-", fmt.Sprintf("%d", eps.Packets), fmt.Sprintf("%d", eps.Bytes)})
 					}
+				} else {
+					data = append(data, []string{"", "", "", "", "", "", "", "", "", "", eps.EndpointIP, fmt.Sprintf("%d", eps.TargetPort), fmt.Sprintf("%d", eps.Weight), "-", fmt.Sprintf("%d", eps.Packets), fmt.Sprintf("%d", eps.Bytes)})
 				}
 			}
+		}
+	} else {
+		if lbrule.Service.PortMax == 0 {
+			data = append(data, []string{lbrule.Service.ExternalIP, fmt.Sprintf("%d", lbrule.Service.Port), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress), fmt.Sprintf("%d", len(lbrule.Endpoints)), fmt.Sprintf("%v", lbrule.Service.Timeout), BoolToMon(lbrule.Service.Monitor)})
 		} else {
-			table.SetHeader(LOADBALANCER_TITLE)
-			if lbrule.Service.PortMax == 0 {
-				data = append(data, []string{lbrule.Service.ExternalIP, fmt.Sprintf("%d", lbrule.Service.Port), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress), fmt.Sprintf("%d", len(lbrule.Endpoints)), fmt.Sprintf("%v", lbrule.Service.Timeout), BoolToMon(lbrule.Service.Monitor)})
-			} else {
-				data = append(data, []string{lbrule.Service.ExternalIP, fmt.Sprintf("%d-%d", lbrule.Service.Port, lbrule.Service.PortMax), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress), fmt.Sprintf("%d", len(lbrule.Endpoints)), fmt.Sprintf("%v", lbrule.Service.Timeout), BoolToMon(lbrule.Service.Monitor)})
-			}
+			data = append(data, []string{lbrule.Service.ExternalIP, fmt.Sprintf("%d-%d", lbrule.Service.Port, lbrule.Service.PortMax), protocolStr, lbrule.Service.Name, fmt.Sprintf("%d", lbrule.Service.Block), NumToSelect(int(lbrule.Service.Sel)), NumToMode(int(lbrule.Service.Mode), lbrule.Service.PpV2, lbrule.Service.Egress), fmt.Sprintf("%d", len(lbrule.Endpoints)), fmt.Sprintf("%v", lbrule.Service.Timeout), BoolToMon(lbrule.Service.Monitor)})
 		}
 	}
-
-	// Rendering the load balance data to table
-	TableShow(data, table)
+	return data
 }
 
 func LoadbalancerAPICall(restOptions *api.RESTOptions) (*http.Response, error) {
@@ -250,8 +395,6 @@ func LoadbalancerAPICall(restOptions *api.RESTOptions) (*http.Response, error) {
 }
 
 func Lbdump(restOptions *api.RESTOptions, path string) (string, error) {
-	lbresp := api.LbRuleModGet{}
-	dresp := api.LbRuleModGet{}
 	// File Open
 	fileP := []string{"/tmp/lbconfig_", ".txt"}
 	t := time.Now()
@@ -277,35 +420,34 @@ func Lbdump(restOptions *api.RESTOptions, path string) (string, error) {
 		return "", err
 	}
 
-	resultByte, err := io.ReadAll(resp.Body)
-	if err != nil {
-		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
-	}
-
-	if err := json.Unmarshal(resultByte, &lbresp); err != nil {
-		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
-		return "", err
-	}
+	defer resp.Body.Close()
 
-	for _, lbrule := range lbresp.LbRules {
-		if !lbrule.Service.Managed && !lbrule.Service.Snat && !strings.Contains(lbrule.Service.Name, "ipvs") {
-			for i := range lbrule.Endpoints {
-				lbacts := &lbrule.Endpoints[i]
-				lbacts.Counter = ""
-			}
-			dresp.LbRules = append(dresp.LbRules, lbrule)
+	// Decode and write rule by rule so large rule tables are never held in memory
+	w := bufio.NewWriter(f)
+	lw := api.NewListWriter(w, "lbAttr", false)
+	err = api.DecodeList(resp.Body, "lbAttr", func(lbrule api.LoadBalancerModel) error {
+		if lbrule.Service.Managed || lbrule.Service.Snat || strings.Contains(lbrule.Service.Name, "ipvs") {
+			return nil
 		}
-	}
-
-	dumpBytes, err := json.Marshal(dresp)
+		for i := range lbrule.Endpoints {
+			lbacts := &lbrule.Endpoints[i]
+			lbacts.Counter, lbacts.Packets, lbacts.Bytes = "", 0, 0
+		}
+		if err := lbrule.Validation(); err != nil {
+			fmt.Printf("Warning: LB rule %s will not be restored: %s\n", lbrule.Service.Key(), err.Error())
+		}
+		return lw.Write(lbrule)
+	})
 	if err != nil {
-		fmt.Printf("Error: Failed to marshal dump LB rules (%s)\n", err.Error())
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
 		return "", err
 	}
 
 	// Write
-	_, err = f.Write(dumpBytes)
-	if err != nil {
+	if err := lw.Close(); err != nil {
+		fmt.Println("File write error")
+	}
+	if err := w.Flush(); err != nil {
 		fmt.Println("File write error")
 	}
 	cfile := path + "lbconfig.txt"
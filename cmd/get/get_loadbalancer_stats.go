@@ -0,0 +1,194 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package get
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"loxicmd/pkg/api"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// LbStatsSnapshot - load balancer counters stored between --since invocations
+type LbStatsSnapshot struct {
+	Time     time.Time            `json:"time"`
+	Services []api.LbServiceStats `json:"services"`
+}
+
+func defaultLbSnapshotFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "loxicmd")
+}
+
+// lbSnapshotFile returns the snapshot file for the API server. A directory
+// gets a file per API server so that counters of different nodes don't mix.
+func lbSnapshotFile(o api.RESTOptions, path string) string {
+	if fi, err := os.Stat(path); (err == nil && fi.IsDir()) || path == defaultLbSnapshotFile() {
+		return filepath.Join(path, fmt.Sprintf("lbstats_%s_%d.json", o.ServerIP, o.ServerPort))
+	}
+	return path
+}
+
+func readLbSnapshot(file string) (*LbStatsSnapshot, error) {
+	b, err := os.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	snap := &LbStatsSnapshot{}
+	if err := json.Unmarshal(b, snap); err != nil {
+		return nil, fmt.Errorf("snapshot %s is broken: %s", file, err.Error())
+	}
+	return snap, nil
+}
+
+// writeLbSnapshot stores stats. Services of prev which are not in stats, for
+// example the ones filtered out this time, are kept.
+func writeLbSnapshot(file string, prev *LbStatsSnapshot, stats []api.LbServiceStats) error {
+	snap := LbStatsSnapshot{Time: time.Now(), Services: stats}
+	if prev != nil {
+		seen := map[string]bool{}
+		for _, st := range stats {
+			seen[st.Service.Key()] = true
+		}
+		for _, st := range prev.Services {
+			if !seen[st.Service.Key()] {
+				snap.Services = append(snap.Services, st)
+			}
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
+
+// PrintGetLbStats prints per-service traffic totals and the share of each
+// endpoint. With --since the traffic since the previous invocation is shown.
+func PrintGetLbStats(resp *http.Response, o api.RESTOptions, lbo GetLoadBalancerOptions, sel api.LbSelector) {
+	lbresp, err := decodeLbRules(resp, sel)
+	if err != nil {
+		fmt.Printf("Error: Failed to unmarshal HTTP response: (%s)\n", err.Error())
+		return
+	}
+	lbresp.SortBy(lbo.SortBy)
+
+	var stats []api.LbServiceStats
+	for _, lbrule := range lbresp.LbRules {
+		if o.ServiceName != "" && o.ServiceName != lbrule.Service.Name || lbrule.Service.Snat {
+			continue
+		}
+		stats = append(stats, lbrule.Stats())
+	}
+
+	var since *time.Time
+	if lbo.Since {
+		file := lbSnapshotFile(o, lbo.SnapshotFile)
+		prev, err := readLbSnapshot(file)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			return
+		}
+		if err := writeLbSnapshot(file, prev, stats); err != nil {
+			fmt.Printf("Error: Failed to save snapshot: %s\n", err.Error())
+			return
+		}
+		if prev != nil {
+			since = &prev.Time
+			prevStats := map[string]api.LbServiceStats{}
+			for _, st := range prev.Services {
+				prevStats[st.Service.Key()] = st
+			}
+			for i := range stats {
+				if p, ok := prevStats[stats[i].Service.Key()]; ok {
+					stats[i].Sub(p)
+				}
+			}
+		} else if o.PrintOption != "json" {
+			fmt.Printf("No previous snapshot in %s, showing the total traffic\n", file)
+		}
+	}
+
+	// if json options enable, it print as a json format.
+	if o.PrintOption == "json" {
+		out := struct {
+			Since    *time.Time           `json:"since,omitempty"`
+			Services []api.LbServiceStats `json:"services"`
+		}{since, stats}
+		resultIndent, _ := json.MarshalIndent(out, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	if since != nil {
+		fmt.Printf("Traffic since %s (%s ago)\n", since.Local().Format("2006-01-02 15:04:05"),
+			time.Since(*since).Round(time.Second))
+	}
+
+	// Table Init
+	table := TableInit()
+	var data [][]string
+	for _, st := range stats {
+		data = append(data, makeLbStatsRows(st)...)
+	}
+	if len(data) > 0 {
+		table.SetHeader(LOADBALANCER_STATS_TITLE)
+	}
+	TableShow(data, table)
+}
+
+func makeLbStatsRows(st api.LbServiceStats) (data [][]string) {
+	port := fmt.Sprintf("%d", st.Service.Port)
+	if st.Service.PortMax != 0 {
+		port = fmt.Sprintf("%d-%d", st.Service.Port, st.Service.PortMax)
+	}
+	var weights uint64
+	for i, ep := range st.Endpoints {
+		row := []string{"", "", "", ""}
+		if i == 0 {
+			row = []string{st.Service.ExternalIP, port, st.Service.Protocol, st.Service.Name}
+		}
+		row = append(row, ep.EndpointIP, fmt.Sprintf("%d", ep.TargetPort), fmt.Sprintf("%d", ep.Weight),
+			fmt.Sprintf("%d", ep.Packets), fmt.Sprintf("%d", ep.Bytes),
+			fmt.Sprintf("%.1f", ep.Share), fmt.Sprintf("%.1f", ep.WeightShare))
+		data = append(data, row)
+		weights += uint64(ep.Weight)
+	}
+	if len(st.Endpoints) == 0 {
+		data = append(data, []string{st.Service.ExternalIP, port, st.Service.Protocol, st.Service.Name, "", "", "", "0", "0", "", ""})
+		return data
+	}
+	data = append(data, []string{"", "", "", "", "total", "", fmt.Sprintf("%d", weights),
+		fmt.Sprintf("%d", st.Packets), fmt.Sprintf("%d", st.Bytes), "", ""})
+	return data
+}
@@ -93,13 +93,13 @@ func PrintGetPolResult(resp *http.Response, o api.RESTOptions) {
 	for _, Pol := range Polresp.PolModInfo {
 		if o.PrintOption == "wide" {
 			table.SetHeader(POLICY_WIDE_TITLE)
-			data = append(data, []string{Pol.Ident, fmt.Sprintf("%d", Pol.Info.PeakInfoRate), fmt.Sprintf("%d", Pol.Info.CommittedInfoRate),
-				fmt.Sprintf("%d", Pol.Info.ExcessBlkSize), fmt.Sprintf("%d", Pol.Info.CommittedBlkSize),
-				fmt.Sprintf("%d", Pol.Info.PolType), fmt.Sprintf("%t", Pol.Info.ColorAware),
-				Pol.Target.PolObjName, fmt.Sprintf("%d", Pol.Target.AttachMent)})
+			data = append(data, []string{Pol.Ident, api.FormatRate(Pol.Info.PeakInfoRate), api.FormatRate(Pol.Info.CommittedInfoRate),
+				api.FormatSize(Pol.Info.ExcessBlkSize), api.FormatSize(Pol.Info.CommittedBlkSize),
+				api.PolTypeToString(Pol.Info.PolType), fmt.Sprintf("%t", Pol.Info.ColorAware),
+				Pol.Target.PolObjName, Pol.Target.AttachMent.String()})
 		} else {
 			table.SetHeader(POLICY_TITLE)
-			data = append(data, []string{Pol.Ident, fmt.Sprintf("%d", Pol.Info.PeakInfoRate), fmt.Sprintf("%d", Pol.Info.CommittedInfoRate)})
+			data = append(data, []string{Pol.Ident, api.FormatRate(Pol.Info.PeakInfoRate), api.FormatRate(Pol.Info.CommittedInfoRate)})
 		}
 	}
 
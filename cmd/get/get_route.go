@@ -21,18 +21,71 @@ import (
 	"fmt"
 	"io"
 	"loxicmd/pkg/api"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
+type GetRouteOptions struct {
+	PrefixMatch   string
+	Watch         bool
+	WatchInterval time.Duration
+}
+
+// RouteRate - traffic of a route per second between two polls of --watch
+type RouteRate struct {
+	Dst           string  `json:"destinationIPNet"`
+	Gw            string  `json:"gateway"`
+	PacketsPerSec float64 `json:"packetsPerSec"`
+	BytesPerSec   float64 `json:"bytesPerSec"`
+	Packets       int     `json:"packets"`
+	Bytes         int     `json:"bytes"`
+}
+
 func NewGetRouteCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := GetRouteOptions{}
+
 	var GetrouteCmd = &cobra.Command{
-		Use:   "route",
+		Use:   "route [--prefix-match=<IP>] [--watch] [--watch-interval=<duration>]",
 		Short: "Get a route",
-		Long:  `It shows route Information in the loxiroute`,
+		Long: `It shows route Information in the loxiroute
+--prefix-match shows the route of the longest prefix which includes the IP, as the datapath would choose.
+--watch polls the routes and shows their packets and bytes per second until Ctrl-C.
+
+ex) loxicmd get route --prefix-match 10.10.1.1
+    loxicmd get route --watch --watch-interval 5s`,
 		Run: func(cmd *cobra.Command, args []string) {
+			var prefixIP net.IP
+			if o.PrefixMatch != "" {
+				if prefixIP = net.ParseIP(o.PrefixMatch); prefixIP == nil {
+					fmt.Printf("Error: IP '%s' is invalid format\n", o.PrefixMatch)
+					return
+				}
+			}
+			if o.Watch {
+				if err := WatchRoutes(restOptions, o.WatchInterval, prefixIP); err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+				}
+				return
+			}
+			if prefixIP != nil {
+				routeresp, err := GetRoutes(restOptions, prefixIP)
+				if err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+				if len(routeresp.RouteAttr) == 0 {
+					fmt.Printf("Error: no route matches %s\n", o.PrefixMatch)
+					return
+				}
+				printRoutes(routeresp, *restOptions)
+				return
+			}
 			client := api.NewLoxiClient(restOptions)
 			ctx := context.TODO()
 			var cancel context.CancelFunc
@@ -53,12 +106,123 @@ func NewGetRouteCmd(restOptions *api.RESTOptions) *cobra.Command {
 		},
 	}
 
+	GetrouteCmd.Flags().StringVarP(&o.PrefixMatch, "prefix-match", "", "", "Show the route of the longest prefix match of the IP")
+	GetrouteCmd.Flags().BoolVarP(&o.Watch, "watch", "w", false, "Watch the packets and bytes per second of the routes")
+	GetrouteCmd.Flags().DurationVarP(&o.WatchInterval, "watch-interval", "", 2*time.Second, "Polling interval of --watch")
+
 	return GetrouteCmd
 }
 
-func PrintGetRouteResult(resp *http.Response, o api.RESTOptions) {
+// GetRoutes gets the routes. With prefixIP, only the longest prefix match of it is kept.
+func GetRoutes(restOptions *api.RESTOptions, prefixIP net.IP) (api.RouteModGet, error) {
 	routeresp := api.RouteModGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Route().SetUrl("/config/route/all").Get(ctx)
+	if err != nil {
+		return routeresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return routeresp, fmt.Errorf("failed to get routes: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&routeresp); err != nil {
+		return routeresp, fmt.Errorf("failed to unmarshal HTTP response: (%s)", err.Error())
+	}
+	if prefixIP != nil {
+		route, ok := routeresp.LongestPrefixMatch(prefixIP)
+		routeresp.RouteAttr = nil
+		if ok {
+			routeresp.RouteAttr = []api.Routev4Get{route}
+		}
+	}
+	return routeresp, nil
+}
+
+func WatchRoutes(restOptions *api.RESTOptions, interval time.Duration, prefixIP net.IP) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval should be more than 0")
+	}
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigCh)
+
+	routeresp, err := GetRoutes(restOptions, prefixIP)
+	if err != nil {
+		return err
+	}
+	last := time.Now()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-sigCh:
+			return nil
+		case <-ticker.C:
+		}
+		cur, err := GetRoutes(restOptions, prefixIP)
+		if err != nil {
+			fmt.Printf("%s Warning: %s\n", time.Now().Format(time.DateTime), err.Error())
+			continue
+		}
+		now := time.Now()
+		rates := MakeRouteRates(routeresp, cur, now.Sub(last).Seconds())
+		routeresp, last = cur, now
+		printRouteRates(rates, now, interval, *restOptions)
+	}
+}
+
+// MakeRouteRates makes the rates of the routes in cur from the statistics of them in old.
+// A new route or a reset counter has the rate 0.
+func MakeRouteRates(old, cur api.RouteModGet, elapsed float64) []RouteRate {
+	prev := map[string]api.RouteGetEntryStatistic{}
+	for _, route := range old.RouteAttr {
+		prev[route.Dst] = route.Statistic
+	}
+	cur.Sort()
+	rates := []RouteRate{}
+	for _, route := range cur.RouteAttr {
+		r := RouteRate{Dst: route.Dst, Gw: route.Gw, Packets: route.Statistic.Packets, Bytes: route.Statistic.Bytes}
+		if p, ok := prev[route.Dst]; ok && elapsed > 0 {
+			if route.Statistic.Packets >= p.Packets {
+				r.PacketsPerSec = float64(route.Statistic.Packets-p.Packets) / elapsed
+			}
+			if route.Statistic.Bytes >= p.Bytes {
+				r.BytesPerSec = float64(route.Statistic.Bytes-p.Bytes) / elapsed
+			}
+		}
+		rates = append(rates, r)
+	}
+	return rates
+}
+
+func printRouteRates(rates []RouteRate, at time.Time, interval time.Duration, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(rates, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
 	var data [][]string
+	table := TableInit()
+	table.SetHeader(ROUTE_WATCH_TITLE)
+	for _, r := range rates {
+		data = append(data, []string{r.Dst, r.Gw, fmt.Sprintf("%.0f", r.PacketsPerSec), fmt.Sprintf("%.0f", r.BytesPerSec),
+			fmt.Sprintf("%d", r.Packets), fmt.Sprintf("%d", r.Bytes)})
+	}
+	// Redraw in place like watch(1)
+	fmt.Print("\x1b[H\x1b[2J")
+	fmt.Printf("Every %s: %d routes at %s. Press Ctrl-C to stop.\n", interval, len(rates), at.Format(time.DateTime))
+	TableShow(data, table)
+}
+
+func PrintGetRouteResult(resp *http.Response, o api.RESTOptions) {
+	routeresp := api.RouteModGet{}
 	resultByte, err := io.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
@@ -77,6 +241,17 @@ func PrintGetRouteResult(resp *http.Response, o api.RESTOptions) {
 		return
 	}
 
+	printRoutes(routeresp, o)
+}
+
+func printRoutes(routeresp api.RouteModGet, o api.RESTOptions) {
+	var data [][]string
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(routeresp, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
 	routeresp.Sort()
 
 	// Table Init
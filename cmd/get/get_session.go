@@ -22,24 +22,87 @@ import (
 	"fmt"
 	"io"
 	"loxicmd/pkg/api"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 )
 
+type GetSessionOptions struct {
+	TeID     uint32
+	UEIP     string
+	TunnelIP string
+	Count    bool
+	WithUlCl bool
+}
+
+// SessionCount - number of sessions in total and per tunnel IP
+type SessionCount struct {
+	Total int `json:"total"`
+	// AccessTunnelIPs - sessions per tunnel IP of the access network (gNB)
+	AccessTunnelIPs map[string]int `json:"accessTunnelIPs"`
+	// CoreTunnelIPs - sessions per tunnel IP of the core network (UPF)
+	CoreTunnelIPs map[string]int `json:"coreTunnelIPs"`
+}
+
 func NewGetSessionCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := GetSessionOptions{}
+
 	var GetsessionCmd = &cobra.Command{
-		Use:     "session",
+		Use:     "session [<ident>] [--teid=<TeID>] [--ue-ip=<IP>] [--tunnel-ip=<IP>] [--count] [--with-ulcl]",
 		Short:   "Get a session",
 		Aliases: []string{"session", "sessions"},
-		Long:    `It shows Session Information`,
+		Long: `It shows Session Information
+--teid and --tunnel-ip match the access or the core network tunnel.
+--count shows the number of sessions in total and per tunnel IP instead of the sessions.
+--with-ulcl shows the ULCL classifiers of each session next to it.
+
+ex) loxicmd get session user1 --with-ulcl
+    loxicmd get session --ue-ip 192.168.20.1
+    loxicmd get session --teid 100 -o wide
+    loxicmd get session --tunnel-ip 1.232.16.1 --count`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			_ = cmd
-			_ = args
+			if len(args) > 0 || cmd.Flags().Changed("teid") || o.UEIP != "" || o.TunnelIP != "" || o.Count || o.WithUlCl {
+				filter, err := MakeSessionFilter(cmd, o)
+				if err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+				if len(args) > 0 {
+					filter.Ident = args[0]
+				}
+				sessions, err := GetSessions(restOptions, filter)
+				if err != nil {
+					fmt.Printf("Error: %s\n", err.Error())
+					return
+				}
+				if filter.Ident != "" && len(sessions.SessionInfo) == 0 {
+					fmt.Printf("Error: session '%s' is not found\n", filter.Ident)
+					return
+				}
+				if o.WithUlCl {
+					ulcls, err := GetSessionUlCls(restOptions)
+					if err != nil {
+						fmt.Printf("Error: %s\n", err.Error())
+						return
+					}
+					sessions.Sort()
+					PrintSessionWithUlCl(api.JoinSessionUlCl(sessions, ulcls), *restOptions)
+					return
+				}
+				if o.Count {
+					PrintSessionCount(CountSessions(sessions), *restOptions)
+					return
+				}
+				printSessions(sessions, *restOptions)
+				return
+			}
 			client := api.NewLoxiClient(restOptions)
 			ctx := context.TODO()
 			var cancel context.CancelFunc
@@ -60,12 +123,144 @@ func NewGetSessionCmd(restOptions *api.RESTOptions) *cobra.Command {
 		},
 	}
 
+	GetsessionCmd.Flags().Uint32VarP(&o.TeID, "teid", "", 0, "Show the sessions with the TeID")
+	GetsessionCmd.Flags().StringVarP(&o.UEIP, "ue-ip", "", "", "Show the session of the UE IP")
+	GetsessionCmd.Flags().StringVarP(&o.TunnelIP, "tunnel-ip", "", "", "Show the sessions with the tunnel IP")
+	GetsessionCmd.Flags().BoolVarP(&o.Count, "count", "", false, "Show the number of sessions")
+	GetsessionCmd.Flags().BoolVarP(&o.WithUlCl, "with-ulcl", "", false, "Show the ULCL classifiers of the sessions")
+	GetsessionCmd.MarkFlagsMutuallyExclusive("count", "with-ulcl")
+
 	return GetsessionCmd
 }
 
+func MakeSessionFilter(cmd *cobra.Command, o GetSessionOptions) (api.SessionFilter, error) {
+	filter := api.SessionFilter{TeID: o.TeID}
+	if cmd.Flags().Changed("teid") && o.TeID == 0 {
+		return filter, errors.New("TeID need to be not 0")
+	}
+	if o.UEIP != "" {
+		if filter.UEIP = net.ParseIP(o.UEIP); filter.UEIP == nil {
+			return filter, fmt.Errorf("UE IP '%s' is invalid format", o.UEIP)
+		}
+	}
+	if o.TunnelIP != "" {
+		if filter.TunnelIP = net.ParseIP(o.TunnelIP); filter.TunnelIP == nil {
+			return filter, fmt.Errorf("tunnel IP '%s' is invalid format", o.TunnelIP)
+		}
+	}
+	return filter, nil
+}
+
+// GetSessions gets the sessions matching the filter. Other sessions are dropped while
+// decoding, so that looking up a session among many doesn't keep all of them.
+func GetSessions(restOptions *api.RESTOptions, filter api.SessionFilter) (api.SessionInformationGet, error) {
+	sessionresp := api.SessionInformationGet{SessionInfo: []api.SessionMod{}}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Session().SetUrl("/config/session/all").Get(ctx)
+	if err != nil {
+		return sessionresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return sessionresp, fmt.Errorf("failed to get sessions: %s", resp.Status)
+	}
+	err = api.DecodeList(resp.Body, "sessionAttr", func(s api.SessionMod) error {
+		if filter.Match(s) {
+			sessionresp.SessionInfo = append(sessionresp.SessionInfo, s)
+		}
+		return nil
+	})
+	return sessionresp, err
+}
+
+func PrintSessionWithUlCl(sessions []api.SessionWithUlCl, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(sessions, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	var data [][]string
+	table := TableInit()
+	if o.PrintOption == "wide" {
+		table.SetHeader(SESSION_ULCL_WIDE_TITLE)
+	} else {
+		table.SetHeader(SESSION_ULCL_TITLE)
+	}
+	for _, s := range sessions {
+		session := []string{s.Ident, s.Ip.String()}
+		if o.PrintOption == "wide" {
+			session = append(session,
+				fmt.Sprintf("TeID: %v TunnelIP: %s", s.AnTun.TeID, s.AnTun.Addr.String()),
+				fmt.Sprintf("TeID: %v TunnelIP: %s", s.CnTun.TeID, s.CnTun.Addr.String()))
+		}
+		if len(s.UlCls) == 0 {
+			data = append(data, append(session, "-", "-"))
+			continue
+		}
+		for i, ulcl := range s.UlCls {
+			row := make([]string, len(session))
+			// The session is shown only in the first row of its ULCLs
+			if i == 0 {
+				copy(row, session)
+			}
+			data = append(data, append(row, ulcl.Addr.String(), fmt.Sprintf("%d", ulcl.Qfi)))
+		}
+	}
+	TableShow(data, table)
+}
+
+func CountSessions(sessionresp api.SessionInformationGet) SessionCount {
+	count := SessionCount{AccessTunnelIPs: map[string]int{}, CoreTunnelIPs: map[string]int{}}
+	for _, s := range sessionresp.SessionInfo {
+		count.Total++
+		count.AccessTunnelIPs[s.AnTun.Addr.String()]++
+		count.CoreTunnelIPs[s.CnTun.Addr.String()]++
+	}
+	return count
+}
+
+func PrintSessionCount(count SessionCount, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(count, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	var data [][]string
+	table := TableInit()
+	table.SetHeader(SESSION_COUNT_TITLE)
+	for _, tunnel := range []struct {
+		name string
+		ips  map[string]int
+	}{{"access", count.AccessTunnelIPs}, {"core", count.CoreTunnelIPs}} {
+		var ips []string
+		for ip := range tunnel.ips {
+			ips = append(ips, ip)
+		}
+		// The busiest tunnel IP first
+		sort.Slice(ips, func(i, j int) bool {
+			if tunnel.ips[ips[i]] != tunnel.ips[ips[j]] {
+				return tunnel.ips[ips[i]] > tunnel.ips[ips[j]]
+			}
+			return ips[i] < ips[j]
+		})
+		for _, ip := range ips {
+			data = append(data, []string{tunnel.name, ip, fmt.Sprintf("%d", tunnel.ips[ip])})
+		}
+	}
+	TableShow(data, table)
+	fmt.Printf("Total: %d sessions\n", count.Total)
+}
+
 func PrintGetSessionResult(resp *http.Response, o api.RESTOptions) {
 	sessionresp := api.SessionInformationGet{}
-	var data [][]string
 	resultByte, err := io.ReadAll(resp.Body)
 	if err != nil {
 		fmt.Printf("Error: Failed to read HTTP response: (%s)\n", err.Error())
@@ -84,6 +279,17 @@ func PrintGetSessionResult(resp *http.Response, o api.RESTOptions) {
 		return
 	}
 
+	printSessions(sessionresp, o)
+}
+
+func printSessions(sessionresp api.SessionInformationGet, o api.RESTOptions) {
+	var data [][]string
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(sessionresp, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
 	sessionresp.Sort()
 
 	// Table Init
@@ -113,6 +113,29 @@ func PrintGetSessionULCLResult(resp *http.Response, o api.RESTOptions) {
 	TableShow(data, table)
 }
 
+func GetSessionUlCls(restOptions *api.RESTOptions) (api.UlclInformationGet, error) {
+	ulclresp := api.UlclInformationGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.SessionUlCL().SetUrl("/config/sessionulcl/all").Get(ctx)
+	if err != nil {
+		return ulclresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ulclresp, fmt.Errorf("failed to get session ULCLs: %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&ulclresp); err != nil {
+		return ulclresp, fmt.Errorf("failed to unmarshal HTTP response: (%s)", err.Error())
+	}
+	return ulclresp, nil
+}
+
 func SessionUlClAPICall(restOptions *api.RESTOptions) (*http.Response, error) {
 	client := api.NewLoxiClient(restOptions)
 	ctx := context.TODO()
@@ -17,10 +17,14 @@ package get
 
 var (
 	CONNTRACK_TITLE         = []string{"destIP", "srcIP", "dPort", "sPort", "proto", "state", "act", "packets", "bytes"}
+	CONNTRACK_SERVICE_TITLE = []string{"Service Name", "destIP", "srcIP", "dport", "sport", "proto", "ident", "state", "act", "packets", "bytes"}
 	LOADBALANCER_TITLE      = []string{"Ext IP", "Port", "Proto", "Name", "Mark", "Sel", "Mode", "# of Endpoints", "Timeout", "Monitor"}
-	LOADBALANCER_WIDE_TITLE = []string{"Ext IP", "Sec IPs", "Sources", "Host", "Port", "Proto", "Name", "Mark", "Sel", "Mode", "Endpoint", "EPort", "Weight", "State", "Counters"}
+	LOADBALANCER_WIDE_TITLE = []string{"Ext IP", "Sec IPs", "Sources", "Host", "Port", "Proto", "Name", "Mark", "Sel", "Mode", "Endpoint", "EPort", "Weight", "State", "Packets", "Bytes"}
 	SESSION_TITLE           = []string{"ident", "session IP"}
 	SESSION_WIDE_TITLE      = []string{"ident", "session IP", "access Network Tunnel", "core Network Tunnel"}
+	SESSION_COUNT_TITLE     = []string{"Tunnel", "Tunnel IP", "Sessions"}
+	SESSION_ULCL_TITLE      = []string{"ident", "session IP", "ulcl IP", "qfi"}
+	SESSION_ULCL_WIDE_TITLE = []string{"ident", "session IP", "access Network Tunnel", "core Network Tunnel", "ulcl IP", "qfi"}
 	PORT_WIDE_TITLE         = []string{"index", "portname", "MAC", "link/state", "mtu", "isActive/bpf\nPort type", "Statistics", "L3Info", "L2Info", "Sync"}
 	PORT_TITLE              = []string{"index", "portname", "MAC", "link/state", "L3Info", "L2Info"}
 	ULCL_TITLE              = []string{"ident", "ulcl IP", "qfi"}
@@ -28,6 +32,7 @@ var (
 	POLICY_WIDE_TITLE       = []string{"Ident", "peakInfoRate", "committedInfoRate", "excessBlkSize", "committedBlkSize", "policyType", "ColorAware", "polObjName", "attachment"}
 	ROUTE_TITLE             = []string{"destinationIPNet", "gateway", "flag"}
 	ROUTE_WIDE_TITLE        = []string{"destinationIPNet", "gateway", "flag", "HardwareMark", "packets", "bytes"}
+	ROUTE_WATCH_TITLE       = []string{"destinationIPNet", "gateway", "packets/s", "bytes/s", "packets", "bytes"}
 	IP_TITLE                = []string{"Device Name", "IP Address"}
 	FDB_TITLE               = []string{"Device Name", "MAC Address"}
 	IP_WIDE_TITLE           = []string{"Device Name", "IP Address", "Sync"}
@@ -40,12 +45,23 @@ var (
 	FILESYSTEM_TITLE        = []string{"fileSystem", "type", "size", "used", "avail", "usePercent", "mountedOn"}
 	MIRROR_TITLE            = []string{"Mirror Name", "Mirror info", "Target\nAttachment", "target\nName"}
 	MIRROR_WIDE_TITLE       = []string{"Mirror Name", "Mirror info", "Target\nAttachment", "target\nName", "Sync"}
-	FIREWALL_TITLE          = []string{"Source IP", "destination IP", "min SPort", "max SPort", "min DPort", "max DPort", "protocol", "port Name", "preference", "Option", "Counters"}
+	FIREWALL_TITLE          = []string{"Match", "preference", "Option", "Packets", "Bytes"}
+	FIREWALL_WIDE_TITLE     = []string{"Source IP", "destination IP", "min SPort", "max SPort", "min DPort", "max DPort", "protocol", "port Name", "preference", "Option", "Packets", "Bytes"}
+	FIREWALL_ORDER_TITLE    = []string{"Order", "Match", "preference", "Option", "Note"}
+	FIREWALL_TEST_TITLE     = []string{"Order", "Match", "preference", "Option", "Result"}
 	ENDPOINT_TITLE          = []string{"Host", "Name", "ptype", "port", "duration", "retries", "minDelay", "avgDelay", "maxDelay", "State"}
 	PARAM_TITLE             = []string{"Param Name", "Value"}
 	BGPNEIGHBOR_TITLE       = []string{"Peer", "AS", "UP/Down", "State"}
+	BGPNEIGHBOR_WIDE_TITLE  = []string{"Peer", "AS", "UP/Down", "State", "Received", "Advertised", "Last Error"}
+	BGP_ROUTE_TITLE         = []string{"Best", "Prefix", "Next Hop", "Peer", "AS Path"}
+	BGP_ROUTE_WIDE_TITLE    = []string{"Best", "Prefix", "Next Hop", "Peer", "AS Path", "MED", "Local Pref", "Communities", "Age"}
+	BGP_POLICY_TITLE        = []string{"Name", "Direction", "Peers", "Prefixes", "Action", "Set"}
 	HASTATE_TITLE           = []string{"Instance", "HAState"}
+	HASTATE_WIDE_TITLE      = []string{"Instance", "HAState", "Sync", "VIP"}
 	BFD_TITLE               = []string{"Instance", "RemoteIP", "State"}
-	BFD_WIDE_TITLE          = []string{"Instance", "RemoteIP", "SourceIP", "Port", "Interval", "Retry Count", "State"}
+	BFD_WIDE_TITLE          = []string{"Instance", "RemoteIP", "SourceIP", "Port", "Interval", "Min Rx", "Multiplier", "Detect Time", "State"}
+	BFD_HISTORY_TITLE       = []string{"Instance", "RemoteIP", "State", "Transitions", "Last Change"}
 	LBVERSION_TITLE         = []string{"LoxiLB Version", "LoxiLB Build Info"}
+
+	LOADBALANCER_STATS_TITLE = []string{"Ext IP", "Port", "Proto", "Name", "Endpoint", "EPort", "Weight", "Packets", "Bytes", "Traffic %", "Weight %"}
 )
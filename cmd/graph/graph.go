@@ -0,0 +1,372 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package graph
+
+import (
+	"context"
+	"fmt"
+	"loxicmd/pkg/api"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Kinds of the graph nodes
+const (
+	NodePort     = "port"
+	NodeBond     = "bond"
+	NodeVlan     = "vlan"
+	NodeVxlan    = "vxlan"
+	NodeVtep     = "vtep"
+	NodeRoute    = "route"
+	NodeGateway  = "gateway"
+	NodeService  = "service"
+	NodeEndpoint = "endpoint"
+)
+
+type GraphOptions struct {
+	Format string
+}
+
+type Graph struct {
+	Nodes []GraphNode `json:"nodes"`
+	Edges []GraphEdge `json:"edges"`
+	// Warnings - resources which failed to be read. The graph is without them
+	Warnings []string `json:"warnings,omitempty"`
+
+	nodes map[string]*GraphNode
+	edges map[GraphEdge]bool
+}
+
+type GraphNode struct {
+	ID    string `json:"id"`
+	Kind  string `json:"kind"`
+	Label string `json:"label"`
+}
+
+type GraphEdge struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Label string `json:"label,omitempty"`
+}
+
+// connectedNet - a subnet of an IP address of a port
+type connectedNet struct {
+	addr string
+	net  *net.IPNet
+	dev  string
+}
+
+func GraphCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := GraphOptions{}
+
+	var graphCmd = &cobra.Command{
+		Use:   "graph [--format=dot|mermaid|json]",
+		Short: "Export the network topology of the LoxiLB as a graph",
+		Long: `Export the network topology of the LoxiLB as a graph of
+  - ports and their bond, sub-interface, VLAN and VXLAN relationships
+  - VLAN members and VXLAN peers (VTEPs)
+  - routes and their gateways
+  - load balancer services and their endpoints
+
+An endpoint or a gateway is linked to the port of its connected subnet,
+or else to the route of the longest prefix match, so that the graph shows
+how a VIP reaches its backends. Resources which fail to be read are left out
+with a warning in the output.
+
+ex) loxicmd graph | dot -Tsvg > loxilb.svg
+    loxicmd graph --format mermaid > loxilb.mmd
+    loxicmd graph --format json
+`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			if !cmd.Flags().Changed("format") && restOptions.PrintOption == "json" {
+				o.Format = "json"
+			}
+			render, ok := renderers[o.Format]
+			if !ok {
+				fmt.Printf("Error: format '%s' is not supported. it should be dot, mermaid or json\n", o.Format)
+				return
+			}
+			g := BuildGraph(restOptions)
+			if len(g.Nodes) == 0 && len(g.Warnings) > 0 {
+				// Nothing could be read. The first failure tells why
+				fmt.Printf("Error: %s\n", g.Warnings[0])
+				return
+			}
+			fmt.Print(render(g))
+		},
+	}
+
+	graphCmd.Flags().StringVarP(&o.Format, "format", "", "dot", "Format of the graph (dot, mermaid, json)")
+
+	return graphCmd
+}
+
+func newGraph() *Graph {
+	return &Graph{nodes: map[string]*GraphNode{}, edges: map[GraphEdge]bool{}}
+}
+
+// node adds a node once. A later kind other than port is more specific and replaces it.
+func (g *Graph) node(id, kind, label string) string {
+	if n, ok := g.nodes[id]; ok {
+		if n.Kind == NodePort && kind != NodePort {
+			n.Kind = kind
+		}
+		return id
+	}
+	g.nodes[id] = &GraphNode{ID: id, Kind: kind, Label: label}
+	return id
+}
+
+func (g *Graph) edge(from, to, label string) {
+	if from == to {
+		return
+	}
+	g.edges[GraphEdge{From: from, To: to, Label: label}] = true
+}
+
+func (g *Graph) warn(resource string, err error) {
+	g.Warnings = append(g.Warnings, fmt.Sprintf("failed to get %s: %s", resource, err.Error()))
+}
+
+func portID(name string) string {
+	return "port:" + name
+}
+
+// portNode adds a port with its name as the label
+func (g *Graph) portNode(name, kind string) string {
+	return g.node(portID(name), kind, name)
+}
+
+func portKind(p api.PortDump) string {
+	switch {
+	case p.SInfo.PortType&api.PortBond == api.PortBond:
+		return NodeBond
+	case p.SInfo.PortType&api.PortVlanBr == api.PortVlanBr:
+		return NodeVlan
+	case p.SInfo.PortType&api.PortVxlanBr == api.PortVxlanBr:
+		return NodeVxlan
+	}
+	return NodePort
+}
+
+// BuildGraph reads the resources of loxilb and links them into a graph
+func BuildGraph(restOptions *api.RESTOptions) *Graph {
+	g := newGraph()
+	var connected []connectedNet
+	addConnected := func(dev, addr string) {
+		if _, ipNet, err := net.ParseCIDR(addr); err == nil {
+			connected = append(connected, connectedNet{addr: addr, net: ipNet, dev: dev})
+		}
+	}
+
+	ports, err := getList[api.PortDump](restOptions, "/config/port/all", "portAttr")
+	if err != nil {
+		g.warn("ports", err)
+	}
+	for _, p := range ports {
+		id := g.portNode(p.Name, portKind(p))
+		if p.HInfo.Master != "" {
+			g.edge(id, g.portNode(p.HInfo.Master, NodePort), "member")
+		}
+		if p.HInfo.Real != "" {
+			g.edge(id, g.portNode(p.HInfo.Real, NodePort), "over")
+		}
+		if p.SInfo.PortReal != nil {
+			g.edge(id, g.portNode(p.SInfo.PortReal.Name, portKind(*p.SInfo.PortReal)), "over")
+		}
+		if p.SInfo.PortOvl != nil {
+			g.edge(g.portNode(p.SInfo.PortOvl.Name, portKind(*p.SInfo.PortOvl)), id, "over")
+		}
+		for _, addr := range append(append([]string{}, p.L3.Ipv4_addrs...), p.L3.Ipv6_addrs...) {
+			addConnected(p.Name, addr)
+		}
+	}
+
+	vlans, err := getList[api.VlanDump](restOptions, "/config/vlan/all", "vlanAttr")
+	if err != nil {
+		g.warn("vlans", err)
+	}
+	for _, vlan := range vlans {
+		dev := vlan.Dev
+		if dev == "" {
+			dev = api.VlanBridgeName(vlan.Vid)
+		}
+		id := g.portNode(dev, NodeVlan)
+		for _, member := range vlan.Member {
+			label := "untagged"
+			if member.Tagged {
+				label = "tagged"
+			}
+			g.edge(g.portNode(member.Dev, NodePort), id, label)
+		}
+	}
+
+	vxlans, err := getList[api.VxlanDump](restOptions, "/config/tunnel/vxlan/all", "vxlanAttr")
+	if err != nil {
+		g.warn("vxlans", err)
+	}
+	for _, vxlan := range vxlans {
+		dev := vxlan.VxlanName
+		if dev == "" {
+			dev = api.VxlanBridgeName(vxlan.VxLanID)
+		}
+		id := g.portNode(dev, NodeVxlan)
+		if vxlan.EndpointDev != "" {
+			g.edge(id, g.portNode(vxlan.EndpointDev, NodePort), "over")
+		}
+		for _, peer := range vxlan.PeerIP {
+			g.edge(id, g.node("vtep:"+peer, NodeVtep, peer), fmt.Sprintf("vni %d", vxlan.VxLanID))
+		}
+	}
+
+	addrs, err := getList[api.Ipv4AddrGet](restOptions, "/config/ipv4address/all", "ipAttr")
+	if err != nil {
+		g.warn("ip addresses", err)
+	}
+	for _, addr := range addrs {
+		g.portNode(addr.Dev, NodePort)
+		for _, ip := range addr.IP {
+			addConnected(addr.Dev, ip)
+		}
+	}
+	// The longest prefix first to find the connected subnet of an IP
+	sort.SliceStable(connected, func(i, j int) bool {
+		li, _ := connected[i].net.Mask.Size()
+		lj, _ := connected[j].net.Mask.Size()
+		return li > lj
+	})
+	for _, c := range connected {
+		n := g.nodes[portID(c.dev)]
+		if !strings.Contains(n.Label+"\n", "\n"+c.addr+"\n") {
+			n.Label += "\n" + c.addr
+		}
+	}
+
+	routes, err := getList[api.Routev4Get](restOptions, "/config/route/all", "routeAttr")
+	if err != nil {
+		g.warn("routes", err)
+	}
+	routeTable := api.RouteModGet{RouteAttr: routes}
+	// attach links the node of an IP to the port of its connected subnet or to its route
+	attach := func(id string, ip net.IP) {
+		if ip == nil {
+			return
+		}
+		for _, c := range connected {
+			if c.net.Contains(ip) {
+				g.edge(id, portID(c.dev), "connected")
+				return
+			}
+		}
+		if route, ok := routeTable.LongestPrefixMatch(ip); ok {
+			g.edge(id, "route:"+route.Dst, "")
+		}
+	}
+	for _, route := range routes {
+		id := g.node("route:"+route.Dst, NodeRoute, route.Dst)
+		if route.Gw != "" {
+			gw := g.node("gateway:"+route.Gw, NodeGateway, route.Gw)
+			g.edge(id, gw, "")
+		}
+	}
+	// Gateways are linked after all the routes are added
+	for _, route := range routes {
+		gw := net.ParseIP(route.Gw)
+		for _, c := range connected {
+			if gw != nil && c.net.Contains(gw) {
+				g.edge("gateway:"+route.Gw, portID(c.dev), "connected")
+				break
+			}
+		}
+	}
+
+	lbs, err := getList[api.LoadBalancerModel](restOptions, "/config/loadbalancer/all", "lbAttr")
+	if err != nil {
+		g.warn("load balancers", err)
+	}
+	for _, lb := range lbs {
+		svc := lb.Service
+		vip := fmt.Sprintf("%s/%s", net.JoinHostPort(svc.ExternalIP, strconv.Itoa(int(svc.Port))), svc.Protocol)
+		label := vip
+		if svc.Name != "" {
+			label = svc.Name + "\n" + vip
+		}
+		id := g.node("service:"+vip, NodeService, label)
+		for _, ep := range lb.Endpoints {
+			epID := g.node("endpoint:"+ep.EndpointIP, NodeEndpoint, ep.EndpointIP)
+			edge := fmt.Sprintf("port %d", ep.TargetPort)
+			if ep.Weight > 0 {
+				edge += fmt.Sprintf(" weight %d", ep.Weight)
+			}
+			if ep.State != "" && ep.State != "active" {
+				edge += " " + ep.State
+			}
+			g.edge(id, epID, edge)
+			attach(epID, net.ParseIP(ep.EndpointIP))
+		}
+	}
+
+	for _, n := range g.nodes {
+		g.Nodes = append(g.Nodes, *n)
+	}
+	sort.Slice(g.Nodes, func(i, j int) bool {
+		return g.Nodes[i].ID < g.Nodes[j].ID
+	})
+	for e := range g.edges {
+		g.Edges = append(g.Edges, e)
+	}
+	sort.Slice(g.Edges, func(i, j int) bool {
+		if g.Edges[i].From != g.Edges[j].From {
+			return g.Edges[i].From < g.Edges[j].From
+		}
+		if g.Edges[i].To != g.Edges[j].To {
+			return g.Edges[i].To < g.Edges[j].To
+		}
+		return g.Edges[i].Label < g.Edges[j].Label
+	})
+	return g
+}
+
+func getList[T any](restOptions *api.RESTOptions, url, attr string) ([]T, error) {
+	var items []T
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.Status().SetUrl(url).Get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s", resp.Status)
+	}
+	err = api.DecodeList(resp.Body, attr, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
@@ -0,0 +1,112 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package graph
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+var renderers = map[string]func(*Graph) string{
+	"dot":     RenderDot,
+	"mermaid": RenderMermaid,
+	"json":    RenderJSON,
+}
+
+var dotShapes = map[string]string{
+	NodePort:     "box",
+	NodeBond:     "box3d",
+	NodeVlan:     "component",
+	NodeVxlan:    "cds",
+	NodeVtep:     "diamond",
+	NodeRoute:    "note",
+	NodeGateway:  "diamond",
+	NodeService:  "hexagon",
+	NodeEndpoint: "ellipse",
+}
+
+func dotQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return `"` + strings.ReplaceAll(s, "\n", `\n`) + `"`
+}
+
+func RenderDot(g *Graph) string {
+	var sb strings.Builder
+	sb.WriteString("digraph loxilb {\n\trankdir=LR;\n")
+	for _, w := range g.Warnings {
+		sb.WriteString(fmt.Sprintf("\t// Warning: %s\n", w))
+	}
+	for _, n := range g.Nodes {
+		sb.WriteString(fmt.Sprintf("\t%s [label=%s, shape=%s];\n", dotQuote(n.ID), dotQuote(n.Label), dotShapes[n.Kind]))
+	}
+	for _, e := range g.Edges {
+		if e.Label != "" {
+			sb.WriteString(fmt.Sprintf("\t%s -> %s [label=%s];\n", dotQuote(e.From), dotQuote(e.To), dotQuote(e.Label)))
+		} else {
+			sb.WriteString(fmt.Sprintf("\t%s -> %s;\n", dotQuote(e.From), dotQuote(e.To)))
+		}
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// mermaidShapes - opening and closing of the node shapes
+var mermaidShapes = map[string][2]string{
+	NodePort:     {"[", "]"},
+	NodeBond:     {"[[", "]]"},
+	NodeVlan:     {"[/", "/]"},
+	NodeVxlan:    {"[\\", "\\]"},
+	NodeVtep:     {"{", "}"},
+	NodeRoute:    {">", "]"},
+	NodeGateway:  {"{", "}"},
+	NodeService:  {"{{", "}}"},
+	NodeEndpoint: {"([", "])"},
+}
+
+func mermaidText(s string) string {
+	s = strings.ReplaceAll(s, `"`, "#quot;")
+	return strings.ReplaceAll(s, "\n", "<br/>")
+}
+
+func RenderMermaid(g *Graph) string {
+	var sb strings.Builder
+	sb.WriteString("flowchart LR\n")
+	for _, w := range g.Warnings {
+		sb.WriteString(fmt.Sprintf("    %%%% Warning: %s\n", w))
+	}
+	// Mermaid IDs can't have the characters of the node IDs
+	ids := map[string]string{}
+	for i, n := range g.Nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		shape := mermaidShapes[n.Kind]
+		sb.WriteString(fmt.Sprintf("    %s%s\"%s\"%s\n", ids[n.ID], shape[0], mermaidText(n.Label), shape[1]))
+	}
+	for _, e := range g.Edges {
+		if e.Label != "" {
+			sb.WriteString(fmt.Sprintf("    %s -->|\"%s\"| %s\n", ids[e.From], mermaidText(e.Label), ids[e.To]))
+		} else {
+			sb.WriteString(fmt.Sprintf("    %s --> %s\n", ids[e.From], ids[e.To]))
+		}
+	}
+	return sb.String()
+}
+
+func RenderJSON(g *Graph) string {
+	resultIndent, _ := json.MarshalIndent(g, "", "    ")
+	return string(resultIndent) + "\n"
+}
@@ -0,0 +1,198 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ha
+
+import (
+	"encoding/json"
+	"fmt"
+	"loxicmd/cmd/get"
+	"loxicmd/pkg/api"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/cobra"
+)
+
+var HA_CLUSTER_TITLE = []string{"Instance", "Node", "HAState", "Sync", "VIP", "Check"}
+
+const (
+	HAClusterOK       = "ok"
+	HAClusterNoMaster = "no MASTER"
+)
+
+type ClusterOptions struct {
+	Nodes []string
+}
+
+// HAClusterEntry - state of a cluster instance on a node
+type HAClusterEntry struct {
+	Node string `json:"node"`
+	api.HAStateInfo
+}
+
+// HAInstanceCheck - states of a cluster instance over the nodes
+type HAInstanceCheck struct {
+	Instance string           `json:"instance"`
+	Masters  []string         `json:"masters"`
+	Check    string           `json:"check"`
+	Nodes    []HAClusterEntry `json:"nodes"`
+}
+
+// HANodeError - a node which did not answer
+type HANodeError struct {
+	Node  string `json:"node"`
+	Error string `json:"error"`
+}
+
+type HAClusterResult struct {
+	Instances   []HAInstanceCheck `json:"instances"`
+	Unreachable []HANodeError     `json:"unreachable,omitempty"`
+}
+
+func NewClusterCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := ClusterOptions{}
+
+	var clusterCmd = &cobra.Command{
+		Use:   "cluster [--nodes=<node>,<node>,...]",
+		Short: "Show the HA state of the cluster instances over the nodes",
+		Long: `Get the HA state from each node and check that each cluster instance has exactly one MASTER.
+A node is the API server of the LoxiLB as "<IP>", "<IP>:<port>" or "[<IPv6>]:<port>".
+--nodes defaults to --servers, or --apiserver without --servers.
+
+ex) loxicmd ha cluster --nodes 192.168.10.1,192.168.10.2
+    loxicmd ha cluster --nodes 192.168.10.1:11111,[2001::2]:11111 -o json
+`,
+		Aliases: []string{"overview"},
+		Run: func(cmd *cobra.Command, args []string) {
+			nodes, err := makeNodes(restOptions, o.Nodes)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			PrintHAClusterResult(CheckHACluster(nodes), *restOptions)
+		},
+	}
+
+	clusterCmd.Flags().StringSliceVarP(&o.Nodes, "nodes", "", nil, "API servers of the cluster nodes")
+
+	return clusterCmd
+}
+
+func makeNodes(restOptions *api.RESTOptions, nodes []string) ([]*api.RESTOptions, error) {
+	if len(nodes) == 0 {
+		nodes = restOptions.Servers
+	}
+	if len(nodes) == 0 {
+		return []*api.RESTOptions{restOptions}, nil
+	}
+	var result []*api.RESTOptions
+	seen := map[string]bool{}
+	for _, n := range nodes {
+		node, err := restOptions.WithServer(n)
+		if err != nil {
+			return nil, err
+		}
+		if seen[node.Server()] {
+			continue
+		}
+		seen[node.Server()] = true
+		result = append(result, node)
+	}
+	return result, nil
+}
+
+// CheckHACluster gets the HA state from the nodes in parallel and groups them by the instance
+func CheckHACluster(nodes []*api.RESTOptions) HAClusterResult {
+	states := make([]api.HAStateGet, len(nodes))
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *api.RESTOptions) {
+			defer wg.Done()
+			states[i], errs[i] = getHAStates(node)
+		}(i, node)
+	}
+	wg.Wait()
+
+	result := HAClusterResult{}
+	instances := map[string]*HAInstanceCheck{}
+	for i, node := range nodes {
+		if errs[i] != nil {
+			result.Unreachable = append(result.Unreachable, HANodeError{Node: node.Server(), Error: errs[i].Error()})
+			continue
+		}
+		for _, state := range states[i].HAStateAttr {
+			inst, ok := instances[state.Instance]
+			if !ok {
+				inst = &HAInstanceCheck{Instance: state.Instance, Masters: []string{}}
+				instances[state.Instance] = inst
+			}
+			inst.Nodes = append(inst.Nodes, HAClusterEntry{Node: node.Server(), HAStateInfo: state})
+			if state.State == api.HAStateMaster {
+				inst.Masters = append(inst.Masters, node.Server())
+			}
+		}
+	}
+
+	for _, inst := range instances {
+		switch len(inst.Masters) {
+		case 0:
+			inst.Check = HAClusterNoMaster
+		case 1:
+			inst.Check = HAClusterOK
+		default:
+			inst.Check = fmt.Sprintf("%d MASTERs", len(inst.Masters))
+		}
+		result.Instances = append(result.Instances, *inst)
+	}
+	sort.Slice(result.Instances, func(i, j int) bool {
+		return result.Instances[i].Instance < result.Instances[j].Instance
+	})
+	return result
+}
+
+func PrintHAClusterResult(result HAClusterResult, o api.RESTOptions) {
+	if o.PrintOption == "json" {
+		resultIndent, _ := json.MarshalIndent(result, "", "    ")
+		fmt.Println(string(resultIndent))
+		return
+	}
+
+	var data [][]string
+	table := get.TableInit()
+	table.SetHeader(HA_CLUSTER_TITLE)
+	for _, inst := range result.Instances {
+		for _, n := range inst.Nodes {
+			data = append(data, []string{inst.Instance, n.Node, n.State, n.SyncString(), n.Vip, inst.Check})
+		}
+	}
+	get.TableShow(data, table)
+
+	for _, n := range result.Unreachable {
+		fmt.Printf("Warning: %s is unreachable: %s\n", n.Node, n.Error)
+	}
+	for _, inst := range result.Instances {
+		switch inst.Check {
+		case HAClusterOK:
+		case HAClusterNoMaster:
+			fmt.Printf("Instance '%s' has no MASTER\n", inst.Instance)
+		default:
+			fmt.Printf("Instance '%s' has %s: %s\n", inst.Instance, inst.Check, strings.Join(inst.Masters, ", "))
+		}
+	}
+}
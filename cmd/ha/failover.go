@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ha
+
+import (
+	"fmt"
+	"loxicmd/pkg/api"
+	"net"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+type FailoverOptions struct {
+	To   string
+	From string
+}
+
+func NewFailoverCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := FailoverOptions{}
+
+	var failoverCmd = &cobra.Command{
+		Use:   "failover <instance> --to=<node> [--from=<node>]",
+		Short: "Move MASTER of a cluster instance to another node",
+		Long: `Move MASTER of a cluster instance to another node for a planned maintenance.
+The current MASTER is set to BACKUP first and then the node of --to is set to MASTER
+with the same VIP. When the node of --to fails, the current MASTER is restored.
+
+A node is the API server of the LoxiLB as "<IP>", "<IP>:<port>" or "[<IPv6>]:<port>".
+--from is the API server of the current MASTER and defaults to --apiserver.
+
+ex) loxicmd ha failover default --to 192.168.10.2
+    loxicmd ha failover default --from 192.168.10.1:11111 --to 192.168.10.2:11111
+`,
+		Args: cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := Failover(restOptions, args[0], o); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+		},
+	}
+
+	failoverCmd.Flags().StringVarP(&o.To, "to", "", "", "API server of the node to be MASTER")
+	failoverCmd.Flags().StringVarP(&o.From, "from", "", "", "API server of the current MASTER (default --apiserver)")
+	failoverCmd.MarkFlagRequired("to")
+
+	return failoverCmd
+}
+
+// Failover demotes the MASTER of the instance and promotes the other node
+func Failover(restOptions *api.RESTOptions, instance string, o FailoverOptions) error {
+	from := restOptions
+	if o.From != "" {
+		node, err := restOptions.WithServer(o.From)
+		if err != nil {
+			return err
+		}
+		from = node
+	}
+	to, err := restOptions.WithServer(o.To)
+	if err != nil {
+		return err
+	}
+	if from.Server() == to.Server() {
+		return fmt.Errorf("--to %s is the current node", to.Server())
+	}
+
+	fromState, err := getInstanceState(from, instance)
+	if err != nil {
+		return err
+	}
+	toState, err := getInstanceState(to, instance)
+	if err != nil {
+		return err
+	}
+	if toState.State == api.HAStateMaster {
+		return fmt.Errorf("instance '%s' is already MASTER on %s", instance, to.Server())
+	}
+	if fromState.State != api.HAStateMaster {
+		return fmt.Errorf("instance '%s' is %s on %s, not MASTER", instance, fromState.State, from.Server())
+	}
+
+	// loxilb reports 0.0.0.0 when the instance has no VIP
+	vip := fromState.Vip
+	if ip := net.ParseIP(vip); ip == nil || ip.IsUnspecified() {
+		vip = toState.Vip
+	}
+	if ip := net.ParseIP(vip); ip == nil || ip.IsUnspecified() {
+		vip = ""
+	}
+	demote := api.HAStateMod{Instance: instance, State: api.HAStateBackup, Vip: vip}
+	promote := api.HAStateMod{Instance: instance, State: api.HAStateMaster, Vip: vip}
+
+	if err := setHAState(from, demote); err != nil {
+		return err
+	}
+	fmt.Printf("%s: %s -> %s\n", from.Server(), fromState.State, api.HAStateBackup)
+	if err := setHAState(to, promote); err != nil {
+		// Do not leave the instance without MASTER
+		if rerr := setHAState(from, promote); rerr != nil {
+			return fmt.Errorf("%s. restoring MASTER on %s failed too: %s", err.Error(), from.Server(), rerr.Error())
+		}
+		return fmt.Errorf("%s. MASTER is restored on %s", err.Error(), from.Server())
+	}
+	fmt.Printf("%s: %s -> %s\n", to.Server(), toState.State, api.HAStateMaster)
+	fmt.Printf("Instance '%s' failed over to %s\n", instance, to.Server())
+	return nil
+}
+
+func getInstanceState(restOptions *api.RESTOptions, instance string) (api.HAStateInfo, error) {
+	states, err := getHAStates(restOptions)
+	if err != nil {
+		return api.HAStateInfo{}, err
+	}
+	state, ok := states.Find(instance)
+	if !ok {
+		return state, fmt.Errorf("instance '%s' is not found on %s", instance, restOptions.Server())
+	}
+	return state, nil
+}
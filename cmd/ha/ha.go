@@ -0,0 +1,98 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package ha
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"loxicmd/pkg/api"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func HaCmd(restOptions *api.RESTOptions) *cobra.Command {
+	var haCmd = &cobra.Command{
+		Use:   "ha",
+		Short: "Manage the HA cluster of the LoxiLB",
+		Long: `Manage the HA cluster of the LoxiLB.
+failover - Move MASTER of a cluster instance to another node
+cluster  - Show the HA state of the cluster instances over the nodes
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+			}
+		},
+		RunE: func(cmd *cobra.Command, args []string) (err error) {
+			fmt.Printf("Error: unknown command \"%v\"for \"loxicmd\" \nRun \"loxicmd --help\" for usage.\n", args)
+			cmd.Help()
+			return err
+		},
+	}
+
+	haCmd.AddCommand(NewFailoverCmd(restOptions))
+	haCmd.AddCommand(NewClusterCmd(restOptions))
+
+	return haCmd
+}
+
+func getHAStates(restOptions *api.RESTOptions) (api.HAStateGet, error) {
+	haresp := api.HAStateGet{}
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.HAState().SubResources([]string{"all"}).Get(ctx)
+	if err != nil {
+		return haresp, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return haresp, fmt.Errorf("failed to get HA state from %s: %s", restOptions.Server(), resp.Status)
+	}
+	resultByte, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return haresp, err
+	}
+	err = json.Unmarshal(resultByte, &haresp)
+	return haresp, err
+}
+
+func setHAState(restOptions *api.RESTOptions, mod api.HAStateMod) error {
+	client := api.NewLoxiClient(restOptions)
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := client.HAState().Create(ctx, mod)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to set '%s' to %s on %s: %s", mod.Instance, mod.State, restOptions.Server(), resp.Status)
+	}
+	return nil
+}
@@ -19,11 +19,22 @@ import (
 	"fmt"
 	"os"
 
+	"loxicmd/cmd/bfd"
+	"loxicmd/cmd/capture"
+	"loxicmd/cmd/check"
+	"loxicmd/cmd/cluster"
 	"loxicmd/cmd/create"
 	"loxicmd/cmd/delete"
+	"loxicmd/cmd/drain"
 	"loxicmd/cmd/dump"
+	"loxicmd/cmd/exporter"
+	"loxicmd/cmd/firewall"
 	"loxicmd/cmd/get"
+	"loxicmd/cmd/graph"
+	"loxicmd/cmd/ha"
 	"loxicmd/cmd/set"
+	"loxicmd/cmd/top"
+	"loxicmd/cmd/update"
 
 	"loxicmd/pkg/api"
 
@@ -64,9 +75,9 @@ var CompletionCmd = &cobra.Command{
 	},
 }
 
-// Execute adds all child commands to the root command and sets flags appropriately.
-// This is called by main.main(). It only needs to happen once to the rootCmd.
-func Execute() {
+// newRootCmd builds the command tree on restOptions. Execute builds one for the
+// command line and the fan-out of --servers builds one for each node.
+func newRootCmd(restOptions *api.RESTOptions) *cobra.Command {
 	var rootCmd = &cobra.Command{
 		Use:   "loxicmd",
 		Short: "loxicmd is the command-line tool for loxilb.",
@@ -76,7 +87,6 @@ func Execute() {
 	- Get Connection track (TCP/UDP/ICMP/SCTP) information
 loxicmd aim to provide all of the configuation for the loxilb.`,
 	}
-	restOptions := &api.RESTOptions{}
 	saveOptions := &dump.SaveOptions{}
 	applyOptions := &dump.ApplyOptions{}
 
@@ -91,6 +101,18 @@ loxicmd aim to provide all of the configuation for the loxilb.`,
 	rootCmd.AddCommand(create.CreateCmd(restOptions))
 	rootCmd.AddCommand(delete.DeleteCmd(restOptions))
 	rootCmd.AddCommand(set.SetParamCmd(restOptions))
+	rootCmd.AddCommand(update.UpdateCmd(restOptions))
+	rootCmd.AddCommand(drain.DrainCmd(restOptions))
+	rootCmd.AddCommand(drain.UndrainCmd(restOptions))
+	rootCmd.AddCommand(exporter.ExporterCmd(restOptions))
+	rootCmd.AddCommand(top.TopCmd(restOptions))
+	rootCmd.AddCommand(capture.CaptureCmd(restOptions))
+	rootCmd.AddCommand(firewall.FirewallCmd(restOptions))
+	rootCmd.AddCommand(check.CheckCmd(restOptions))
+	rootCmd.AddCommand(ha.HaCmd(restOptions))
+	rootCmd.AddCommand(bfd.BfdCmd(restOptions))
+	rootCmd.AddCommand(cluster.ClusterCmd(restOptions))
+	rootCmd.AddCommand(graph.GraphCmd(restOptions))
 
 	saveCmd := dump.SaveCmd(saveOptions, restOptions)
 	applyCmd := dump.ApplyCmd(applyOptions, restOptions)
@@ -121,6 +143,30 @@ loxicmd aim to provide all of the configuation for the loxilb.`,
 
 	rootCmd.AddCommand(saveCmd)
 	rootCmd.AddCommand(applyCmd)
+	return rootCmd
+}
+
+// Execute adds all child commands to the root command and sets flags appropriately.
+// This is called by main.main(). It only needs to happen once to the rootCmd.
+func Execute() {
+	restOptions := &api.RESTOptions{}
+	fanOutOptions := &cluster.FanOutOptions{}
+	rootCmd := newRootCmd(restOptions)
+
+	rootCmd.PersistentFlags().StringSliceVarP(&restOptions.Servers, "servers", "", nil, "Run the command on the API servers (ex.) 10.0.0.1,10.0.0.2:11111)")
+	rootCmd.PersistentFlags().BoolVarP(&fanOutOptions.Atomic, "atomic", "", false, "Roll back all the servers when a server of --servers fails (apply -f only)")
+
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		fanOut, err := cluster.CheckFanOut(cmd, restOptions, *fanOutOptions)
+		if err != nil {
+			fmt.Printf("Error: %s\n", err.Error())
+			os.Exit(1)
+		}
+		if fanOut {
+			os.Exit(cluster.FanOut(cmd, restOptions, *fanOutOptions, os.Args[1:], newRootCmd))
+		}
+	}
+
 	rootCmd.AddCommand(CompletionCmd)
 	rootCmd.AddCommand(VersionCmd)
 
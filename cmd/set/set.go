@@ -46,6 +46,7 @@ func SetParamCmd(restOptions *api.RESTOptions) *cobra.Command {
 	}
 	SetParamCmd.AddCommand(NewSetLogLevelCmd(restOptions))
 	SetParamCmd.AddCommand(NewSetBFDCmd(restOptions))
+	SetParamCmd.AddCommand(NewSetHAStateCmd(restOptions))
 	SetParamCmd.AddCommand(NewSetLogInCmd(restOptions))
 	SetParamCmd.AddCommand(NewSetLogOutCmd(restOptions))
 	SetParamCmd.AddCommand(NewSetRefreshTokenCmd(restOptions))
@@ -19,6 +19,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"loxicmd/cmd/create"
 	"loxicmd/pkg/api"
 	"net"
 	"net/http"
@@ -30,13 +31,18 @@ import (
 // NewLogLevelCmd represents the save command
 func NewSetBFDCmd(restOptions *api.RESTOptions) *cobra.Command {
 	o := api.BFDSessionInfo{}
+	opt := create.CreateBFDOptions{}
 	SetBFDCmd := &cobra.Command{
-		Use:   "bfd remoteIP [--instance=<instance>] [--interval=<interval>] [--retryCount=<count>]",
+		Use:   "bfd remoteIP [--instance=<instance>] [--interval=<interval>] [--minRx=<interval>] [--retryCount=<count>|--multiplier=<count>]",
 		Short: "bfd session configuration",
 		Long: `bfd session congfigration
 --instance   - Cluster Instance name
---interval   - BFD packet Tx interval value in microseconds
---retryCount - Maximum number of retry to detect failure`,
+--interval   - BFD packet Tx interval like 200ms, 1s or 300us. A number without a unit is in microseconds
+--minRx      - Minimum Rx interval required from the remote
+--retryCount - Maximum number of retry to detect failure
+--multiplier - Same as --retryCount
+
+ex) loxicmd set bfd 32.32.32.2 --interval=100ms --multiplier=5`,
 
 		Aliases: []string{"bfd-session"},
 		Run: func(cmd *cobra.Command, args []string) {
@@ -46,6 +52,10 @@ func NewSetBFDCmd(restOptions *api.RESTOptions) *cobra.Command {
 				fmt.Printf("Error: %s\n", err.Error())
 				return
 			}
+			if err := create.ReadBFDTimerOptions(cmd, &o, opt); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
 			resp, err := SetBFDAPICall(restOptions, o)
 			if err != nil {
 				fmt.Printf("Error: %s\n", err.Error())
@@ -59,8 +69,11 @@ func NewSetBFDCmd(restOptions *api.RESTOptions) *cobra.Command {
 		},
 	}
 	SetBFDCmd.Flags().StringVarP(&o.Instance, "instance", "", "default", "Specify the cluster instance name")
-	SetBFDCmd.Flags().Uint64VarP(&o.Interval, "interval", "", 0, "Specify the BFD packet tx interval (in microseconds)")
+	SetBFDCmd.Flags().StringVarP(&opt.Interval, "interval", "", "", "Specify the BFD packet tx interval (ex. 200ms, 1s or microseconds)")
+	SetBFDCmd.Flags().StringVarP(&opt.MinRx, "minRx", "", "", "Specify the minimum rx interval required from the remote")
 	SetBFDCmd.Flags().Uint8VarP(&o.RetryCount, "retryCount", "", 0, "Specify the number of retries")
+	SetBFDCmd.Flags().Uint8VarP(&opt.Multiplier, "multiplier", "", 0, "Specify the detect multiplier (same as --retryCount)")
+	SetBFDCmd.MarkFlagsMutuallyExclusive("retryCount", "multiplier")
 
 	return SetBFDCmd
 }
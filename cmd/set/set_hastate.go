@@ -0,0 +1,92 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package set
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"loxicmd/pkg/api"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewSetHAStateCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := api.HAStateMod{}
+	SetHAStateCmd := &cobra.Command{
+		Use:   "hastate <instance> --state=MASTER|BACKUP [--vip=<VIP>]",
+		Short: "HA state configuration",
+		Long: `Set the HA state of a cluster instance in the LoxiLB
+--state - MASTER or BACKUP
+--vip   - Virtual IP address of the instance
+
+ex) loxicmd set hastate default --state=BACKUP
+    loxicmd set hastate default --state=MASTER --vip=192.168.10.100
+
+To move MASTER to another node, use "loxicmd ha failover".`,
+
+		Aliases: []string{"ha", "HAstate"},
+		Run: func(cmd *cobra.Command, args []string) {
+			if err := ReadSetHAStateOptions(&o, args); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			resp, err := client.HAState().Create(ctx, o)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				body, _ := io.ReadAll(resp.Body)
+				fmt.Printf("Error: failed to set HA state: %s\n", resp.Status)
+				if b := strings.TrimSpace(string(body)); b != "" {
+					fmt.Printf("%s\n", b)
+				}
+				return
+			}
+			PrintSetResult(resp, *restOptions)
+		},
+	}
+	SetHAStateCmd.Flags().StringVarP(&o.State, "state", "", "", "Specify the HA state (MASTER|BACKUP)")
+	SetHAStateCmd.Flags().StringVarP(&o.Vip, "vip", "", "", "Specify the virtual IP address of the instance")
+	SetHAStateCmd.MarkFlagRequired("state")
+
+	return SetHAStateCmd
+}
+
+func ReadSetHAStateOptions(o *api.HAStateMod, args []string) error {
+	if len(args) > 1 {
+		return errors.New("set hastate command get so many args")
+	} else if len(args) < 1 {
+		return errors.New("set hastate need <instance> args")
+	}
+	o.Instance = args[0]
+	o.State = strings.ToUpper(o.State)
+
+	return o.Validation()
+}
@@ -0,0 +1,268 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package top
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"loxicmd/pkg/api"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// snapshot holds one poll of every API shown by top.
+type snapshot struct {
+	at         time.Time
+	lbs        api.LbRuleModGet
+	endpoints  api.EPInformationGet
+	ports      api.PortGet
+	conntrack  api.CtInformationGet
+	bgp        api.BGPNeighborModGet
+	bfd        api.BFDSessionGet
+	ha         api.HAStateGet
+	process    api.ProcessGet
+	filesystem api.FilesystemGet
+	portRates  map[string]portRate
+	errs       []string
+}
+
+type portRate struct {
+	rxBps, txBps, rxPps, txPps float64
+}
+
+func fetchJSON(restOptions *api.RESTOptions, c *api.CommonAPI, v interface{}) error {
+	ctx := context.TODO()
+	var cancel context.CancelFunc
+	if restOptions.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.TODO(), time.Duration(restOptions.Timeout)*time.Second)
+		defer cancel()
+	}
+	resp, err := c.Get(ctx)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// fetchSnapshot polls every API. prev is used to compute port rates.
+func fetchSnapshot(restOptions *api.RESTOptions, prev *snapshot) *snapshot {
+	client := api.NewLoxiClient(restOptions)
+	s := &snapshot{at: time.Now(), portRates: map[string]portRate{}}
+
+	fetches := []struct {
+		name string
+		c    *api.CommonAPI
+		v    interface{}
+	}{
+		{"loadbalancer", &client.LoadBalancerAll().CommonAPI, &s.lbs},
+		{"endpoint", client.EndPoint().SetUrl("/config/endpoint/all"), &s.endpoints},
+		{"port", &client.Port().CommonAPI, &s.ports},
+		{"conntrack", &client.Conntrack().CommonAPI, &s.conntrack},
+		{"bgp", client.BGPNeighbor().SetUrl("/config/bgp/neigh/all"), &s.bgp},
+		{"bfd", client.BFDSession().SetUrl("config/bfd/all"), &s.bfd},
+		{"hastate", client.HAState().SubResources([]string{"all"}), &s.ha},
+		{"process", client.Status().SetUrl("status/process"), &s.process},
+		{"filesystem", client.Status().SetUrl("status/filesystem"), &s.filesystem},
+	}
+	for _, f := range fetches {
+		if err := fetchJSON(restOptions, f.c, f.v); err != nil {
+			s.errs = append(s.errs, fmt.Sprintf("%s: %s", f.name, err.Error()))
+		}
+	}
+
+	if prev != nil {
+		elapsed := s.at.Sub(prev.at).Seconds()
+		old := map[string]api.PortStatsInfo{}
+		for _, p := range prev.ports.Ports {
+			old[p.Name] = p.Stats
+		}
+		for _, p := range s.ports.Ports {
+			o, ok := old[p.Name]
+			if !ok || elapsed <= 0 {
+				continue
+			}
+			s.portRates[p.Name] = portRate{
+				rxBps: rate(p.Stats.RxBytes, o.RxBytes, elapsed),
+				txBps: rate(p.Stats.TxBytes, o.TxBytes, elapsed),
+				rxPps: rate(p.Stats.RxPackets, o.RxPackets, elapsed),
+				txPps: rate(p.Stats.TxPackets, o.TxPackets, elapsed),
+			}
+		}
+	}
+	return s
+}
+
+func rate(cur, old uint64, elapsed float64) float64 {
+	if cur < old {
+		// counter was reset
+		return 0
+	}
+	return float64(cur-old) / elapsed
+}
+
+// lbPortString returns "port" or "port-portmax" of the service.
+func lbPortString(svc api.LoadBalancerService) string {
+	if svc.PortMax > svc.Port {
+		return fmt.Sprintf("%d-%d", svc.Port, svc.PortMax)
+	}
+	return fmt.Sprintf("%d", svc.Port)
+}
+
+func serviceRows(s *snapshot) [][]string {
+	var rows [][]string
+	for _, lb := range s.lbs.LbRules {
+		svc := lb.Service
+		for _, ep := range lb.Endpoints {
+			rows = append(rows, []string{svc.Name, svc.ExternalIP, lbPortString(svc), svc.Protocol,
+				svc.Mode.String(), svc.Sel.String(), ep.EndpointIP, fmt.Sprintf("%d", ep.TargetPort),
+				fmt.Sprintf("%d", ep.Weight), ep.State, fmt.Sprintf("%d", ep.Packets), fmt.Sprintf("%d", ep.Bytes)})
+		}
+	}
+	return rows
+}
+
+func endpointRows(s *snapshot) [][]string {
+	var rows [][]string
+	for _, ep := range s.endpoints.EPInfo {
+		rows = append(rows, []string{ep.HostName, ep.Name, ep.ProbeType, fmt.Sprintf("%d", ep.ProbePort),
+			ep.CurrState, ep.MinDelay, ep.AvgDelay, ep.MaxDelay})
+	}
+	return rows
+}
+
+func portRows(s *snapshot) [][]string {
+	var rows [][]string
+	for _, p := range s.ports.Ports {
+		link := "down"
+		if p.HInfo.Link {
+			link = "up"
+		}
+		r := s.portRates[p.Name]
+		rows = append(rows, []string{p.Name, link,
+			fmt.Sprintf("%.0f", r.rxBps), fmt.Sprintf("%.0f", r.txBps),
+			fmt.Sprintf("%.0f", r.rxPps), fmt.Sprintf("%.0f", r.txPps),
+			fmt.Sprintf("%d", p.Stats.RxBytes), fmt.Sprintf("%d", p.Stats.TxBytes),
+			fmt.Sprintf("%d", p.Stats.RxError), fmt.Sprintf("%d", p.Stats.TxError)})
+	}
+	return rows
+}
+
+func conntrackAggRows(s *snapshot, key string) [][]string {
+	agg, err := s.conntrack.Aggregate(key, "bytes")
+	if err != nil {
+		return nil
+	}
+	var rows [][]string
+	for _, a := range agg {
+		rows = append(rows, []string{a.Key, fmt.Sprintf("%d", a.Flows), fmt.Sprintf("%d", a.Pkts), fmt.Sprintf("%d", a.Bytes)})
+	}
+	return rows
+}
+
+func conntrackRows(s *snapshot, f api.CtFilter) [][]string {
+	var rows [][]string
+	for _, ct := range s.conntrack.CtInfo {
+		if !f.Match(ct) {
+			continue
+		}
+		rows = append(rows, []string{ct.Sip, fmt.Sprintf("%d", ct.Sport), ct.Dip, fmt.Sprintf("%d", ct.Dport),
+			ct.Proto, ct.CState, ct.CAct, fmt.Sprintf("%d", ct.Pkts), fmt.Sprintf("%d", ct.Bytes)})
+	}
+	return rows
+}
+
+func clusterRows(s *snapshot) [][]string {
+	var rows [][]string
+	for _, ha := range s.ha.HAStateAttr {
+		rows = append(rows, []string{"ha", ha.Instance, ha.Vip, ha.State, ""})
+	}
+	for _, n := range s.bgp.BGPAttr {
+		rows = append(rows, []string{"bgp", fmt.Sprintf("as%d", n.RemoteAs), n.IPaddress, n.State, n.UpDownTime})
+	}
+	for _, b := range s.bfd.BFDSessionAttr {
+		rows = append(rows, []string{"bfd", b.Instance, b.RemoteIP, b.State,
+			fmt.Sprintf("src %s interval %dus retry %d", b.SourceIP, b.Interval, b.RetryCount)})
+	}
+	return rows
+}
+
+func processRows(s *snapshot) [][]string {
+	var rows [][]string
+	for _, p := range s.process.ProcessAttr {
+		rows = append(rows, []string{p.Pid, p.User, p.Status, p.CPUUsage, p.MemoryUsage,
+			p.ResidentSize, p.ProcessTime, p.Command})
+	}
+	return rows
+}
+
+func filesystemRows(s *snapshot) [][]string {
+	var rows [][]string
+	for _, f := range s.filesystem.FilesystemAttr {
+		rows = append(rows, []string{f.FileSystem, f.Fstype, f.Size, f.Used, f.Avail, f.UsePercent, f.MountedOn})
+	}
+	return rows
+}
+
+// serviceCtFilter returns the conntrack filter selecting the flows of a
+// services tab row.
+func serviceCtFilter(row []string) api.CtFilter {
+	f := api.CtFilter{}
+	if row[0] != "" {
+		f.ServName = row[0]
+		return f
+	}
+	f.Dst, _ = api.ParseCtPrefix(row[1])
+	f.Proto = row[3]
+	if !strings.Contains(row[2], "-") {
+		if port, err := strconv.ParseUint(row[2], 10, 16); err == nil {
+			f.DPort = uint16(port)
+		}
+	}
+	return f
+}
+
+// sortRows sorts rows by col. Cells are compared as numbers when both parse.
+func sortRows(rows [][]string, col int, desc bool) {
+	if col < 0 {
+		return
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		if col >= len(rows[i]) || col >= len(rows[j]) {
+			return false
+		}
+		a, b := rows[i][col], rows[j][col]
+		x, errX := strconv.ParseFloat(a, 64)
+		y, errY := strconv.ParseFloat(b, 64)
+		if errX == nil && errY == nil && x != y {
+			if desc {
+				return x > y
+			}
+			return x < y
+		}
+		if desc {
+			return a > b
+		}
+		return a < b
+	})
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package top
+
+import (
+	"fmt"
+	"loxicmd/pkg/api"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type TopOptions struct {
+	Interval time.Duration
+	Tab      string
+}
+
+// key is a decoded keyboard input.
+type key struct {
+	r    rune
+	name string
+}
+
+func TopCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := TopOptions{}
+
+	var topCmd = &cobra.Command{
+		Use:   "top",
+		Short: "Interactive dashboard of the LoxiLB",
+		Long: `Interactive full-screen dashboard of the LoxiLB.
+Tabs: services, endpoints, ports, conntrack, cluster (HA/BGP/BFD), process, filesystem
+
+Keys:
+  tab/right/l, shift-tab/left/h   next/previous tab (or 1-7)
+  up/down/j/k, pgup/pgdn          move selection
+  s / S                           sort by next column / reverse order
+  /                               filter rows (enter to apply, esc to clear)
+  g                               conntrack tab: change group key
+  enter                           services tab: show conntrack entries of the service
+  esc                             back from drill-down
+  r                               refresh now
+  q                               quit
+
+ex) loxicmd top
+    loxicmd top --interval 5s --tab ports
+`,
+		Run: func(cmd *cobra.Command, args []string) {
+			if o.Interval <= 0 {
+				fmt.Printf("Error: interval must be greater than 0\n")
+				return
+			}
+			if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+				fmt.Printf("Error: top requires a terminal\n")
+				return
+			}
+			m := newModel()
+			if o.Tab != "" && !m.selectTab(o.Tab) {
+				fmt.Printf("Error: unknown tab '%s'\n", o.Tab)
+				return
+			}
+			if err := run(restOptions, o, m); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+			}
+		},
+	}
+
+	topCmd.Flags().DurationVarP(&o.Interval, "interval", "", 2*time.Second, "Refresh interval")
+	topCmd.Flags().StringVarP(&o.Tab, "tab", "", "", "Initial tab (services, endpoints, ports, conntrack, cluster, process, filesystem)")
+
+	return topCmd
+}
+
+func run(restOptions *api.RESTOptions, o TopOptions, m *model) error {
+	fd := int(os.Stdin.Fd())
+	oldState, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	// alternate screen, hide cursor
+	fmt.Print("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		fmt.Print("\x1b[?25h\x1b[?1049l")
+		term.Restore(fd, oldState)
+	}()
+
+	keys := make(chan key)
+	go readKeys(keys)
+
+	snapshots := make(chan *snapshot)
+	refresh := make(chan struct{}, 1)
+	go func() {
+		var prev *snapshot
+		ticker := time.NewTicker(o.Interval)
+		defer ticker.Stop()
+		for {
+			prev = fetchSnapshot(restOptions, prev)
+			snapshots <- prev
+			select {
+			case <-ticker.C:
+			case <-refresh:
+			}
+		}
+	}()
+
+	// redraw regularly so that terminal resizes are picked up
+	redraw := time.NewTicker(time.Second)
+	defer redraw.Stop()
+
+	for {
+		m.render(os.Stdout)
+		select {
+		case s := <-snapshots:
+			m.snap = s
+		case k := <-keys:
+			if k.name == "eof" {
+				return nil
+			}
+			if m.handleKey(k) {
+				return nil
+			}
+			if k.r == 'r' && !m.editing {
+				select {
+				case refresh <- struct{}{}:
+				default:
+				}
+			}
+		case <-redraw.C:
+		}
+	}
+}
+
+// readKeys decodes raw terminal input into keys.
+func readKeys(keys chan<- key) {
+	buf := make([]byte, 64)
+	for {
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			keys <- key{name: "eof"}
+			return
+		}
+		in := buf[:n]
+		for len(in) > 0 {
+			k, size := decodeKey(in)
+			in = in[size:]
+			keys <- k
+		}
+	}
+}
+
+var escapeKeys = map[string]string{
+	"\x1b[A":  "up",
+	"\x1b[B":  "down",
+	"\x1b[C":  "right",
+	"\x1b[D":  "left",
+	"\x1bOA":  "up",
+	"\x1bOB":  "down",
+	"\x1bOC":  "right",
+	"\x1bOD":  "left",
+	"\x1b[5~": "pgup",
+	"\x1b[6~": "pgdn",
+	"\x1b[H":  "home",
+	"\x1b[F":  "end",
+	"\x1b[Z":  "backtab",
+}
+
+func decodeKey(in []byte) (key, int) {
+	if in[0] == 0x1b {
+		for seq, name := range escapeKeys {
+			if strings.HasPrefix(string(in), seq) {
+				return key{name: name}, len(seq)
+			}
+		}
+		return key{name: "esc"}, 1
+	}
+	switch in[0] {
+	case '\r', '\n':
+		return key{name: "enter"}, 1
+	case '\t':
+		return key{name: "tab"}, 1
+	case 0x7f, 0x08:
+		return key{name: "backspace"}, 1
+	case 0x03:
+		return key{name: "ctrl-c"}, 1
+	}
+	r, size := utf8.DecodeRune(in)
+	return key{r: r}, size
+}
@@ -0,0 +1,396 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package top
+
+import (
+	"fmt"
+	"io"
+	"loxicmd/pkg/api"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// view is one table of the dashboard.
+type view struct {
+	name   string
+	header []string
+	rows   func(s *snapshot) [][]string
+	// drill returns the view shown when enter is pressed on row, if any.
+	drill func(row []string) *view
+
+	sortCol int
+	desc    bool
+	cursor  int
+	offset  int
+	filter  string
+}
+
+type model struct {
+	tabs   []*view
+	active int
+	// drilled is the drill-down view shown over the active tab
+	drilled *view
+	snap    *snapshot
+
+	ctGroup int
+	editing bool
+	input   string
+}
+
+func newModel() *model {
+	m := &model{}
+	m.tabs = []*view{
+		{
+			name:    "services",
+			header:  []string{"NAME", "VIP", "PORT", "PROTO", "MODE", "SEL", "ENDPOINT", "TPORT", "WEIGHT", "STATE", "PACKETS", "BYTES"},
+			rows:    serviceRows,
+			drill:   conntrackView,
+			sortCol: -1,
+		},
+		{
+			name:    "endpoints",
+			header:  []string{"HOST", "NAME", "PROBE", "PPORT", "STATE", "MIN", "AVG", "MAX"},
+			rows:    endpointRows,
+			sortCol: -1,
+		},
+		{
+			name:    "ports",
+			header:  []string{"PORT", "LINK", "RX B/S", "TX B/S", "RX PPS", "TX PPS", "RX BYTES", "TX BYTES", "RX ERR", "TX ERR"},
+			rows:    portRows,
+			sortCol: -1,
+		},
+		{
+			name:    "conntrack",
+			header:  []string{"KEY", "FLOWS", "PACKETS", "BYTES"},
+			rows:    func(s *snapshot) [][]string { return conntrackAggRows(s, api.CtAggregateKeys[m.ctGroup]) },
+			sortCol: 3,
+			desc:    true,
+		},
+		{
+			name:    "cluster",
+			header:  []string{"TYPE", "INSTANCE", "PEER", "STATE", "DETAIL"},
+			rows:    clusterRows,
+			sortCol: -1,
+		},
+		{
+			name:    "process",
+			header:  []string{"PID", "USER", "STATUS", "CPU%", "MEM%", "RES", "TIME", "COMMAND"},
+			rows:    processRows,
+			sortCol: -1,
+		},
+		{
+			name:    "filesystem",
+			header:  []string{"FILESYSTEM", "TYPE", "SIZE", "USED", "AVAIL", "USE%", "MOUNTED ON"},
+			rows:    filesystemRows,
+			sortCol: -1,
+		},
+	}
+	// group conntrack by source address first to show the top talkers
+	for i, k := range api.CtAggregateKeys {
+		if k == "src" {
+			m.ctGroup = i
+		}
+	}
+	return m
+}
+
+func conntrackView(row []string) *view {
+	f := serviceCtFilter(row)
+	name := row[0]
+	if name == "" {
+		name = row[1] + ":" + row[2]
+	}
+	return &view{
+		name:    "conntrack of " + name,
+		header:  []string{"SRC", "SPORT", "DST", "DPORT", "PROTO", "STATE", "ACT", "PACKETS", "BYTES"},
+		rows:    func(s *snapshot) [][]string { return conntrackRows(s, f) },
+		sortCol: 8,
+		desc:    true,
+	}
+}
+
+func (m *model) selectTab(name string) bool {
+	for i, t := range m.tabs {
+		if strings.HasPrefix(t.name, strings.ToLower(name)) {
+			m.active = i
+			return true
+		}
+	}
+	return false
+}
+
+func (m *model) current() *view {
+	if m.drilled != nil {
+		return m.drilled
+	}
+	return m.tabs[m.active]
+}
+
+// visibleRows returns the filtered and sorted rows of v.
+func (m *model) visibleRows(v *view) [][]string {
+	if m.snap == nil {
+		return nil
+	}
+	rows := v.rows(m.snap)
+	if v.filter != "" {
+		filter := strings.ToLower(v.filter)
+		var frows [][]string
+		for _, row := range rows {
+			if strings.Contains(strings.ToLower(strings.Join(row, " ")), filter) {
+				frows = append(frows, row)
+			}
+		}
+		rows = frows
+	}
+	sortRows(rows, v.sortCol, v.desc)
+	return rows
+}
+
+// handleKey updates the model and returns true when top should quit.
+func (m *model) handleKey(k key) bool {
+	v := m.current()
+
+	if m.editing {
+		switch k.name {
+		case "enter":
+			v.filter = m.input
+			m.editing = false
+		case "esc":
+			m.editing = false
+		case "backspace":
+			if len(m.input) > 0 {
+				r := []rune(m.input)
+				m.input = string(r[:len(r)-1])
+			}
+		case "ctrl-c":
+			return true
+		case "":
+			m.input += string(k.r)
+		}
+		v.cursor, v.offset = 0, 0
+		return false
+	}
+
+	_, height := termSize()
+	page := height - 5
+	if page < 1 {
+		page = 1
+	}
+
+	switch k.name {
+	case "ctrl-c":
+		return true
+	case "tab", "right":
+		m.switchTab(1)
+	case "backtab", "left":
+		m.switchTab(-1)
+	case "up":
+		v.cursor--
+	case "down":
+		v.cursor++
+	case "pgup":
+		v.cursor -= page
+	case "pgdn":
+		v.cursor += page
+	case "home":
+		v.cursor = 0
+	case "end":
+		v.cursor = len(m.visibleRows(v)) - 1
+	case "esc":
+		if m.drilled != nil {
+			m.drilled = nil
+		} else {
+			v.filter = ""
+		}
+	case "enter":
+		rows := m.visibleRows(v)
+		if v.drill != nil && v.cursor >= 0 && v.cursor < len(rows) {
+			m.drilled = v.drill(rows[v.cursor])
+		}
+	}
+
+	switch k.r {
+	case 'q':
+		return true
+	case 'l':
+		m.switchTab(1)
+	case 'h':
+		m.switchTab(-1)
+	case 'k':
+		v.cursor--
+	case 'j':
+		v.cursor++
+	case 's':
+		v.sortCol = (v.sortCol + 1) % len(v.header)
+	case 'S':
+		v.desc = !v.desc
+	case '/':
+		m.editing = true
+		m.input = v.filter
+	case 'g':
+		if m.drilled == nil && m.tabs[m.active].name == "conntrack" {
+			m.ctGroup = (m.ctGroup + 1) % len(api.CtAggregateKeys)
+			v.cursor, v.offset = 0, 0
+		}
+	default:
+		if k.r >= '1' && k.r <= '9' && int(k.r-'1') < len(m.tabs) {
+			m.drilled = nil
+			m.active = int(k.r - '1')
+		}
+	}
+	return false
+}
+
+func (m *model) switchTab(delta int) {
+	m.drilled = nil
+	m.active = (m.active + delta + len(m.tabs)) % len(m.tabs)
+}
+
+func termSize() (int, int) {
+	width, height, err := term.GetSize(int(os.Stdout.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+	return width, height
+}
+
+// render draws the whole screen.
+func (m *model) render(w io.Writer) {
+	width, height := termSize()
+	v := m.current()
+	rows := m.visibleRows(v)
+
+	if v.cursor >= len(rows) {
+		v.cursor = len(rows) - 1
+	}
+	if v.cursor < 0 {
+		v.cursor = 0
+	}
+	bodyHeight := height - 4
+	if bodyHeight < 1 {
+		bodyHeight = 1
+	}
+	if v.cursor < v.offset {
+		v.offset = v.cursor
+	}
+	if v.cursor >= v.offset+bodyHeight {
+		v.offset = v.cursor - bodyHeight + 1
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\x1b[H\x1b[2J")
+
+	// tab bar
+	var bar strings.Builder
+	for i, t := range m.tabs {
+		label := fmt.Sprintf(" %d:%s ", i+1, t.name)
+		if i == m.active {
+			label = "\x1b[7m" + label + "\x1b[0m"
+		}
+		bar.WriteString(label)
+	}
+
+	// column widths
+	widths := make([]int, len(v.header))
+	for i, h := range v.header {
+		widths[i] = len(h) + 1
+	}
+	for _, row := range rows {
+		for i, c := range row {
+			if i < len(widths) && len(c) > widths[i] {
+				widths[i] = len(c)
+			}
+		}
+	}
+	for i := range widths {
+		if widths[i] > 40 {
+			widths[i] = 40
+		}
+	}
+
+	header := make([]string, len(v.header))
+	for i, h := range v.header {
+		if i == v.sortCol {
+			if v.desc {
+				h += "v"
+			} else {
+				h += "^"
+			}
+		}
+		header[i] = h
+	}
+	title := v.name
+	if v.name == "conntrack" && m.drilled == nil {
+		title += " by " + api.CtAggregateKeys[m.ctGroup]
+	}
+	bar.WriteString("  [" + title + "]")
+	sb.WriteString(bar.String() + "\r\n")
+	sb.WriteString("\x1b[1m" + clip(formatRow(header, widths), width) + "\x1b[0m\r\n")
+
+	for i := v.offset; i < len(rows) && i < v.offset+bodyHeight; i++ {
+		line := clip(formatRow(rows[i], widths), width)
+		if i == v.cursor {
+			line = "\x1b[7m" + line + "\x1b[0m"
+		}
+		sb.WriteString(line + "\r\n")
+	}
+
+	// status line
+	sb.WriteString(fmt.Sprintf("\x1b[%d;1H", height))
+	var status string
+	switch {
+	case m.editing:
+		status = "filter: " + m.input
+	case m.snap == nil:
+		status = "loading..."
+	default:
+		status = fmt.Sprintf("%d rows  updated %s", len(rows), m.snap.at.Format("15:04:05"))
+		if v.filter != "" {
+			status += "  filter: " + v.filter
+		}
+		if len(m.snap.errs) > 0 {
+			status += "  error: " + m.snap.errs[0]
+		}
+		status += "  (q quit, / filter, s sort, enter drill-down)"
+	}
+	sb.WriteString(clip(status, width))
+	io.WriteString(w, sb.String())
+}
+
+func formatRow(row []string, widths []int) string {
+	cells := make([]string, len(row))
+	for i, c := range row {
+		wd := 10
+		if i < len(widths) {
+			wd = widths[i]
+		}
+		cells[i] = fmt.Sprintf("%-*s", wd, clip(c, wd))
+	}
+	return strings.Join(cells, " ")
+}
+
+func clip(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	if n <= 0 {
+		return ""
+	}
+	return string(r[:n])
+}
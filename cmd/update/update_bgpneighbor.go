@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package update
+
+import (
+	"context"
+	"fmt"
+	"loxicmd/cmd/create"
+	"loxicmd/pkg/api"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+type UpdateBGPNeighborOptions struct {
+	RemoteAs             int
+	RemotePort           uint16
+	SetMultiHtop         bool
+	HoldTime             uint32
+	KeepaliveInterval    uint32
+	Password             string
+	SourceAddress        string
+	AddressFamilies      []string
+	LocalAs              int
+	RouteReflectorClient bool
+}
+
+func NewUpdateBGPNeighborCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := UpdateBGPNeighborOptions{}
+
+	var updateBGPNeighborCmd = &cobra.Command{
+		Use:   "bgpneighbor <PeerIP> [--remoteAs=<ASN>] [--remotePort=<remoteBGPPort>] [--setMultiHtop] [--holdTime=<sec>] [--keepaliveInterval=<sec>] [--password=<md5 key>] [--sourceAddress=<IP>] [--addressFamily=ipv4,ipv6] [--localAs=<ASN>] [--routeReflectorClient]",
+		Short: "Update a BGP Neighbor",
+		Long: `Update the settings of a BGP Neighbor in place in the LoxiLB.
+Only the given flags are changed. A boolean flag is turned off with "=false",
+--password="" removes the password and --localAs=0 removes the local-AS override.
+
+ex) loxicmd update bgpneighbor 10.10.10.1 --holdTime=9 --keepaliveInterval=3
+    loxicmd update bgpneighbor 10.10.10.1 --password=newsecret
+    loxicmd update bgpneighbor 10.10.10.1 --routeReflectorClient=false
+    loxicmd update bgpneighbor 2001::1 --addressFamily=ipv6
+`,
+		Aliases: []string{"bgpnei", "bgpneigh"},
+		Args:    cobra.MaximumNArgs(1),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			peer := args[0]
+			if net.ParseIP(peer) == nil {
+				fmt.Printf("Error: peer IP '%s' is invalid format\n", peer)
+				return
+			}
+			mod := MakeBGPNeighborUpdateMod(cmd, o)
+			if err := mod.Validation(peer); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			resp, err := client.BGPNeighbor().SubResources([]string{peer}).Update(ctx, mod)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				fmt.Printf("Error: failed to update BGP neighbor %s: %s\n", peer, resp.Status)
+				return
+			}
+			create.PrintCreateResult(resp, *restOptions)
+		},
+	}
+
+	updateBGPNeighborCmd.Flags().IntVarP(&o.RemoteAs, "remoteAs", "", 0, "ASN of the remote site")
+	updateBGPNeighborCmd.Flags().Uint16VarP(&o.RemotePort, "remotePort", "", 179, "BGP Port number of the remote site")
+	updateBGPNeighborCmd.Flags().BoolVarP(&o.SetMultiHtop, "setMultiHtop", "", false, "Enable Multihop BGP in the load balancer")
+	updateBGPNeighborCmd.Flags().Uint32VarP(&o.HoldTime, "holdTime", "", 0, "Hold timer in seconds")
+	updateBGPNeighborCmd.Flags().Uint32VarP(&o.KeepaliveInterval, "keepaliveInterval", "", 0, "Keepalive timer in seconds")
+	updateBGPNeighborCmd.Flags().StringVarP(&o.Password, "password", "", "", "TCP MD5 password of the session")
+	updateBGPNeighborCmd.Flags().StringVarP(&o.SourceAddress, "sourceAddress", "", "", "Local address of the session")
+	updateBGPNeighborCmd.Flags().StringSliceVarP(&o.AddressFamilies, "addressFamily", "", nil, "Address families to exchange (ipv4, ipv6)")
+	updateBGPNeighborCmd.Flags().IntVarP(&o.LocalAs, "localAs", "", 0, "ASN used toward this neighbor instead of the global ASN")
+	updateBGPNeighborCmd.Flags().BoolVarP(&o.RouteReflectorClient, "routeReflectorClient", "", false, "Make the neighbor a route reflector client")
+
+	return updateBGPNeighborCmd
+}
+
+// MakeBGPNeighborUpdateMod sets only the fields of the flags given on the command line
+func MakeBGPNeighborUpdateMod(cmd *cobra.Command, o UpdateBGPNeighborOptions) api.BGPNeighborUpdateMod {
+	mod := api.BGPNeighborUpdateMod{}
+	flags := cmd.Flags()
+	if flags.Changed("remoteAs") {
+		mod.RemoteAs = &o.RemoteAs
+	}
+	if flags.Changed("remotePort") {
+		port := int(o.RemotePort)
+		mod.RemotePort = &port
+	}
+	if flags.Changed("setMultiHtop") {
+		mod.SetMultiHop = &o.SetMultiHtop
+	}
+	if flags.Changed("holdTime") {
+		mod.HoldTime = &o.HoldTime
+	}
+	if flags.Changed("keepaliveInterval") {
+		mod.KeepaliveInterval = &o.KeepaliveInterval
+	}
+	if flags.Changed("password") {
+		mod.Password = &o.Password
+	}
+	if flags.Changed("sourceAddress") {
+		mod.SourceAddress = &o.SourceAddress
+	}
+	if flags.Changed("addressFamily") {
+		mod.AddressFamilies = &o.AddressFamilies
+	}
+	if flags.Changed("localAs") {
+		mod.LocalAs = &o.LocalAs
+	}
+	if flags.Changed("routeReflectorClient") {
+		mod.RouteReflectorClient = &o.RouteReflectorClient
+	}
+	return mod
+}
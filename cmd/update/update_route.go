@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package update
+
+import (
+	"context"
+	"fmt"
+	"loxicmd/cmd/create"
+	"loxicmd/pkg/api"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+func NewUpdateRouteCmd(restOptions *api.RESTOptions) *cobra.Command {
+	o := create.CreateRouteStaticOptions{}
+
+	var updateRouteCmd = &cobra.Command{
+		Use:   "route <DestinationIPNet> <gateway> [--proto=<protocol>]",
+		Short: "Update a Route",
+		Long: `Replace the gateway of a Route in place in the LoxiLB.
+It is working as "ip route replace <DestinationIPNet> via <gateway>"
+A route has a single gateway as the route API of LoxiLB has no ECMP next-hops.
+
+ex) loxicmd update route 10.10.0.0/16 172.17.0.254
+    loxicmd update route 2001:db8::/64 fe80::2
+`,
+		Args: cobra.MaximumNArgs(2),
+		PreRun: func(cmd *cobra.Command, args []string) {
+			if len(args) == 0 {
+				cmd.Help()
+				os.Exit(0)
+			}
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			var route api.Routev4Get
+			if err := create.ReadCreateRouteOptions(&route, args, o); err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+
+			client := api.NewLoxiClient(restOptions)
+			ctx := context.TODO()
+			var cancel context.CancelFunc
+			if restOptions.Timeout > 0 {
+				ctx, cancel = context.WithTimeout(ctx, time.Duration(restOptions.Timeout)*time.Second)
+				defer cancel()
+			}
+			subResources := []string{
+				"destinationIPNet", route.Dst,
+			}
+			resp, err := client.Route().SubResources(subResources).Update(ctx, route)
+			if err != nil {
+				fmt.Printf("Error: %s\n", err.Error())
+				return
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				fmt.Printf("Error: failed to update route %s: %s\n", route.Dst, resp.Status)
+				return
+			}
+			create.PrintCreateResult(resp, *restOptions)
+		},
+	}
+
+	updateRouteCmd.Flags().StringVarP(&o.StaticProto, "proto", "", "", "Proto static mode")
+
+	return updateRouteCmd
+}
@@ -15,6 +15,23 @@
  */
 package api
 
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+)
+
+// BFD session states reported by loxilb
+const (
+	BFDStateUp        = "BFDUp"
+	BFDStateDown      = "BFDDown"
+	BFDStateInit      = "BFDInit"
+	BFDStateAdminDown = "BFDAdminDown"
+)
+
+const BFDDefaultPort = 3784
+
 type BFDSession struct {
 	CommonAPI
 }
@@ -42,9 +59,12 @@ type BFDSessionInfo struct {
 	// Interval - Tx Interval between BFD packets
 	Interval uint64 `json:"interval" yaml:"interval"`
 
-	// RetryCount - Retry Count for detecting failure
+	// RetryCount - Retry Count for detecting failure (detect multiplier)
 	RetryCount uint8 `json:"retryCount" yaml:"retryCount"`
 
+	// MinRxInterval - Minimum Rx interval of BFD packets required from the remote in microseconds
+	MinRxInterval uint64 `json:"minRxInterval,omitempty" yaml:"minRxInterval,omitempty"`
+
 	// Current BFD State
 	State string `json:"state" yaml:"state"`
 }
@@ -54,3 +74,63 @@ type ConfigurationBFDFile struct {
 	ObjectMeta `yaml:"metadata,omitempty"`
 	Spec       BFDSessionInfo `yaml:"spec"`
 }
+
+// ParseBFDInterval parses an interval with a unit like "200ms", "1s" or "300us".
+// A number without a unit is in microseconds.
+func ParseBFDInterval(interval string) (uint64, error) {
+	if val, err := strconv.ParseUint(interval, 10, 64); err == nil {
+		return val, nil
+	}
+	d, err := time.ParseDuration(interval)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("interval '%s' is invalid. ex) 200ms, 1s, 300us or 200000", interval)
+	}
+	if d%time.Microsecond != 0 {
+		return 0, fmt.Errorf("interval '%s' is finer than a microsecond", interval)
+	}
+	return uint64(d / time.Microsecond), nil
+}
+
+// FormatBFDInterval formats an interval in microseconds as "200ms"
+func FormatBFDInterval(interval uint64) string {
+	if interval == 0 {
+		return "-"
+	}
+	return (time.Duration(interval) * time.Microsecond).String()
+}
+
+// DetectTime returns the time to detect a failure of the session
+func (bfd BFDSessionInfo) DetectTime() time.Duration {
+	interval := bfd.Interval
+	if bfd.MinRxInterval > interval {
+		interval = bfd.MinRxInterval
+	}
+	return time.Duration(interval) * time.Microsecond * time.Duration(bfd.RetryCount)
+}
+
+// Key returns the session key as "<instance>/<remoteIP>"
+func (bfd BFDSessionInfo) Key() string {
+	return bfd.Instance + "/" + bfd.RemoteIP
+}
+
+func (bfd BFDSessionInfo) Validation() error {
+	if net.ParseIP(bfd.RemoteIP) == nil {
+		return fmt.Errorf("remote IP '%s' is invalid format", bfd.RemoteIP)
+	}
+	if bfd.SourceIP != "" {
+		src := net.ParseIP(bfd.SourceIP)
+		if src == nil {
+			return fmt.Errorf("source IP '%s' is invalid format", bfd.SourceIP)
+		}
+		if (src.To4() == nil) != (net.ParseIP(bfd.RemoteIP).To4() == nil) {
+			return fmt.Errorf("source IP '%s' and remote IP '%s' are different IP families", bfd.SourceIP, bfd.RemoteIP)
+		}
+	}
+	if bfd.Interval != 0 && bfd.Interval < 1000 {
+		return fmt.Errorf("interval %s is too short. it should be 1ms or more", FormatBFDInterval(bfd.Interval))
+	}
+	if bfd.MinRxInterval != 0 && bfd.MinRxInterval < 1000 {
+		return fmt.Errorf("min-rx %s is too short. it should be 1ms or more", FormatBFDInterval(bfd.MinRxInterval))
+	}
+	return nil
+}
@@ -16,7 +16,9 @@
 package api
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"sort"
 )
 
@@ -37,6 +39,34 @@ type BGPNeighborMod struct {
 	RemotePort int `json:"remotePort" yaml:"remotePort"`
 	// SetMultiHop - BGP Multihop enable
 	SetMultiHop bool `json:"setMultiHop" yaml:"setMultiHop"`
+	// HoldTime - hold timer in seconds, 0 is the default of loxilb
+	HoldTime uint32 `json:"holdTime,omitempty" yaml:"holdTime,omitempty"`
+	// KeepaliveInterval - keepalive timer in seconds, 0 is the default of loxilb
+	KeepaliveInterval uint32 `json:"keepaliveInterval,omitempty" yaml:"keepaliveInterval,omitempty"`
+	// Password - TCP MD5 password of the session
+	Password string `json:"password,omitempty" yaml:"password,omitempty"`
+	// SourceAddress - local address of the session
+	SourceAddress string `json:"sourceAddress,omitempty" yaml:"sourceAddress,omitempty"`
+	// AddressFamilies - "ipv4" and/or "ipv6" unicast families to exchange
+	AddressFamilies []string `json:"addressFamilies,omitempty" yaml:"addressFamilies,omitempty"`
+	// LocalAs - ASN used toward this neighbor instead of the global ASN
+	LocalAs int `json:"localAs,omitempty" yaml:"localAs,omitempty"`
+	// RouteReflectorClient - the neighbor is a route reflector client
+	RouteReflectorClient bool `json:"routeReflectorClient,omitempty" yaml:"routeReflectorClient,omitempty"`
+}
+
+// BGPNeighborUpdateMod - BGP neighbor settings to change in place, nil fields are kept
+type BGPNeighborUpdateMod struct {
+	RemoteAs             *int      `json:"remoteAs,omitempty"`
+	RemotePort           *int      `json:"remotePort,omitempty"`
+	SetMultiHop          *bool     `json:"setMultiHop,omitempty"`
+	HoldTime             *uint32   `json:"holdTime,omitempty"`
+	KeepaliveInterval    *uint32   `json:"keepaliveInterval,omitempty"`
+	Password             *string   `json:"password,omitempty"`
+	SourceAddress        *string   `json:"sourceAddress,omitempty"`
+	AddressFamilies      *[]string `json:"addressFamilies,omitempty"`
+	LocalAs              *int      `json:"localAs,omitempty"`
+	RouteReflectorClient *bool     `json:"routeReflectorClient,omitempty"`
 }
 
 type BGPNeighborEntry struct {
@@ -48,6 +78,12 @@ type BGPNeighborEntry struct {
 	RemoteAs int `json:"remoteAs" yaml:"remoteAs"`
 	// UpDownTime - uptime or down time based on status
 	UpDownTime string `json:"updowntime" yaml:"updowntime"`
+	// PrefixesReceived - number of prefixes received from the neighbor
+	PrefixesReceived int `json:"prefixesReceived" yaml:"prefixesReceived"`
+	// PrefixesAdvertised - number of prefixes advertised to the neighbor
+	PrefixesAdvertised int `json:"prefixesAdvertised" yaml:"prefixesAdvertised"`
+	// LastError - last notification or error of the session
+	LastError string `json:"lastError" yaml:"lastError"`
 }
 
 type ConfigurationBGPFile struct {
@@ -65,3 +101,144 @@ func (BGPsresp BGPNeighborModGet) Sort() {
 		return BGPsresp.BGPAttr[i].Key() < BGPsresp.BGPAttr[j].Key()
 	})
 }
+
+// BGP address families
+const (
+	BGPFamilyIPv4 = "ipv4"
+	BGPFamilyIPv6 = "ipv6"
+)
+
+// MaxBGPPasswordLen is the longest TCP MD5 key
+const MaxBGPPasswordLen = 80
+
+func validateBGPAs(name string, as int) error {
+	if as < 1 || int64(as) > 4294967295 {
+		return fmt.Errorf("%s %d is out of range (1-4294967295)", name, as)
+	}
+	return nil
+}
+
+// validateBGPTimers follows RFC 4271: the hold time is 0 or at least 3 seconds
+// and the keepalive interval is less than the hold time.
+func validateBGPTimers(hold, keepalive uint32) error {
+	if hold != 0 && hold < 3 {
+		return fmt.Errorf("hold time %d must be 0 or at least 3 seconds", hold)
+	}
+	if hold > 65535 {
+		return fmt.Errorf("hold time %d is over 65535 seconds", hold)
+	}
+	if hold != 0 && keepalive >= hold {
+		return fmt.Errorf("keepalive interval %d must be less than the hold time %d", keepalive, hold)
+	}
+	return nil
+}
+
+func validateBGPFamilies(families []string) error {
+	seen := map[string]bool{}
+	for _, af := range families {
+		if af != BGPFamilyIPv4 && af != BGPFamilyIPv6 {
+			return fmt.Errorf("address family '%s' is not supported (%s, %s)", af, BGPFamilyIPv4, BGPFamilyIPv6)
+		}
+		if seen[af] {
+			return fmt.Errorf("address family '%s' is given twice", af)
+		}
+		seen[af] = true
+	}
+	return nil
+}
+
+func validateBGPPassword(password string) error {
+	if len(password) > MaxBGPPasswordLen {
+		return fmt.Errorf("password is longer than %d characters", MaxBGPPasswordLen)
+	}
+	return nil
+}
+
+func validateBGPSource(peer, source string) error {
+	src := net.ParseIP(source)
+	if src == nil {
+		return fmt.Errorf("source address '%s' is invalid format", source)
+	}
+	if peerIP := net.ParseIP(peer); peerIP != nil && (peerIP.To4() == nil) != (src.To4() == nil) {
+		return fmt.Errorf("source address %s and peer %s are not the same IP family", source, peer)
+	}
+	return nil
+}
+
+func (nei BGPNeighborMod) Validation() error {
+	if net.ParseIP(nei.IPaddress) == nil {
+		return fmt.Errorf("peer IP '%s' is invalid format", nei.IPaddress)
+	}
+	if err := validateBGPAs("remoteAs", nei.RemoteAs); err != nil {
+		return err
+	}
+	if nei.RemotePort < 0 || nei.RemotePort > 65535 {
+		return fmt.Errorf("remote port %d is out of range", nei.RemotePort)
+	}
+	if err := validateBGPTimers(nei.HoldTime, nei.KeepaliveInterval); err != nil {
+		return err
+	}
+	if err := validateBGPPassword(nei.Password); err != nil {
+		return err
+	}
+	if nei.SourceAddress != "" {
+		if err := validateBGPSource(nei.IPaddress, nei.SourceAddress); err != nil {
+			return err
+		}
+	}
+	if err := validateBGPFamilies(nei.AddressFamilies); err != nil {
+		return err
+	}
+	if nei.LocalAs != 0 {
+		if err := validateBGPAs("localAs", nei.LocalAs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validation checks the fields to change. A timer is checked against the other
+// timer only when both are changed, loxilb checks the rest.
+func (nei BGPNeighborUpdateMod) Validation(peer string) error {
+	if nei == (BGPNeighborUpdateMod{}) {
+		return errors.New("nothing to update")
+	}
+	if nei.RemoteAs != nil {
+		if err := validateBGPAs("remoteAs", *nei.RemoteAs); err != nil {
+			return err
+		}
+	}
+	if nei.RemotePort != nil && (*nei.RemotePort < 1 || *nei.RemotePort > 65535) {
+		return fmt.Errorf("remote port %d is out of range", *nei.RemotePort)
+	}
+	if nei.HoldTime != nil {
+		keepalive := uint32(0)
+		if nei.KeepaliveInterval != nil {
+			keepalive = *nei.KeepaliveInterval
+		}
+		if err := validateBGPTimers(*nei.HoldTime, keepalive); err != nil {
+			return err
+		}
+	}
+	if nei.Password != nil {
+		if err := validateBGPPassword(*nei.Password); err != nil {
+			return err
+		}
+	}
+	if nei.SourceAddress != nil && *nei.SourceAddress != "" {
+		if err := validateBGPSource(peer, *nei.SourceAddress); err != nil {
+			return err
+		}
+	}
+	if nei.AddressFamilies != nil {
+		if err := validateBGPFamilies(*nei.AddressFamilies); err != nil {
+			return err
+		}
+	}
+	if nei.LocalAs != nil && *nei.LocalAs != 0 {
+		if err := validateBGPAs("localAs", *nei.LocalAs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type BGPPolicy struct {
+	CommonAPI
+}
+
+// BGP policy directions
+const (
+	BGPPolicyImport = "import"
+	BGPPolicyExport = "export"
+)
+
+// BGP policy actions
+const (
+	BGPPolicyAccept = "accept"
+	BGPPolicyReject = "reject"
+)
+
+type BGPPolicyModGet struct {
+	BGPPolicyAttr []BGPPolicyMod `json:"bgpPolicyAttr"`
+}
+
+// BGPPolicyPrefix - an entry of a prefix-list. MaskLenMin and MaskLenMax
+// match longer prefixes inside Prefix, both 0 matches Prefix exactly.
+type BGPPolicyPrefix struct {
+	Prefix     string `json:"prefix" yaml:"prefix"`
+	MaskLenMin uint8  `json:"maskLenMin,omitempty" yaml:"maskLenMin,omitempty"`
+	MaskLenMax uint8  `json:"maskLenMax,omitempty" yaml:"maskLenMax,omitempty"`
+}
+
+// BGPPolicyMod - Info related to a BGP import or export policy
+type BGPPolicyMod struct {
+	// Name - policy identifier
+	Name string `json:"name" yaml:"name"`
+	// Direction - "import" or "export"
+	Direction string `json:"direction" yaml:"direction"`
+	// Peers - neighbors the policy applies to, empty for all neighbors
+	Peers []string `json:"peers,omitempty" yaml:"peers,omitempty"`
+	// Prefixes - prefix-list the policy matches, empty for all prefixes
+	Prefixes []BGPPolicyPrefix `json:"prefixes,omitempty" yaml:"prefixes,omitempty"`
+	// Action - "accept" or "reject" the matching prefixes
+	Action string `json:"action" yaml:"action"`
+	// SetCommunities - communities added to the matching prefixes
+	SetCommunities []string `json:"setCommunities,omitempty" yaml:"setCommunities,omitempty"`
+	// SetMed - MED set on the matching prefixes
+	SetMed *uint32 `json:"setMed,omitempty" yaml:"setMed,omitempty"`
+	// SetLocalPref - local preference set on the matching prefixes
+	SetLocalPref *uint32 `json:"setLocalPref,omitempty" yaml:"setLocalPref,omitempty"`
+}
+
+type ConfigurationBGPPolicyFile struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata,omitempty"`
+	Spec       BGPPolicyMod `yaml:"spec"`
+}
+
+var bgpMaskRangeRe = regexp.MustCompile(`^(\d+)-(\d+)$`)
+
+// ParseBGPPolicyPrefix parses "<cidr>" or "<cidr>:<min>-<max>".
+// ex) 10.0.0.0/8:24-32, 2001:db8::/32:48-64
+func ParseBGPPolicyPrefix(prefix string) (BGPPolicyPrefix, error) {
+	p := BGPPolicyPrefix{Prefix: prefix}
+	if i := strings.LastIndex(prefix, ":"); i >= 0 {
+		if m := bgpMaskRangeRe.FindStringSubmatch(prefix[i+1:]); m != nil {
+			min, err1 := strconv.ParseUint(m[1], 10, 8)
+			max, err2 := strconv.ParseUint(m[2], 10, 8)
+			if err1 != nil || err2 != nil {
+				return p, fmt.Errorf("mask length range '%s' is invalid", prefix[i+1:])
+			}
+			p = BGPPolicyPrefix{Prefix: prefix[:i], MaskLenMin: uint8(min), MaskLenMax: uint8(max)}
+		}
+	}
+	return p, p.Validation()
+}
+
+func (p BGPPolicyPrefix) String() string {
+	if p.MaskLenMin == 0 && p.MaskLenMax == 0 {
+		return p.Prefix
+	}
+	return fmt.Sprintf("%s:%d-%d", p.Prefix, p.MaskLenMin, p.MaskLenMax)
+}
+
+func (p BGPPolicyPrefix) Validation() error {
+	_, ipNet, err := net.ParseCIDR(p.Prefix)
+	if err != nil {
+		return fmt.Errorf("prefix '%s' is not a valid CIDR", p.Prefix)
+	}
+	if p.MaskLenMin == 0 && p.MaskLenMax == 0 {
+		return nil
+	}
+	ones, bits := ipNet.Mask.Size()
+	if int(p.MaskLenMin) < ones || p.MaskLenMin > p.MaskLenMax || int(p.MaskLenMax) > bits {
+		return fmt.Errorf("mask length range %d-%d of %s must be within %d-%d", p.MaskLenMin, p.MaskLenMax, p.Prefix, ones, bits)
+	}
+	return nil
+}
+
+var bgpCommunityRe = regexp.MustCompile(`^(\d+):(\d+)$`)
+
+// ValidateBGPCommunity accepts "<ASN>:<value>" with 16 bit parts and the
+// well-known names no-export, no-advertise and no-export-subconfed.
+func ValidateBGPCommunity(community string) error {
+	switch community {
+	case "no-export", "no-advertise", "no-export-subconfed":
+		return nil
+	}
+	m := bgpCommunityRe.FindStringSubmatch(community)
+	if m == nil {
+		return fmt.Errorf("community '%s' is invalid format (<ASN>:<value>)", community)
+	}
+	for _, part := range m[1:] {
+		if _, err := strconv.ParseUint(part, 10, 16); err != nil {
+			return fmt.Errorf("community '%s' is out of range", community)
+		}
+	}
+	return nil
+}
+
+func (pol BGPPolicyMod) Validation() error {
+	if pol.Name == "" {
+		return errors.New("policy name is empty")
+	}
+	if pol.Direction != BGPPolicyImport && pol.Direction != BGPPolicyExport {
+		return fmt.Errorf("direction '%s' is not supported (%s, %s)", pol.Direction, BGPPolicyImport, BGPPolicyExport)
+	}
+	if pol.Action != BGPPolicyAccept && pol.Action != BGPPolicyReject {
+		return fmt.Errorf("action '%s' is not supported (%s, %s)", pol.Action, BGPPolicyAccept, BGPPolicyReject)
+	}
+	for _, peer := range pol.Peers {
+		if net.ParseIP(peer) == nil {
+			return fmt.Errorf("peer IP '%s' is invalid format", peer)
+		}
+	}
+	for _, p := range pol.Prefixes {
+		if err := p.Validation(); err != nil {
+			return err
+		}
+	}
+	for _, c := range pol.SetCommunities {
+		if err := ValidateBGPCommunity(c); err != nil {
+			return err
+		}
+	}
+	if pol.Action == BGPPolicyReject && (len(pol.SetCommunities) != 0 || pol.SetMed != nil || pol.SetLocalPref != nil) {
+		return errors.New("a reject policy can't set communities, MED or local preference")
+	}
+	return nil
+}
+
+func (bgppolresp BGPPolicyModGet) Sort() {
+	sort.Slice(bgppolresp.BGPPolicyAttr, func(i, j int) bool {
+		return bgppolresp.BGPPolicyAttr[i].Name < bgppolresp.BGPPolicyAttr[j].Name
+	})
+}
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package api
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type BGPRoute struct {
+	CommonAPI
+}
+
+type BGPRouteGet struct {
+	BGPRouteAttr []BGPRouteEntry `json:"bgpRouteAttr"`
+}
+
+// BGPRouteEntry - a path in the BGP table
+type BGPRouteEntry struct {
+	// Prefix - destination in CIDR
+	Prefix string `json:"prefix"`
+	// NextHop - next hop of the path
+	NextHop string `json:"nextHop"`
+	// Peer - neighbor the path is learned from or advertised to, empty for local paths
+	Peer string `json:"peer"`
+	// AsPath - AS path of the path
+	AsPath []uint32 `json:"asPath"`
+	// Communities - communities of the path
+	Communities []string `json:"communities"`
+	// Med - multi exit discriminator
+	Med uint32 `json:"med"`
+	// LocalPref - local preference
+	LocalPref uint32 `json:"localPref"`
+	// Age - time since the path was received or advertised
+	Age string `json:"age"`
+	// Best - the path is the best path of the prefix
+	Best bool `json:"best"`
+}
+
+// AsPathString renders the AS path as "65001 65002"
+func (r BGPRouteEntry) AsPathString() string {
+	var path []string
+	for _, as := range r.AsPath {
+		path = append(path, fmt.Sprintf("%d", as))
+	}
+	return strings.Join(path, " ")
+}
+
+func (r BGPRouteEntry) Key() string {
+	return fmt.Sprintf("%s|%s|%s", r.Prefix, r.Peer, r.NextHop)
+}
+
+func (routeresp BGPRouteGet) Sort() {
+	sort.Slice(routeresp.BGPRouteAttr, func(i, j int) bool {
+		return routeresp.BGPRouteAttr[i].Key() < routeresp.BGPRouteAttr[j].Key()
+	})
+}
@@ -41,8 +41,11 @@ const (
 	loxiEndPointResource        = "config/endpoint"
 	loxiParamResource           = "config/params"
 	loxiBGPNeighResource        = "config/bgp/neigh"
+	loxiBGPRouteResource        = "config/bgp/routes"
+	loxiBGPPolicyResource       = "config/bgp/policy"
 	loxiStatusResource          = "status"
 	loxiBFDSessionResource      = "config/bfd"
+	loxiHAStateResource         = "config/cistate"
 	loxiVersionResource         = "version"
 	loxiLoginResource           = "auth/login"
 )
@@ -56,7 +59,8 @@ func NewLoxiClient(o *RESTOptions) *LoxiClient {
 		restClient: RESTClient{
 			Options: *o,
 			Client: &http.Client{
-				Timeout: time.Second * time.Duration(o.Timeout),
+				Timeout:   time.Second * time.Duration(o.Timeout),
+				Transport: o.Transport,
 			},
 		},
 	}
@@ -309,6 +313,32 @@ func (l *LoxiClient) BGPNeighbor() *BGPNeighbor {
 	}
 }
 
+func (l *LoxiClient) BGPRoute() *BGPRoute {
+	return &BGPRoute{
+		CommonAPI: CommonAPI{
+			restClient: &l.restClient,
+			requestInfo: RequestInfo{
+				provider:   loxiProvider,
+				apiVersion: loxiApiVersion,
+				resource:   loxiBGPRouteResource,
+			},
+		},
+	}
+}
+
+func (l *LoxiClient) BGPPolicy() *BGPPolicy {
+	return &BGPPolicy{
+		CommonAPI: CommonAPI{
+			restClient: &l.restClient,
+			requestInfo: RequestInfo{
+				provider:   loxiProvider,
+				apiVersion: loxiApiVersion,
+				resource:   loxiBGPPolicyResource,
+			},
+		},
+	}
+}
+
 func (l *LoxiClient) BFDSession() *BFDSession {
 	return &BFDSession{
 		CommonAPI: CommonAPI{
@@ -321,6 +351,19 @@ func (l *LoxiClient) BFDSession() *BFDSession {
 		},
 	}
 }
+func (l *LoxiClient) HAState() *HAState {
+	return &HAState{
+		CommonAPI: CommonAPI{
+			restClient: &l.restClient,
+			requestInfo: RequestInfo{
+				provider:   loxiProvider,
+				apiVersion: loxiApiVersion,
+				resource:   loxiHAStateResource,
+			},
+		},
+	}
+}
+
 func (l *LoxiClient) LBVersion() *LBVersion {
 	return &LBVersion{
 		CommonAPI: CommonAPI{
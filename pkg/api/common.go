@@ -18,6 +18,8 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 )
@@ -54,6 +56,15 @@ func (l *CommonAPI) Create(ctx context.Context, modelbody interface{}) (*http.Re
 	return l.restClient.POST(ctx, createURL, body)
 }
 
+func (l *CommonAPI) Update(ctx context.Context, modelbody interface{}) (*http.Response, error) {
+	body, err := json.Marshal(modelbody)
+	if err != nil {
+		return nil, err
+	}
+	updateURL := l.GetUrlString()
+	return l.restClient.PUT(ctx, updateURL, body)
+}
+
 func (l *CommonAPI) Delete(ctx context.Context) (*http.Response, error) {
 	deleteURL := l.GetUrlString()
 	return l.restClient.DELETE(ctx, deleteURL)
@@ -78,3 +89,120 @@ func (l *CommonAPI) SetUrl(url string) *CommonAPI {
 	l.requestInfo.resource = url
 	return l
 }
+
+// DecodeList decodes the JSON array stored under key of the object read from r
+// and calls fn for each element as soon as it is decoded, so the whole list
+// is never held in memory. Other keys of the object are skipped.
+func DecodeList[T any](r io.Reader, key string, fn func(T) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if k, ok := tok.(string); !ok || k != key {
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		tok, err = dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok == nil {
+			// null list
+			continue
+		}
+		if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+			return fmt.Errorf("expected array for '%s' but got %v", key, tok)
+		}
+		for dec.More() {
+			var v T
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+			if err := fn(v); err != nil {
+				return err
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, '}')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != want {
+		return fmt.Errorf("expected '%v' but got %v", want, tok)
+	}
+	return nil
+}
+
+// ListWriter writes {"<key>":[...]} to w one element at a time.
+type ListWriter struct {
+	w      io.Writer
+	key    string
+	indent bool
+	count  int
+}
+
+func NewListWriter(w io.Writer, key string, indent bool) *ListWriter {
+	return &ListWriter{w: w, key: key, indent: indent}
+}
+
+// Write appends one element to the list.
+func (l *ListWriter) Write(v interface{}) error {
+	var elem []byte
+	var err error
+	if l.indent {
+		elem, err = json.MarshalIndent(v, "        ", "    ")
+	} else {
+		elem, err = json.Marshal(v)
+	}
+	if err != nil {
+		return err
+	}
+	prefix := ","
+	if l.count == 0 {
+		prefix = fmt.Sprintf("{\"%s\":[", l.key)
+		if l.indent {
+			prefix = fmt.Sprintf("{\n    \"%s\": [", l.key)
+		}
+	}
+	if l.indent {
+		prefix += "\n        "
+	}
+	l.count++
+	if _, err := io.WriteString(l.w, prefix); err != nil {
+		return err
+	}
+	_, err = l.w.Write(elem)
+	return err
+}
+
+// Close terminates the list. It must be called even if nothing was written.
+func (l *ListWriter) Close() error {
+	var end string
+	switch {
+	case l.count == 0 && l.indent:
+		end = fmt.Sprintf("{\n    \"%s\": []\n}\n", l.key)
+	case l.count == 0:
+		end = fmt.Sprintf("{\"%s\":[]}", l.key)
+	case l.indent:
+		end = "\n    ]\n}\n"
+	default:
+		end = "]}"
+	}
+	_, err := io.WriteString(l.w, end)
+	return err
+}
@@ -0,0 +1,196 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const benchListSize = 1000000
+
+var (
+	benchBodyOnce sync.Once
+	benchBody     []byte
+)
+
+func makeCtInfo(i int) ConntrackInformation {
+	return ConntrackInformation{
+		Dip:      fmt.Sprintf("10.%d.%d.%d", i>>16&0xff, i>>8&0xff, i&0xff),
+		Sip:      "192.168.0.1",
+		Dport:    80,
+		Sport:    uint16(i),
+		Proto:    "tcp",
+		CState:   "est",
+		CAct:     "fdnat-1",
+		Pkts:     uint64(i),
+		Bytes:    uint64(i) * 64,
+		ServName: "k8s-web",
+	}
+}
+
+// ctListBody returns a {"ctAttr":[...]} body with benchListSize entries.
+func ctListBody(b *testing.B) []byte {
+	benchBodyOnce.Do(func() {
+		var buf bytes.Buffer
+		lw := NewListWriter(&buf, "ctAttr", false)
+		for i := 0; i < benchListSize; i++ {
+			if err := lw.Write(makeCtInfo(i)); err != nil {
+				b.Fatal(err)
+			}
+		}
+		if err := lw.Close(); err != nil {
+			b.Fatal(err)
+		}
+		benchBody = buf.Bytes()
+	})
+	return benchBody
+}
+
+func TestDecodeList(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		want  []string
+		isErr bool
+	}{
+		{"list", `{"ctAttr":[{"destinationIP":"10.0.0.1"},{"destinationIP":"10.0.0.2"}]}`, []string{"10.0.0.1", "10.0.0.2"}, false},
+		{"other keys skipped", `{"count":2,"ctAttr":[{"destinationIP":"10.0.0.1"}],"extra":{"a":[1]}}`, []string{"10.0.0.1"}, false},
+		{"null list", `{"ctAttr":null}`, nil, false},
+		{"missing key", `{}`, nil, false},
+		{"not an array", `{"ctAttr":{}}`, nil, true},
+		{"not an object", `[]`, nil, true},
+		{"truncated", `{"ctAttr":[{"destinationIP":"10.0.0.1"}`, []string{"10.0.0.1"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			err := DecodeList(strings.NewReader(tt.body), "ctAttr", func(ct ConntrackInformation) error {
+				got = append(got, ct.Dip)
+				return nil
+			})
+			if (err != nil) != tt.isErr {
+				t.Fatalf("err = %v, want error %v", err, tt.isErr)
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListWriter(t *testing.T) {
+	for _, indent := range []bool{false, true} {
+		for _, n := range []int{0, 1, 3} {
+			var buf bytes.Buffer
+			lw := NewListWriter(&buf, "ctAttr", indent)
+			for i := 0; i < n; i++ {
+				if err := lw.Write(makeCtInfo(i)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := lw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			var resp CtInformationGet
+			if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+				t.Fatalf("indent=%v n=%d: invalid JSON %q: %v", indent, n, buf.String(), err)
+			}
+			if len(resp.CtInfo) != n {
+				t.Errorf("indent=%v: got %d entries, want %d", indent, len(resp.CtInfo), n)
+			}
+		}
+	}
+}
+
+func BenchmarkDecodeList(b *testing.B) {
+	body := ctListBody(b)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		n := 0
+		err := DecodeList(bytes.NewReader(body), "ctAttr", func(ct ConntrackInformation) error {
+			n++
+			return nil
+		})
+		if err != nil || n != benchListSize {
+			b.Fatalf("decoded %d entries: %v", n, err)
+		}
+	}
+}
+
+// BenchmarkUnmarshalList is the whole-body decode DecodeList replaced.
+func BenchmarkUnmarshalList(b *testing.B) {
+	body := ctListBody(b)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var resp CtInformationGet
+		if err := json.NewDecoder(bytes.NewReader(body)).Decode(&resp); err != nil {
+			b.Fatal(err)
+		}
+		if len(resp.CtInfo) != benchListSize {
+			b.Fatalf("decoded %d entries", len(resp.CtInfo))
+		}
+	}
+}
+
+func BenchmarkListWriter(b *testing.B) {
+	for _, indent := range []bool{false, true} {
+		b.Run(fmt.Sprintf("indent=%v", indent), func(b *testing.B) {
+			ct := makeCtInfo(1)
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				lw := NewListWriter(io.Discard, "ctAttr", indent)
+				for j := 0; j < benchListSize; j++ {
+					if err := lw.Write(ct); err != nil {
+						b.Fatal(err)
+					}
+				}
+				if err := lw.Close(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkDecodeListWriter is get ct --stream -o json: decode and re-encode.
+func BenchmarkDecodeListWriter(b *testing.B) {
+	body := ctListBody(b)
+	b.SetBytes(int64(len(body)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		lw := NewListWriter(io.Discard, "ctAttr", true)
+		err := DecodeList(bytes.NewReader(body), "ctAttr", func(ct ConntrackInformation) error {
+			return lw.Write(ct)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if err := lw.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -17,7 +17,10 @@ package api
 
 import (
 	"fmt"
+	"net"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type Conntrack struct {
@@ -51,3 +54,227 @@ func (ctresp CtInformationGet) Sort() {
 		return ctresp.CtInfo[i].Key() < ctresp.CtInfo[j].Key()
 	})
 }
+
+// EndpointIP returns the endpoint address the connection is translated to
+// by the conntrack action (ex) "dnat-10.10.10.1:8080:w1" or an empty string.
+func (ct ConntrackInformation) EndpointIP() string {
+	idx := strings.Index(ct.CAct, "-")
+	if idx < 0 {
+		return ""
+	}
+	act := ct.CAct[idx+1:]
+	if i := strings.IndexAny(act, ", "); i >= 0 {
+		act = act[:i]
+	}
+	act = strings.Trim(act, "[]")
+	hasPort := false
+	if i := strings.LastIndex(act, ":w"); i >= 0 {
+		act = act[:i]
+		hasPort = true
+	}
+	if ip := net.ParseIP(act); ip != nil && !hasPort {
+		return ip.String()
+	}
+	if i := strings.LastIndex(act, ":"); i >= 0 {
+		if ip := net.ParseIP(strings.Trim(act[:i], "[]")); ip != nil {
+			return ip.String()
+		}
+	}
+	return ""
+}
+
+// IsActive returns false for connections which are already being torn down.
+func (ct ConntrackInformation) IsActive() bool {
+	state := strings.ToLower(ct.CState)
+	return !strings.HasPrefix(state, "closed") && !strings.HasPrefix(state, "fin") &&
+		!strings.HasPrefix(state, "time-wait")
+}
+
+// CtFilter - client-side filter for conntrack entries. Empty fields match all.
+type CtFilter struct {
+	ServName string
+	Src      *net.IPNet
+	Dst      *net.IPNet
+	SPort    uint16
+	DPort    uint16
+	Port     uint16
+	Proto    string
+	State    string
+}
+
+// ParseCtPrefix parses an IP address or CIDR into an IPNet.
+func ParseCtPrefix(prefix string) (*net.IPNet, error) {
+	if !strings.Contains(prefix, "/") {
+		ip := net.ParseIP(prefix)
+		if ip == nil {
+			return nil, fmt.Errorf("'%s' is not a valid IP or CIDR", prefix)
+		}
+		if ip.To4() != nil {
+			prefix += "/32"
+		} else {
+			prefix += "/128"
+		}
+	}
+	_, ipNet, err := net.ParseCIDR(prefix)
+	if err != nil {
+		return nil, fmt.Errorf("'%s' is not a valid IP or CIDR", prefix)
+	}
+	return ipNet, nil
+}
+
+// Match returns true when the entry passes every set field of the filter.
+func (f CtFilter) Match(ct ConntrackInformation) bool {
+	if f.ServName != "" && f.ServName != ct.ServName {
+		return false
+	}
+	if f.Src != nil && !f.Src.Contains(net.ParseIP(ct.Sip)) {
+		return false
+	}
+	if f.Dst != nil && !f.Dst.Contains(net.ParseIP(ct.Dip)) {
+		return false
+	}
+	if f.SPort != 0 && f.SPort != ct.Sport {
+		return false
+	}
+	if f.DPort != 0 && f.DPort != ct.Dport {
+		return false
+	}
+	if f.Port != 0 && f.Port != ct.Sport && f.Port != ct.Dport {
+		return false
+	}
+	if f.Proto != "" && !strings.EqualFold(f.Proto, ct.Proto) {
+		return false
+	}
+	if f.State != "" && !strings.EqualFold(f.State, ct.CState) {
+		return false
+	}
+	return true
+}
+
+// Filter returns the entries selected by f.
+func (ctresp CtInformationGet) Filter(f CtFilter) CtInformationGet {
+	fresp := CtInformationGet{}
+	for _, ct := range ctresp.CtInfo {
+		if f.Match(ct) {
+			fresp.CtInfo = append(fresp.CtInfo, ct)
+		}
+	}
+	return fresp
+}
+
+// SortBy sorts the entries by "bytes" or "packets" in descending order.
+// Any other field sorts by Key.
+func (ctresp CtInformationGet) SortBy(field string) {
+	switch field {
+	case "bytes":
+		sort.SliceStable(ctresp.CtInfo, func(i, j int) bool {
+			return ctresp.CtInfo[i].Bytes > ctresp.CtInfo[j].Bytes
+		})
+	case "packets":
+		sort.SliceStable(ctresp.CtInfo, func(i, j int) bool {
+			return ctresp.CtInfo[i].Pkts > ctresp.CtInfo[j].Pkts
+		})
+	default:
+		ctresp.Sort()
+	}
+}
+
+// CtAggregate - conntrack entries summed up by a key
+type CtAggregate struct {
+	Key   string `json:"key"`
+	Flows uint64 `json:"flows"`
+	Pkts  uint64 `json:"packets"`
+	Bytes uint64 `json:"bytes"`
+}
+
+// CtAggregateKeys - supported keys of Aggregate
+var CtAggregateKeys = []string{"service", "endpoint", "state", "src", "dst", "proto"}
+
+// CtAggregator sums up flows, packets and bytes of conntrack entries by a key
+// without keeping the entries themselves.
+type CtAggregator struct {
+	key    string
+	aggMap map[string]*CtAggregate
+}
+
+// NewCtAggregator returns an aggregator for one of CtAggregateKeys.
+func NewCtAggregator(key string) (*CtAggregator, error) {
+	valid := false
+	for _, k := range CtAggregateKeys {
+		if k == key {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return nil, fmt.Errorf("aggregate key '%s' is not supported (%s)", key, strings.Join(CtAggregateKeys, ", "))
+	}
+	return &CtAggregator{key: key, aggMap: map[string]*CtAggregate{}}, nil
+}
+
+// Add accounts one conntrack entry.
+func (a *CtAggregator) Add(ct ConntrackInformation) {
+	var k string
+	switch a.key {
+	case "service":
+		k = ct.ServName
+	case "endpoint":
+		k = ct.EndpointIP()
+	case "state":
+		k = ct.CState
+	case "src":
+		k = ct.Sip
+	case "dst":
+		k = ct.Dip + ":" + strconv.Itoa(int(ct.Dport))
+	case "proto":
+		k = ct.Proto
+	}
+	if k == "" {
+		k = "-"
+	}
+	agg, ok := a.aggMap[k]
+	if !ok {
+		agg = &CtAggregate{Key: k}
+		a.aggMap[k] = agg
+	}
+	agg.Flows++
+	agg.Pkts += ct.Pkts
+	agg.Bytes += ct.Bytes
+}
+
+// Result returns the aggregates sorted by "flows", "bytes" or "packets" in descending order.
+func (a *CtAggregator) Result(sortBy string) []CtAggregate {
+	aggs := make([]CtAggregate, 0, len(a.aggMap))
+	for _, agg := range a.aggMap {
+		aggs = append(aggs, *agg)
+	}
+	sort.Slice(aggs, func(i, j int) bool {
+		var x, y uint64
+		switch sortBy {
+		case "bytes":
+			x, y = aggs[i].Bytes, aggs[j].Bytes
+		case "packets":
+			x, y = aggs[i].Pkts, aggs[j].Pkts
+		default:
+			x, y = aggs[i].Flows, aggs[j].Flows
+		}
+		if x != y {
+			return x > y
+		}
+		return aggs[i].Key < aggs[j].Key
+	})
+	return aggs
+}
+
+// Aggregate sums up flows, packets and bytes by one of CtAggregateKeys
+// and returns them sorted by "flows", "bytes" or "packets" in descending order.
+func (ctresp CtInformationGet) Aggregate(key string, sortBy string) ([]CtAggregate, error) {
+	agg, err := NewCtAggregator(key)
+	if err != nil {
+		return nil, err
+	}
+	for _, ct := range ctresp.CtInfo {
+		agg.Add(ct)
+	}
+	return agg.Result(sortBy), nil
+}
@@ -16,8 +16,12 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type Firewall struct {
@@ -25,7 +29,7 @@ type Firewall struct {
 }
 
 type FWInformationGet struct {
-	FWInfo []FwRuleMod `json:"fwAttr"`
+	FWInfo []FwRuleMod `json:"fwAttr" yaml:"fwAttr"`
 }
 
 // FwRuleOpts - Information related to Firewall options
@@ -43,13 +47,16 @@ type FwOptArg struct {
 	// Record - Record packets matching rule
 	Record bool `json:"record" yaml:"record"`
 	// DoSNAT - Do snat on matching rule
-	DoSnat bool   `json:"doSnat"`
-	ToIP   string `json:"toIP"`
-	ToPort uint16 `json:"toPort"`
+	DoSnat bool   `json:"doSnat" yaml:"doSnat"`
+	ToIP   string `json:"toIP" yaml:"toIP"`
+	ToPort uint16 `json:"toPort" yaml:"toPort"`
 	// OnDefault - Trigger only on default cases
-	OnDefault bool `json:"onDefault"`
+	OnDefault bool `json:"onDefault" yaml:"onDefault"`
 	// Counter - Traffic counter
-	Counter string `json:"counter"`
+	Counter string `json:"counter" yaml:"counter,omitempty"`
+	// Packets and Bytes are parsed from Counter
+	Packets uint64 `json:"packets,omitempty" yaml:"packets,omitempty"`
+	Bytes   uint64 `json:"bytes,omitempty" yaml:"bytes,omitempty"`
 }
 
 // FwRuleArg - Information related to firewall rule
@@ -88,6 +95,15 @@ type ConfigurationFWFile struct {
 	Spec       FwRuleMod `yaml:"spec"`
 }
 
+func (opts *FwOptArg) UnmarshalJSON(b []byte) error {
+	type plain FwOptArg
+	if err := json.Unmarshal(b, (*plain)(opts)); err != nil {
+		return err
+	}
+	opts.Packets, opts.Bytes, _ = ParseCounter(opts.Counter)
+	return nil
+}
+
 func (fw FwRuleArg) Key() string {
 	return fmt.Sprintf("%s|%s|%05d|%05d|%05d|%05d|%d",
 		fw.SrcIP, fw.DstIP, fw.SrcPortMin, fw.SrcPortMax,
@@ -99,3 +115,359 @@ func (fwresp FWInformationGet) Sort() {
 		return fwresp.FWInfo[i].Rule.Key() < fwresp.FWInfo[j].Rule.Key()
 	})
 }
+
+// SortBy sorts the rules by "bytes" or "packets" in descending order.
+// Any other field sorts by Key.
+func (fwresp FWInformationGet) SortBy(field string) {
+	switch field {
+	case "bytes":
+		sort.SliceStable(fwresp.FWInfo, func(i, j int) bool {
+			return fwresp.FWInfo[i].Opts.Bytes > fwresp.FWInfo[j].Opts.Bytes
+		})
+	case "packets":
+		sort.SliceStable(fwresp.FWInfo, func(i, j int) bool {
+			return fwresp.FWInfo[i].Opts.Packets > fwresp.FWInfo[j].Opts.Packets
+		})
+	default:
+		fwresp.Sort()
+	}
+}
+
+var fwProtoNames = []struct {
+	name  string
+	proto uint8
+}{
+	{"icmp", 1}, {"tcp", 6}, {"udp", 17}, {"icmpv6", 58}, {"sctp", 132},
+}
+
+// ParseFwProto converts a protocol name or number to the protocol number.
+// "any" is 0.
+func ParseFwProto(proto string) (uint8, error) {
+	proto = strings.ToLower(proto)
+	if proto == "any" {
+		return 0, nil
+	}
+	for _, p := range fwProtoNames {
+		if p.name == proto {
+			return p.proto, nil
+		}
+	}
+	num, err := strconv.ParseUint(proto, 10, 8)
+	if err != nil {
+		return 0, fmt.Errorf("protocol '%s' is not supported (tcp, udp, icmp, icmpv6, sctp, any or a number)", proto)
+	}
+	return uint8(num), nil
+}
+
+// FwProtoToString converts a protocol number to its name when it has one.
+func FwProtoToString(proto uint8) string {
+	if proto == 0 {
+		return "any"
+	}
+	for _, p := range fwProtoNames {
+		if p.proto == proto {
+			return p.name
+		}
+	}
+	return strconv.Itoa(int(proto))
+}
+
+// parseFwPrefix validates an IP or CIDR and returns it in CIDR notation.
+func parseFwPrefix(prefix string) (string, error) {
+	if !strings.Contains(prefix, "/") {
+		ip := net.ParseIP(prefix)
+		if ip == nil {
+			return "", fmt.Errorf("'%s' is not a valid IP or CIDR", prefix)
+		}
+		if ip.To4() != nil {
+			prefix += "/32"
+		} else {
+			prefix += "/128"
+		}
+	}
+	if _, _, err := net.ParseCIDR(prefix); err != nil {
+		return "", fmt.Errorf("'%s' is not a valid IP or CIDR", prefix)
+	}
+	return prefix, nil
+}
+
+// parseFwPortRange parses "port" or "min-max".
+func parseFwPortRange(ports string) (uint16, uint16, error) {
+	minStr, maxStr, isRange := strings.Cut(ports, "-")
+	min, err := strconv.ParseUint(minStr, 10, 16)
+	if err != nil {
+		return 0, 0, fmt.Errorf("port '%s' is not valid", ports)
+	}
+	max := min
+	if isRange {
+		max, err = strconv.ParseUint(maxStr, 10, 16)
+		if err != nil {
+			return 0, 0, fmt.Errorf("port '%s' is not valid", ports)
+		}
+	}
+	if min > max {
+		return 0, 0, fmt.Errorf("port range '%s' is reversed", ports)
+	}
+	return uint16(min), uint16(max), nil
+}
+
+// ParseFwMatch parses a match expression of "<field> <value>" pairs:
+//
+//	src <ip|cidr>  dst <ip|cidr>  sport <port|min-max>  dport <port|min-max>
+//	proto <name|number>  in <port name>
+//
+// ex) src 10.0.0.0/8 dst 1.2.3.4 dport 80-90 proto tcp in eth0
+func ParseFwMatch(expr string) (FwRuleArg, error) {
+	rule := FwRuleArg{}
+	fields := strings.Fields(expr)
+	if len(fields)%2 != 0 {
+		return rule, fmt.Errorf("match '%s' needs '<field> <value>' pairs", expr)
+	}
+	seen := map[string]bool{}
+	for i := 0; i < len(fields); i += 2 {
+		key, value := strings.ToLower(fields[i]), fields[i+1]
+		if seen[key] {
+			return rule, fmt.Errorf("match field '%s' is given twice", key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "src":
+			rule.SrcIP, err = parseFwPrefix(value)
+		case "dst":
+			rule.DstIP, err = parseFwPrefix(value)
+		case "sport":
+			rule.SrcPortMin, rule.SrcPortMax, err = parseFwPortRange(value)
+		case "dport":
+			rule.DstPortMin, rule.DstPortMax, err = parseFwPortRange(value)
+		case "proto":
+			rule.Proto, err = ParseFwProto(value)
+		case "in":
+			rule.InPort = value
+		default:
+			err = fmt.Errorf("match field '%s' is not supported (src, dst, sport, dport, proto, in)", key)
+		}
+		if err != nil {
+			return rule, err
+		}
+	}
+	return rule, rule.Validation()
+}
+
+// Validation checks the CIDRs, port ranges and protocol of the rule
+func (fw FwRuleArg) Validation() error {
+	var srcNet, dstNet *net.IPNet
+	var err error
+	if fw.SrcIP != "" {
+		if _, srcNet, err = net.ParseCIDR(fw.SrcIP); err != nil {
+			return fmt.Errorf("sourceIP '%s' is not a valid CIDR", fw.SrcIP)
+		}
+	}
+	if fw.DstIP != "" {
+		if _, dstNet, err = net.ParseCIDR(fw.DstIP); err != nil {
+			return fmt.Errorf("destinationIP '%s' is not a valid CIDR", fw.DstIP)
+		}
+	}
+	// A zero-length prefix matches any address, so it is family neutral
+	if srcNet != nil && dstNet != nil && !isFwAnyNet(srcNet) && !isFwAnyNet(dstNet) &&
+		(srcNet.IP.To4() == nil) != (dstNet.IP.To4() == nil) {
+		return fmt.Errorf("src %s and dst %s are not the same IP family", fw.SrcIP, fw.DstIP)
+	}
+	if fw.SrcPortMin > fw.SrcPortMax {
+		return fmt.Errorf("source port range %d-%d is reversed", fw.SrcPortMin, fw.SrcPortMax)
+	}
+	if fw.DstPortMin > fw.DstPortMax {
+		return fmt.Errorf("destination port range %d-%d is reversed", fw.DstPortMin, fw.DstPortMax)
+	}
+	hasPorts := fw.SrcPortMax != 0 || fw.DstPortMax != 0
+	if hasPorts && fw.Proto != 0 && fw.Proto != 6 && fw.Proto != 17 && fw.Proto != 132 {
+		return fmt.Errorf("ports can't be matched with protocol %s", FwProtoToString(fw.Proto))
+	}
+	return nil
+}
+
+func isFwAnyNet(ipNet *net.IPNet) bool {
+	ones, _ := ipNet.Mask.Size()
+	return ones == 0
+}
+
+func fwPortRangeString(min, max uint16) string {
+	if min == max {
+		return strconv.Itoa(int(min))
+	}
+	return fmt.Sprintf("%d-%d", min, max)
+}
+
+// MatchString renders the rule as a match expression accepted by ParseFwMatch.
+func (fw FwRuleArg) MatchString() string {
+	var m []string
+	if fw.SrcIP != "" {
+		m = append(m, "src", fw.SrcIP)
+	}
+	if fw.DstIP != "" {
+		m = append(m, "dst", fw.DstIP)
+	}
+	if fw.SrcPortMin != 0 || fw.SrcPortMax != 0 {
+		m = append(m, "sport", fwPortRangeString(fw.SrcPortMin, fw.SrcPortMax))
+	}
+	if fw.DstPortMin != 0 || fw.DstPortMax != 0 {
+		m = append(m, "dport", fwPortRangeString(fw.DstPortMin, fw.DstPortMax))
+	}
+	if fw.Proto != 0 {
+		m = append(m, "proto", FwProtoToString(fw.Proto))
+	}
+	if fw.InPort != "" {
+		m = append(m, "in", fw.InPort)
+	}
+	return strings.Join(m, " ")
+}
+
+// SortByPref sorts the rules in the evaluation order of loxilb:
+// a higher preference is evaluated first, ties are ordered by Key.
+func (fwresp FWInformationGet) SortByPref() {
+	sort.SliceStable(fwresp.FWInfo, func(i, j int) bool {
+		ri, rj := fwresp.FWInfo[i].Rule, fwresp.FWInfo[j].Rule
+		if ri.Pref != rj.Pref {
+			return ri.Pref > rj.Pref
+		}
+		return ri.Key() < rj.Key()
+	})
+}
+
+// fwNet returns the network of a CIDR, or nil when it matches any address.
+func fwNet(cidr string) *net.IPNet {
+	if cidr == "" {
+		return nil
+	}
+	_, ipNet, err := net.ParseCIDR(cidr)
+	if err != nil || isFwAnyNet(ipNet) {
+		return nil
+	}
+	return ipNet
+}
+
+func fwNetCovers(a, b *net.IPNet) bool {
+	if a == nil {
+		return true
+	}
+	if b == nil || (a.IP.To4() == nil) != (b.IP.To4() == nil) {
+		return false
+	}
+	aOnes, _ := a.Mask.Size()
+	bOnes, _ := b.Mask.Size()
+	return aOnes <= bOnes && a.Contains(b.IP)
+}
+
+func fwNetOverlaps(a, b *net.IPNet) bool {
+	if a == nil || b == nil {
+		return true
+	}
+	return fwNetCovers(a, b) || fwNetCovers(b, a)
+}
+
+// A port range of 0-0 matches any port
+func fwPortCovers(aMin, aMax, bMin, bMax uint16) bool {
+	if aMax == 0 {
+		return true
+	}
+	return bMax != 0 && aMin <= bMin && bMax <= aMax
+}
+
+func fwPortOverlaps(aMin, aMax, bMin, bMax uint16) bool {
+	if aMax == 0 || bMax == 0 {
+		return true
+	}
+	return aMin <= bMax && bMin <= aMax
+}
+
+// fwFamily returns 4 or 6 when the rule only matches that IP family, or 0
+func (fw FwRuleArg) fwFamily() int {
+	for _, ipNet := range []*net.IPNet{fwNet(fw.SrcIP), fwNet(fw.DstIP)} {
+		if ipNet != nil {
+			if ipNet.IP.To4() != nil {
+				return 4
+			}
+			return 6
+		}
+	}
+	return 0
+}
+
+// Covers reports whether every packet matching other also matches fw.
+func (fw FwRuleArg) Covers(other FwRuleArg) bool {
+	if family := fw.fwFamily(); family != 0 && family != other.fwFamily() {
+		return false
+	}
+	return fwNetCovers(fwNet(fw.SrcIP), fwNet(other.SrcIP)) &&
+		fwNetCovers(fwNet(fw.DstIP), fwNet(other.DstIP)) &&
+		fwPortCovers(fw.SrcPortMin, fw.SrcPortMax, other.SrcPortMin, other.SrcPortMax) &&
+		fwPortCovers(fw.DstPortMin, fw.DstPortMax, other.DstPortMin, other.DstPortMax) &&
+		(fw.Proto == 0 || fw.Proto == other.Proto) &&
+		(fw.InPort == "" || fw.InPort == other.InPort)
+}
+
+// Overlaps reports whether some packet can match both fw and other.
+func (fw FwRuleArg) Overlaps(other FwRuleArg) bool {
+	if a, b := fw.fwFamily(), other.fwFamily(); a != 0 && b != 0 && a != b {
+		return false
+	}
+	return fwNetOverlaps(fwNet(fw.SrcIP), fwNet(other.SrcIP)) &&
+		fwNetOverlaps(fwNet(fw.DstIP), fwNet(other.DstIP)) &&
+		fwPortOverlaps(fw.SrcPortMin, fw.SrcPortMax, other.SrcPortMin, other.SrcPortMax) &&
+		fwPortOverlaps(fw.DstPortMin, fw.DstPortMax, other.DstPortMin, other.DstPortMax) &&
+		(fw.Proto == 0 || other.Proto == 0 || fw.Proto == other.Proto) &&
+		(fw.InPort == "" || other.InPort == "" || fw.InPort == other.InPort)
+}
+
+// SameAction reports whether both options do the same thing, ignoring counters.
+func (opts FwOptArg) SameAction(other FwOptArg) bool {
+	opts.Counter, opts.Packets, opts.Bytes = "", 0, 0
+	other.Counter, other.Packets, other.Bytes = "", 0, 0
+	return opts == other
+}
+
+// Normalize returns the match fields of the rule with canonical CIDRs, where a
+// zero-length prefix is left empty as both mean any address. The preference is
+// cleared as LoxiLB identifies a rule by its match fields only.
+func (fw FwRuleArg) Normalize() FwRuleArg {
+	fw.Pref = 0
+	srcNet, dstNet := fwNet(fw.SrcIP), fwNet(fw.DstIP)
+	fw.SrcIP, fw.DstIP = "", ""
+	if srcNet != nil {
+		fw.SrcIP = srcNet.String()
+	}
+	if dstNet != nil {
+		fw.DstIP = dstNet.String()
+	}
+	return fw
+}
+
+// FwPacket - packet fields a firewall rule matches on
+type FwPacket struct {
+	SrcIP   net.IP
+	DstIP   net.IP
+	SrcPort uint16
+	DstPort uint16
+	Proto   uint8
+	InPort  string
+}
+
+func fwNetContains(cidr string, ip net.IP) bool {
+	ipNet := fwNet(cidr)
+	return ipNet == nil || (ip != nil && (ipNet.IP.To4() == nil) == (ip.To4() == nil) && ipNet.Contains(ip))
+}
+
+func fwPortContains(min, max, port uint16) bool {
+	return max == 0 || (min <= port && port <= max)
+}
+
+// Matches reports whether the packet matches the rule.
+// Empty or zero fields of the rule match anything.
+func (fw FwRuleArg) Matches(pkt FwPacket) bool {
+	return fwNetContains(fw.SrcIP, pkt.SrcIP) &&
+		fwNetContains(fw.DstIP, pkt.DstIP) &&
+		fwPortContains(fw.SrcPortMin, fw.SrcPortMax, pkt.SrcPort) &&
+		fwPortContains(fw.DstPortMin, fw.DstPortMax, pkt.DstPort) &&
+		(fw.Proto == 0 || fw.Proto == pkt.Proto) &&
+		(fw.InPort == "" || fw.InPort == pkt.InPort)
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package api
+
+import (
+	"net"
+	"testing"
+)
+
+func fwPkt(src, dst string, sport, dport uint16, proto uint8, in string) FwPacket {
+	return FwPacket{SrcIP: net.ParseIP(src), DstIP: net.ParseIP(dst), SrcPort: sport, DstPort: dport, Proto: proto, InPort: in}
+}
+
+func TestFwRuleArgMatches(t *testing.T) {
+	tests := []struct {
+		name string
+		rule FwRuleArg
+		pkt  FwPacket
+		want bool
+	}{
+		{"empty rule matches anything", FwRuleArg{}, fwPkt("1.1.1.1", "2.2.2.2", 1000, 80, 6, "eth0"), true},
+		{"any address matches v6", FwRuleArg{SrcIP: "0.0.0.0/0"}, fwPkt("2001:db8::1", "2001:db8::2", 0, 0, 58, ""), true},
+		{"src in cidr", FwRuleArg{SrcIP: "10.0.0.0/8"}, fwPkt("10.1.2.3", "2.2.2.2", 0, 0, 6, ""), true},
+		{"src out of cidr", FwRuleArg{SrcIP: "10.0.0.0/8"}, fwPkt("11.1.2.3", "2.2.2.2", 0, 0, 6, ""), false},
+		{"dst host", FwRuleArg{DstIP: "1.2.3.4/32"}, fwPkt("10.0.0.1", "1.2.3.4", 0, 0, 6, ""), true},
+		{"v4 rule against v6 packet", FwRuleArg{SrcIP: "10.0.0.0/8"}, fwPkt("2001:db8::10", "2001:db8::1", 0, 0, 6, ""), false},
+		{"v6 cidr", FwRuleArg{DstIP: "2001:db8::/32"}, fwPkt("2001:db9::1", "2001:db8:1::1", 0, 0, 6, ""), true},
+		{"dport at range start", FwRuleArg{DstPortMin: 80, DstPortMax: 90}, fwPkt("1.1.1.1", "2.2.2.2", 0, 80, 6, ""), true},
+		{"dport at range end", FwRuleArg{DstPortMin: 80, DstPortMax: 90}, fwPkt("1.1.1.1", "2.2.2.2", 0, 90, 6, ""), true},
+		{"dport past range", FwRuleArg{DstPortMin: 80, DstPortMax: 90}, fwPkt("1.1.1.1", "2.2.2.2", 0, 91, 6, ""), false},
+		{"sport range", FwRuleArg{SrcPortMin: 1024, SrcPortMax: 65535}, fwPkt("1.1.1.1", "2.2.2.2", 1023, 80, 6, ""), false},
+		{"any protocol", FwRuleArg{Proto: 0}, fwPkt("1.1.1.1", "2.2.2.2", 0, 0, 17, ""), true},
+		{"protocol differs", FwRuleArg{Proto: 6}, fwPkt("1.1.1.1", "2.2.2.2", 0, 0, 17, ""), false},
+		{"in port differs", FwRuleArg{InPort: "eth0"}, fwPkt("1.1.1.1", "2.2.2.2", 0, 0, 6, "eth1"), false},
+		{"all fields", FwRuleArg{SrcIP: "10.0.0.0/8", DstIP: "1.2.3.4/32", DstPortMin: 80, DstPortMax: 90, Proto: 6, InPort: "eth0"},
+			fwPkt("10.9.9.9", "1.2.3.4", 5000, 85, 6, "eth0"), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.Matches(tt.pkt); got != tt.want {
+				t.Errorf("%s Matches = %v, want %v", tt.rule.MatchString(), got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFwRuleArgCoversOverlaps(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     FwRuleArg
+		covers   bool // a covers b
+		overlaps bool
+	}{
+		{"any covers all", FwRuleArg{}, FwRuleArg{SrcIP: "10.0.0.0/8", Proto: 6, DstPortMin: 80, DstPortMax: 80}, true, true},
+		{"specific does not cover any", FwRuleArg{Proto: 6}, FwRuleArg{}, false, true},
+		{"wider cidr", FwRuleArg{SrcIP: "10.0.0.0/8"}, FwRuleArg{SrcIP: "10.1.0.0/16"}, true, true},
+		{"narrower cidr", FwRuleArg{SrcIP: "10.1.0.0/16"}, FwRuleArg{SrcIP: "10.0.0.0/8"}, false, true},
+		{"disjoint cidrs", FwRuleArg{SrcIP: "10.0.0.0/8"}, FwRuleArg{SrcIP: "11.0.0.0/8"}, false, false},
+		{"zero prefix is any", FwRuleArg{SrcIP: "0.0.0.0/0"}, FwRuleArg{SrcIP: "10.0.0.0/8"}, true, true},
+		{"different families", FwRuleArg{SrcIP: "10.0.0.0/8"}, FwRuleArg{SrcIP: "2001:db8::/32"}, false, false},
+		{"v4 rule against any-family rule", FwRuleArg{SrcIP: "10.0.0.0/8"}, FwRuleArg{Proto: 6}, false, true},
+		{"port range inside", FwRuleArg{DstPortMin: 80, DstPortMax: 90}, FwRuleArg{DstPortMin: 85, DstPortMax: 86}, true, true},
+		{"port ranges cross", FwRuleArg{DstPortMin: 80, DstPortMax: 90}, FwRuleArg{DstPortMin: 90, DstPortMax: 100}, false, true},
+		{"port ranges apart", FwRuleArg{DstPortMin: 80, DstPortMax: 90}, FwRuleArg{DstPortMin: 91, DstPortMax: 100}, false, false},
+		{"any port does not fit a range", FwRuleArg{DstPortMin: 80, DstPortMax: 90}, FwRuleArg{}, false, true},
+		{"any protocol", FwRuleArg{}, FwRuleArg{Proto: 17}, true, true},
+		{"other protocol", FwRuleArg{Proto: 6}, FwRuleArg{Proto: 17}, false, false},
+		{"other in port", FwRuleArg{InPort: "eth0"}, FwRuleArg{InPort: "eth1"}, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Covers(tt.b); got != tt.covers {
+				t.Errorf("Covers = %v, want %v", got, tt.covers)
+			}
+			if got := tt.a.Overlaps(tt.b); got != tt.overlaps {
+				t.Errorf("Overlaps = %v, want %v", got, tt.overlaps)
+			}
+			if got := tt.b.Overlaps(tt.a); got != tt.overlaps {
+				t.Errorf("reverse Overlaps = %v, want %v", got, tt.overlaps)
+			}
+		})
+	}
+}
+
+func TestFwRuleArgNormalize(t *testing.T) {
+	a := FwRuleArg{SrcIP: "10.1.2.3/8", DstIP: "0.0.0.0/0", Proto: 6, Pref: 100}
+	b := FwRuleArg{SrcIP: "10.0.0.0/8", Proto: 6, Pref: 200}
+	if a.Normalize() != b.Normalize() {
+		t.Errorf("Normalize %+v != %+v", a.Normalize(), b.Normalize())
+	}
+	c := FwRuleArg{SrcIP: "10.0.0.0/8", Proto: 17}
+	if a.Normalize() == c.Normalize() {
+		t.Errorf("Normalize %+v == %+v", a.Normalize(), c.Normalize())
+	}
+}
+
+func TestFWInformationGetSortByPref(t *testing.T) {
+	fwresp := FWInformationGet{FWInfo: []FwRuleMod{
+		{Rule: FwRuleArg{SrcIP: "10.0.0.0/8", Pref: 100}},
+		{Rule: FwRuleArg{SrcIP: "11.0.0.0/8", Pref: 300}},
+		{Rule: FwRuleArg{SrcIP: "12.0.0.0/8", Pref: 200}},
+		{Rule: FwRuleArg{SrcIP: "1.0.0.0/8", Pref: 200}},
+	}}
+	fwresp.SortByPref()
+	want := []string{"11.0.0.0/8", "1.0.0.0/8", "12.0.0.0/8", "10.0.0.0/8"}
+	for i, fwrule := range fwresp.FWInfo {
+		if fwrule.Rule.SrcIP != want[i] {
+			t.Fatalf("rule %d is %s, want %s", i, fwrule.Rule.SrcIP, want[i])
+		}
+	}
+}
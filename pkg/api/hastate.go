@@ -16,6 +16,8 @@
 package api
 
 import (
+	"fmt"
+	"net"
 	"sort"
 )
 
@@ -50,3 +52,53 @@ func (haState HAStateGet) Sort() {
 		return haState.HAStateAttr[i].Instance < haState.HAStateAttr[j].Instance
 	})
 }
+
+const (
+	HAStateMaster = "MASTER"
+	HAStateBackup = "BACKUP"
+)
+
+// HAStateMod - state of a cluster instance to set
+//
+// swagger:model CIStatusMod
+type HAStateMod struct {
+	// Instance name
+	Instance string `json:"instance"`
+
+	// State - MASTER or BACKUP
+	State string `json:"state"`
+
+	// Vip - Instance Virtual IP address
+	Vip string `json:"vip,omitempty"`
+}
+
+func (ha HAStateMod) Validation() error {
+	if ha.Instance == "" {
+		return fmt.Errorf("instance name is empty")
+	}
+	if ha.State != HAStateMaster && ha.State != HAStateBackup {
+		return fmt.Errorf("state '%s' is invalid. it should be %s or %s", ha.State, HAStateMaster, HAStateBackup)
+	}
+	if ha.Vip != "" && net.ParseIP(ha.Vip) == nil {
+		return fmt.Errorf("VIP '%s' is invalid format", ha.Vip)
+	}
+	return nil
+}
+
+// Find returns the state of the instance
+func (haState HAStateGet) Find(instance string) (HAStateInfo, bool) {
+	for _, ha := range haState.HAStateAttr {
+		if ha.Instance == instance {
+			return ha, true
+		}
+	}
+	return HAStateInfo{}, false
+}
+
+// SyncString returns the sync status or "-" when loxilb did not report it
+func (ha HAStateInfo) SyncString() string {
+	if ha.Sync == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%d", *ha.Sync)
+}
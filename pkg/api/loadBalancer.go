@@ -16,8 +16,13 @@
 package api
 
 import (
+	"encoding/json"
 	"fmt"
+	"net"
+	"regexp"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type LoadBalancer struct {
@@ -29,6 +34,56 @@ type LbMode int32
 type LbOP int32
 type LbSec int32
 
+const (
+	// LbSelRr - select the lb end-points based on round-robin
+	LbSelRr EpSelect = iota
+	// LbSelHash - select the lb end-points based on hashing
+	LbSelHash
+	// LbSelPrio - select the lb end-points based on weighted round-robin
+	LbSelPrio
+	// LbSelRrPersist - select the lb end-point based on sender
+	LbSelRrPersist
+	// LbSelLeastConnections - select the lb end-point based on least connection
+	LbSelLeastConnections
+	// LbSelN2 - select the lb end-point based on N2 interface params
+	LbSelN2
+	// LbSelN3 - select the lb end-point based on N3 interface params
+	LbSelN3
+)
+
+const (
+	// LBModeDefault - default NAT mode
+	LBModeDefault LbMode = iota
+	// LBModeOneArm - LB put LB-IP as srcIP
+	LBModeOneArm
+	// LBModeFullNAT - LB put Service IP as srcIP
+	LBModeFullNAT
+	// LBModeDSR - return traffic bypasses the load balancer
+	LBModeDSR
+	// LBModeFullProxy - LB operating as a L7 proxy
+	LBModeFullProxy
+	// LBModeHostOneArm - LB operating in host one-arm
+	LBModeHostOneArm
+)
+
+const (
+	// LbSecNone - no security
+	LbSecNone LbSec = iota
+	// LbSecHTTPS - https/tls termination
+	LbSecHTTPS
+	// LbSecE2EHTTPS - end-to-end https/tls
+	LbSecE2EHTTPS
+)
+
+const (
+	// LbOPAdd - add the rule or replace its endpoints
+	LbOPAdd LbOP = iota
+	// LbOPAttach - attach endpoints to an existing rule
+	LbOPAttach
+	// LbOPDetach - detach endpoints from an existing rule
+	LbOPDetach
+)
+
 type LbRuleModGet struct {
 	LbRules []LoadBalancerModel `json:"lbAttr"`
 }
@@ -67,6 +122,28 @@ type LoadBalancerEndpoint struct {
 	Weight     uint8  `json:"weight"     yaml:"weight"`
 	State      string `json:"state"      yaml:"state"`
 	Counter    string `json:"counter"    yaml:"counter"`
+	// Packets and Bytes are parsed from Counter
+	Packets uint64 `json:"packets,omitempty" yaml:"packets,omitempty"`
+	Bytes   uint64 `json:"bytes,omitempty"   yaml:"bytes,omitempty"`
+}
+
+// LbEndpointStats - traffic of one endpoint and its share of the service
+type LbEndpointStats struct {
+	EndpointIP  string  `json:"endpointIP"`
+	TargetPort  uint16  `json:"targetPort"`
+	Weight      uint8   `json:"weight"`
+	Packets     uint64  `json:"packets"`
+	Bytes       uint64  `json:"bytes"`
+	Share       float64 `json:"share"`
+	WeightShare float64 `json:"weightShare"`
+}
+
+// LbServiceStats - traffic of a load balancer rule summed up over its endpoints
+type LbServiceStats struct {
+	Service   LoadBalancerService `json:"serviceArguments"`
+	Packets   uint64              `json:"packets"`
+	Bytes     uint64              `json:"bytes"`
+	Endpoints []LbEndpointStats   `json:"endpoints"`
 }
 
 type LoadBalancerSecIp struct {
@@ -88,8 +165,523 @@ func (service LoadBalancerService) Key() string {
 	return fmt.Sprintf("%s|%05d|%s", service.ExternalIP, service.Port, service.Protocol)
 }
 
+// ParseCounter parses a "<packets>:<bytes>" traffic counter string.
+func ParseCounter(counter string) (uint64, uint64, error) {
+	counters := strings.Split(strings.TrimSpace(counter), ":")
+	if len(counters) != 2 {
+		return 0, 0, fmt.Errorf("counter '%s' is invalid format", counter)
+	}
+	pkts, err := strconv.ParseUint(counters[0], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("counter packets '%s' is not integer", counters[0])
+	}
+	bytes, err := strconv.ParseUint(counters[1], 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("counter bytes '%s' is not integer", counters[1])
+	}
+	return pkts, bytes, nil
+}
+
+func (ep *LoadBalancerEndpoint) UnmarshalJSON(b []byte) error {
+	type plain LoadBalancerEndpoint
+	if err := json.Unmarshal(b, (*plain)(ep)); err != nil {
+		return err
+	}
+	ep.Packets, ep.Bytes, _ = ParseCounter(ep.Counter)
+	return nil
+}
+
+// Key returns the identifier of the endpoint within its rule.
+func (ep LbEndpointStats) Key() string {
+	return fmt.Sprintf("%s|%05d", ep.EndpointIP, ep.TargetPort)
+}
+
+// Totals returns the packets and bytes of the rule summed up over its endpoints.
+func (lb LoadBalancerModel) Totals() (uint64, uint64) {
+	var pkts, bytes uint64
+	for _, ep := range lb.Endpoints {
+		pkts += ep.Packets
+		bytes += ep.Bytes
+	}
+	return pkts, bytes
+}
+
+// Stats returns the traffic of the rule and the share of each endpoint.
+// The share is computed on bytes, or on packets when no bytes were counted.
+func (lb LoadBalancerModel) Stats() LbServiceStats {
+	st := LbServiceStats{Service: lb.Service}
+	var weights uint64
+	for _, ep := range lb.Endpoints {
+		st.Endpoints = append(st.Endpoints, LbEndpointStats{
+			EndpointIP: ep.EndpointIP,
+			TargetPort: ep.TargetPort,
+			Weight:     ep.Weight,
+			Packets:    ep.Packets,
+			Bytes:      ep.Bytes,
+		})
+		weights += uint64(ep.Weight)
+	}
+	st.Packets, st.Bytes = lb.Totals()
+	st.computeShares(weights)
+	return st
+}
+
+// Sub turns st into the traffic since prev. Counters that went backwards are
+// taken as reset and kept as they are.
+func (st *LbServiceStats) Sub(prev LbServiceStats) {
+	old := map[string]LbEndpointStats{}
+	for _, ep := range prev.Endpoints {
+		old[ep.Key()] = ep
+	}
+	var weights uint64
+	st.Packets, st.Bytes = 0, 0
+	for i := range st.Endpoints {
+		ep := &st.Endpoints[i]
+		if o, ok := old[ep.Key()]; ok {
+			if ep.Packets >= o.Packets && ep.Bytes >= o.Bytes {
+				ep.Packets -= o.Packets
+				ep.Bytes -= o.Bytes
+			}
+		}
+		st.Packets += ep.Packets
+		st.Bytes += ep.Bytes
+		weights += uint64(ep.Weight)
+	}
+	st.computeShares(weights)
+}
+
+func (st *LbServiceStats) computeShares(weights uint64) {
+	for i := range st.Endpoints {
+		ep := &st.Endpoints[i]
+		switch {
+		case st.Bytes > 0:
+			ep.Share = float64(ep.Bytes) * 100 / float64(st.Bytes)
+		case st.Packets > 0:
+			ep.Share = float64(ep.Packets) * 100 / float64(st.Packets)
+		default:
+			ep.Share = 0
+		}
+		if weights > 0 {
+			ep.WeightShare = float64(ep.Weight) * 100 / float64(weights)
+		}
+	}
+}
+
+// SortBy sorts the rules by their total "bytes" or "packets" and the endpoints
+// of each rule likewise, in descending order. Any other field sorts by Key.
+func (lbresp LbRuleModGet) SortBy(field string) {
+	value := func(pkts, bytes uint64) uint64 {
+		if field == "packets" {
+			return pkts
+		}
+		return bytes
+	}
+	switch field {
+	case "bytes", "packets":
+		for _, lb := range lbresp.LbRules {
+			eps := lb.Endpoints
+			sort.SliceStable(eps, func(i, j int) bool {
+				return value(eps[i].Packets, eps[i].Bytes) > value(eps[j].Packets, eps[j].Bytes)
+			})
+		}
+		sort.SliceStable(lbresp.LbRules, func(i, j int) bool {
+			return value(lbresp.LbRules[i].Totals()) > value(lbresp.LbRules[j].Totals())
+		})
+	default:
+		lbresp.Sort()
+	}
+}
+
 func (lbresp LbRuleModGet) Sort() {
 	sort.Slice(lbresp.LbRules, func(i, j int) bool {
 		return lbresp.LbRules[i].Service.Key() < lbresp.LbRules[j].Service.Key()
 	})
 }
+
+func (sel EpSelect) String() string {
+	switch sel {
+	case LbSelRr:
+		return "rr"
+	case LbSelHash:
+		return "hash"
+	case LbSelPrio:
+		return "priority"
+	case LbSelRrPersist:
+		return "persist"
+	case LbSelLeastConnections:
+		return "lc"
+	case LbSelN2:
+		return "n2"
+	case LbSelN3:
+		return "n3"
+	}
+	return fmt.Sprintf("unknown(%d)", int(sel))
+}
+
+func (mode LbMode) String() string {
+	switch mode {
+	case LBModeDefault:
+		return "default"
+	case LBModeOneArm:
+		return "onearm"
+	case LBModeFullNAT:
+		return "fullnat"
+	case LBModeDSR:
+		return "dsr"
+	case LBModeFullProxy:
+		return "fullproxy"
+	case LBModeHostOneArm:
+		return "hostonearm"
+	}
+	return fmt.Sprintf("unknown(%d)", int(mode))
+}
+
+func (sec LbSec) String() string {
+	switch sec {
+	case LbSecNone:
+		return "none"
+	case LbSecHTTPS:
+		return "https"
+	case LbSecE2EHTTPS:
+		return "e2ehttps"
+	}
+	return fmt.Sprintf("unknown(%d)", int(sec))
+}
+
+// Validation checks the load balancer rule for combinations which loxilb
+// would reject or silently misinterpret. It is shared by the CLI, apply -f
+// and the save/restore path so all of them report the same errors.
+func (lb LoadBalancerModel) Validation() error {
+	service := lb.Service
+	if err := service.Validation(); err != nil {
+		return err
+	}
+
+	vip := net.ParseIP(service.ExternalIP)
+	for _, sip := range lb.SecondaryIPs {
+		ip := net.ParseIP(sip.SecondaryIP)
+		if ip == nil {
+			return fmt.Errorf("secondary IP '%s' is invalid format", sip.SecondaryIP)
+		}
+		if (ip.To4() == nil) != (vip.To4() == nil) {
+			return fmt.Errorf("secondary IP '%s' and external IP '%s' are not in the same IP family", sip.SecondaryIP, service.ExternalIP)
+		}
+	}
+	if len(lb.SecondaryIPs) > 0 && service.Protocol != "sctp" {
+		return fmt.Errorf("secondary IPs are allowed in sctp only")
+	}
+
+	for _, src := range lb.SrcIPs {
+		if _, _, err := net.ParseCIDR(src.Prefix); err != nil {
+			return fmt.Errorf("allowed source '%s' is not a valid CIDR", src.Prefix)
+		}
+	}
+
+	var epV4, epV6, weight int
+	for _, ep := range lb.Endpoints {
+		ip := net.ParseIP(ep.EndpointIP)
+		if ip == nil {
+			return fmt.Errorf("endpoint IP '%s' is invalid format", ep.EndpointIP)
+		}
+		if ip.To4() != nil {
+			epV4++
+		} else {
+			epV6++
+		}
+		if service.Protocol != "icmp" && ep.TargetPort == 0 {
+			return fmt.Errorf("endpoint '%s' needs a non-zero target port", ep.EndpointIP)
+		}
+		if service.Mode == LBModeDSR && ep.TargetPort != service.Port {
+			return fmt.Errorf("endpoint '%s': no port-translation in dsr mode (port %d, targetPort %d)", ep.EndpointIP, service.Port, ep.TargetPort)
+		}
+		weight += int(ep.Weight)
+	}
+	if epV4 > 0 && epV6 > 0 {
+		return fmt.Errorf("endpoints can't mix IPv4 and IPv6 addresses")
+	}
+	if vip.To4() != nil && epV6 > 0 {
+		return fmt.Errorf("IPv4 external IP '%s' can't have IPv6 endpoints", service.ExternalIP)
+	}
+	if service.Sel == LbSelPrio && service.Oper == LbOPAdd && len(lb.Endpoints) > 0 && weight == 0 {
+		return fmt.Errorf("select priority needs at least one endpoint with non-zero weight")
+	}
+
+	return nil
+}
+
+// Validation checks the service arguments of a load balancer rule.
+func (service LoadBalancerService) Validation() error {
+	if net.ParseIP(service.ExternalIP) == nil {
+		return fmt.Errorf("external IP '%s' is invalid format", service.ExternalIP)
+	}
+
+	switch service.Protocol {
+	case "tcp", "udp", "sctp":
+		if service.Port == 0 && !service.Egress {
+			return fmt.Errorf("%s rule needs a non-zero port", service.Protocol)
+		}
+	case "icmp":
+		if service.Port != 0 || service.PortMax != 0 {
+			return fmt.Errorf("icmp rule can't have a port")
+		}
+	default:
+		return fmt.Errorf("protocol '%s' is not supported (tcp, udp, sctp, icmp)", service.Protocol)
+	}
+	if service.PortMax != 0 && service.PortMax < service.Port {
+		return fmt.Errorf("portMax %d is lower than port %d", service.PortMax, service.Port)
+	}
+
+	if service.Sel > LbSelN3 {
+		return fmt.Errorf("select %s is not supported", service.Sel)
+	}
+	if service.Mode < LBModeDefault || service.Mode > LBModeHostOneArm {
+		return fmt.Errorf("mode %s is not supported", service.Mode)
+	}
+	if service.Security < LbSecNone || service.Security > LbSecE2EHTTPS {
+		return fmt.Errorf("security %s is not supported (none, https|tls, e2ehttps|e2etls)", service.Security)
+	}
+	if service.Oper < LbOPAdd || service.Oper > LbOPDetach {
+		return fmt.Errorf("operation %d is not supported", int(service.Oper))
+	}
+
+	if service.Mode == LBModeDSR && service.Sel != LbSelHash {
+		return fmt.Errorf("mode dsr is only available with select hash (got %s)", service.Sel)
+	}
+	if service.Sel == LbSelN2 && service.Mode != LBModeFullProxy {
+		return fmt.Errorf("select n2 is only available with mode fullproxy (got %s)", service.Mode)
+	}
+	if service.Security != LbSecNone && service.Protocol != "tcp" {
+		return fmt.Errorf("security %s is only available with tcp (got %s)", service.Security, service.Protocol)
+	}
+	if service.PpV2 {
+		if service.Protocol != "tcp" {
+			return fmt.Errorf("proxy protocol v2 is only available with tcp (got %s)", service.Protocol)
+		}
+		if service.Mode == LBModeDSR {
+			return fmt.Errorf("proxy protocol v2 is not available in mode dsr")
+		}
+		if service.Security != LbSecNone {
+			return fmt.Errorf("proxy protocol v2 can't be combined with security %s", service.Security)
+		}
+	}
+
+	return nil
+}
+
+// LbSelectorTerm - one "key op value" condition of a LbSelector
+type LbSelectorTerm struct {
+	// Key - one of name, ip, port, proto, mode, sel, host, endpoint, managed
+	Key string
+	// Op - one of "=", "!=", "=~" (regex match) or "!~" (regex mismatch)
+	Op string
+	// Value - value to compare, CIDR is accepted for ip and endpoint
+	Value string
+
+	re    *regexp.Regexp
+	ipNet *net.IPNet
+}
+
+// LbSelector - client-side filter for load balancer rules.
+// A rule is selected when all of the terms match.
+type LbSelector struct {
+	Terms []LbSelectorTerm
+	// ExceptManaged - never select rules managed by an external controller
+	ExceptManaged bool
+}
+
+var lbSelectorKeys = []string{"name", "ip", "port", "proto", "mode", "sel", "host", "endpoint", "managed"}
+
+// ParseLbSelector parses comma separated terms such as
+// name=~"^k8s-",proto=tcp,ip=10.0.0.0/24 into a LbSelector.
+func ParseLbSelector(expr string) (LbSelector, error) {
+	s := LbSelector{}
+	terms, err := splitSelectorTerms(expr)
+	if err != nil {
+		return s, err
+	}
+	for _, termStr := range terms {
+		termStr = strings.TrimSpace(termStr)
+		if termStr == "" {
+			continue
+		}
+		var key, op, value string
+		for _, o := range []string{"!=", "=~", "!~", "="} {
+			if idx := strings.Index(termStr, o); idx > 0 {
+				key, op, value = termStr[:idx], o, termStr[idx+len(o):]
+				break
+			}
+		}
+		if op == "" {
+			return s, fmt.Errorf("selector term '%s' is invalid format (key=value, key!=value, key=~regex, key!~regex)", termStr)
+		}
+		if err := s.Add(key, op, value); err != nil {
+			return s, err
+		}
+	}
+	return s, nil
+}
+
+// splitSelectorTerms splits expr on the commas that are not inside a quoted
+// value, so regexes like name=~"^a{1,3}$" stay in one term.
+func splitSelectorTerms(expr string) ([]string, error) {
+	var terms []string
+	var quote rune
+	start := 0
+	for i, c := range expr {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == ',':
+			terms = append(terms, expr[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("selector '%s' has an unterminated quote", expr)
+	}
+	return append(terms, expr[start:]), nil
+}
+
+// Add appends a term to the selector after checking the key and value.
+func (s *LbSelector) Add(key, op, value string) error {
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.Trim(strings.TrimSpace(value), `"'`)
+	valid := false
+	for _, k := range lbSelectorKeys {
+		if k == key {
+			valid = true
+			break
+		}
+	}
+	if !valid {
+		return fmt.Errorf("selector key '%s' is not supported (%s)", key, strings.Join(lbSelectorKeys, ", "))
+	}
+
+	term := LbSelectorTerm{Key: key, Op: op, Value: value}
+	switch op {
+	case "=~", "!~":
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return fmt.Errorf("selector '%s%s%s' has invalid regex: %s", key, op, value, err.Error())
+		}
+		term.re = re
+	case "=", "!=":
+		switch key {
+		case "ip", "endpoint":
+			if strings.Contains(value, "/") {
+				_, ipNet, err := net.ParseCIDR(value)
+				if err != nil {
+					return fmt.Errorf("selector '%s%s%s' has invalid CIDR", key, op, value)
+				}
+				term.ipNet = ipNet
+			}
+		case "port":
+			if _, err := strconv.ParseUint(value, 10, 16); err != nil {
+				return fmt.Errorf("selector '%s%s%s' has invalid port", key, op, value)
+			}
+		}
+	default:
+		return fmt.Errorf("selector operator '%s' is not supported", op)
+	}
+	s.Terms = append(s.Terms, term)
+	return nil
+}
+
+// Empty returns true when the selector has no terms. ExceptManaged alone
+// does not count as a selection.
+func (s LbSelector) Empty() bool {
+	return len(s.Terms) == 0
+}
+
+// Match returns true when all terms of the selector match the rule.
+func (s LbSelector) Match(lb LoadBalancerModel) bool {
+	if s.ExceptManaged && lb.Service.Managed {
+		return false
+	}
+	for _, term := range s.Terms {
+		if !term.Match(lb) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns true when the term matches the rule.
+func (t LbSelectorTerm) Match(lb LoadBalancerModel) bool {
+	negate := t.Op == "!=" || t.Op == "!~"
+	for _, v := range t.values(lb) {
+		if t.matchValue(v) {
+			return !negate
+		}
+	}
+	return negate
+}
+
+func (t LbSelectorTerm) matchValue(v string) bool {
+	if t.re != nil {
+		return t.re.MatchString(v)
+	}
+	if t.ipNet != nil {
+		ip := net.ParseIP(v)
+		return ip != nil && t.ipNet.Contains(ip)
+	}
+	if t.Key == "ip" || t.Key == "endpoint" {
+		ip := net.ParseIP(v)
+		return ip != nil && ip.Equal(net.ParseIP(t.Value))
+	}
+	return strings.EqualFold(v, t.Value)
+}
+
+func (t LbSelectorTerm) values(lb LoadBalancerModel) []string {
+	service := lb.Service
+	switch t.Key {
+	case "name":
+		return []string{service.Name}
+	case "ip":
+		return []string{service.ExternalIP}
+	case "port":
+		if t.re == nil {
+			// Port ranges match any port inside the range
+			port, _ := strconv.ParseUint(t.Value, 10, 16)
+			if uint16(port) >= service.Port && uint16(port) <= service.PortMax {
+				return []string{t.Value}
+			}
+		}
+		return []string{fmt.Sprintf("%d", service.Port)}
+	case "proto":
+		return []string{service.Protocol}
+	case "mode":
+		return []string{service.Mode.String()}
+	case "sel":
+		return []string{service.Sel.String()}
+	case "host":
+		return []string{service.Host}
+	case "managed":
+		return []string{fmt.Sprintf("%v", service.Managed)}
+	case "endpoint":
+		var eps []string
+		for _, ep := range lb.Endpoints {
+			eps = append(eps, ep.EndpointIP)
+		}
+		return eps
+	}
+	return nil
+}
+
+// Filter returns the rules selected by s.
+func (lbresp LbRuleModGet) Filter(s LbSelector) LbRuleModGet {
+	if s.Empty() && !s.ExceptManaged {
+		return lbresp
+	}
+	fresp := LbRuleModGet{}
+	for _, lb := range lbresp.LbRules {
+		if s.Match(lb) {
+			fresp.LbRules = append(fresp.LbRules, lb)
+		}
+	}
+	return fresp
+}
@@ -15,7 +15,13 @@
  */
 package api
 
-import "sort"
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
 
 type Mirror struct {
 	CommonAPI
@@ -99,3 +105,91 @@ func (Mirrorresp MirrorGet) Sort() {
 		return Mirrorresp.Mirrors[i].Ident < Mirrorresp.Mirrors[j].Ident
 	})
 }
+
+// MirrTypeFromString converts span, rspan or erspan to MirrType.
+func MirrTypeFromString(mirrType string) (int, error) {
+	switch strings.ToLower(mirrType) {
+	case "span", "":
+		return MirrTypeSpan, nil
+	case "rspan":
+		return MirrTypeRspan, nil
+	case "erspan":
+		return MirrTypeErspan, nil
+	}
+	return -1, fmt.Errorf("mirror type '%s' is not supported (span, rspan, erspan)", mirrType)
+}
+
+// MirrTypeToString converts MirrType to span, rspan or erspan.
+func MirrTypeToString(mirrType int) string {
+	switch mirrType {
+	case MirrTypeSpan:
+		return "span"
+	case MirrTypeRspan:
+		return "rspan"
+	case MirrTypeErspan:
+		return "erspan"
+	}
+	return fmt.Sprintf("unknown(%d)", mirrType)
+}
+
+// Validation checks that the fields required by the mirror type are set and
+// that fields of other types are not.
+func (info MirrInfo) Validation() error {
+	typeStr := MirrTypeToString(info.MirrType)
+	switch info.MirrType {
+	case MirrTypeSpan, MirrTypeRspan:
+		if info.MirrPort == "" {
+			return fmt.Errorf("%s mirror needs a port to send mirrored traffic to", typeStr)
+		}
+		if info.MirrRip != "" || info.MirrSip != "" || info.MirrTid != 0 {
+			return fmt.Errorf("remote IP, source IP and tunnel ID are only for erspan mirror")
+		}
+	case MirrTypeErspan:
+		rip := net.ParseIP(info.MirrRip)
+		if rip == nil {
+			return fmt.Errorf("erspan mirror needs a valid remote IP, got '%s'", info.MirrRip)
+		}
+		sip := net.ParseIP(info.MirrSip)
+		if sip == nil {
+			return fmt.Errorf("erspan mirror needs a valid source IP, got '%s'", info.MirrSip)
+		}
+		if (rip.To4() == nil) != (sip.To4() == nil) {
+			return fmt.Errorf("remote IP %s and source IP %s are not the same IP family", info.MirrRip, info.MirrSip)
+		}
+		// ERSPAN session ID is a 10 bit field
+		if info.MirrTid <= 0 || info.MirrTid > 1023 {
+			return fmt.Errorf("erspan mirror needs a tunnel ID between 1 and 1023, got %d", info.MirrTid)
+		}
+		if info.MirrVlan != 0 {
+			return fmt.Errorf("vlan is only for rspan mirror")
+		}
+	default:
+		return fmt.Errorf("mirror type %d is not supported", info.MirrType)
+	}
+	if info.MirrType == MirrTypeRspan {
+		if info.MirrVlan < 1 || info.MirrVlan > 4094 {
+			return fmt.Errorf("rspan mirror needs a vlan between 1 and 4094, got %d", info.MirrVlan)
+		}
+	} else if info.MirrType == MirrTypeSpan && info.MirrVlan != 0 {
+		return fmt.Errorf("vlan is only for rspan mirror")
+	}
+	return nil
+}
+
+func (mirr MirrMod) Validation() error {
+	if mirr.Ident == "" {
+		return errors.New("mirror needs an ident")
+	}
+	if err := mirr.Info.Validation(); err != nil {
+		return err
+	}
+	switch mirr.Target.AttachMent {
+	case MirrAttachPort, MirrAttachRule:
+	default:
+		return fmt.Errorf("mirror attachment %d is not supported (1: port, 2: lb rule)", mirr.Target.AttachMent)
+	}
+	if mirr.Target.MirrObjName == "" {
+		return errors.New("mirror needs an object to attach to")
+	}
+	return nil
+}
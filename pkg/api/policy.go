@@ -15,7 +15,14 @@
  */
 package api
 
-import "sort"
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 type Policy struct {
 	CommonAPI
@@ -23,6 +30,20 @@ type Policy struct {
 
 type PolObjType uint
 
+const (
+	// PolAttachPort - policy attachment to a port
+	PolAttachPort PolObjType = 1 << iota
+	// PolAttachLbRule - policy attachment to a lb rule
+	PolAttachLbRule
+)
+
+const (
+	// PolTypeTrtcm - two rate three color marker
+	PolTypeTrtcm = 0
+	// PolTypeSrtcm - single rate three color marker
+	PolTypeSrtcm = 1
+)
+
 type PolInformationGet struct {
 	PolModInfo []PolMod `json:"polAttr"`
 }
@@ -37,7 +58,8 @@ type PolInfo struct {
 }
 
 type PolObj struct {
-	PolObjName string     `json:"polObjName" yaml:"polObjName"`
+	PolObjName string `json:"polObjName" yaml:"polObjName"`
+	// AttachMent - one of PolAttachPort or PolAttachLbRule
 	AttachMent PolObjType `json:"attachment" yaml:"attachment"`
 }
 
@@ -57,3 +79,178 @@ func (Polresp PolInformationGet) Sort() {
 		return Polresp.PolModInfo[i].Ident < Polresp.PolModInfo[j].Ident
 	})
 }
+
+func (t PolObjType) String() string {
+	switch t {
+	case PolAttachPort:
+		return "port"
+	case PolAttachLbRule:
+		return "lb-rule"
+	}
+	return fmt.Sprintf("unknown(%d)", uint(t))
+}
+
+// ParsePolObjType converts port, lb-rule or the number of the attachment to PolObjType.
+func ParsePolObjType(attachment string) (PolObjType, error) {
+	switch strings.ToLower(attachment) {
+	case "port", "1":
+		return PolAttachPort, nil
+	case "lb-rule", "lbrule", "lb", "2":
+		return PolAttachLbRule, nil
+	}
+	return 0, fmt.Errorf("attachment '%s' is not supported (port, lb-rule)", attachment)
+}
+
+// PolTypeToString converts the policy type to trTCM or srTCM.
+func PolTypeToString(polType int) string {
+	switch polType {
+	case PolTypeTrtcm:
+		return "trTCM"
+	case PolTypeSrtcm:
+		return "srTCM"
+	}
+	return fmt.Sprintf("unknown(%d)", polType)
+}
+
+// ParsePolType converts trTCM, srTCM or the number of the type to the policy type.
+func ParsePolType(polType string) (int, error) {
+	switch strings.ToLower(polType) {
+	case "trtcm", "0", "":
+		return PolTypeTrtcm, nil
+	case "srtcm", "1":
+		return PolTypeSrtcm, nil
+	}
+	return -1, fmt.Errorf("policy type '%s' is not supported (trTCM, srTCM)", polType)
+}
+
+// rateUnits - Mbps of each unit as a fraction
+var rateUnits = []struct {
+	suffix string
+	num    int64
+	den    int64
+}{
+	{"tbps", 1000000, 1}, {"gbps", 1000, 1}, {"mbps", 1, 1}, {"kbps", 1, 1000}, {"bps", 1, 1000000},
+	{"t", 1000000, 1}, {"g", 1000, 1}, {"m", 1, 1},
+}
+
+// scaleDecimal multiplies the decimal number str by num/den without the rounding
+// of float64, so that 2.01 of 1000 is exactly 2010. whole is false when the
+// result has a fraction.
+func scaleDecimal(str string, num, den int64) (v uint64, whole bool, err error) {
+	r, ok := new(big.Rat).SetString(str)
+	if !ok || strings.Contains(str, "/") || r.Sign() < 0 {
+		return 0, false, errors.New("invalid number")
+	}
+	r.Mul(r, big.NewRat(num, den))
+	if !r.IsInt() {
+		return 0, false, nil
+	}
+	if !r.Num().IsUint64() {
+		return 0, false, errors.New("number out of range")
+	}
+	return r.Num().Uint64(), true, nil
+}
+
+// ParseRate parses a rate like 500Mbps or 1.5Gbps into Mbps, the unit of the
+// policy rates. A bare number is taken as Mbps.
+func ParseRate(rate string) (uint64, error) {
+	str := strings.ToLower(strings.TrimSpace(rate))
+	num, den := int64(1), int64(1)
+	for _, u := range rateUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			num, den = u.num, u.den
+			break
+		}
+	}
+	mbps, whole, err := scaleDecimal(str, num, den)
+	if err != nil {
+		return 0, fmt.Errorf("rate '%s' is invalid, use a number with bps, Kbps, Mbps, Gbps or Tbps", rate)
+	}
+	if !whole {
+		return 0, fmt.Errorf("rate '%s' is not a multiple of 1Mbps", rate)
+	}
+	return mbps, nil
+}
+
+// FormatRate renders a rate in Mbps with the largest unit that keeps it short.
+func FormatRate(mbps uint64) string {
+	switch {
+	case mbps >= 1000000 && mbps%10000 == 0:
+		return strconv.FormatFloat(float64(mbps)/1000000, 'f', -1, 64) + "Tbps"
+	case mbps >= 1000 && mbps%10 == 0:
+		return strconv.FormatFloat(float64(mbps)/1000, 'f', -1, 64) + "Gbps"
+	}
+	return fmt.Sprintf("%dMbps", mbps)
+}
+
+var sizeUnits = []struct {
+	suffix string
+	bytes  uint64
+}{
+	{"gib", 1 << 30}, {"mib", 1 << 20}, {"kib", 1 << 10},
+	{"gb", 1000000000}, {"mb", 1000000}, {"kb", 1000}, {"b", 1},
+}
+
+// ParseSize parses a size like 64KiB or 1MB into bytes. A bare number is
+// taken as bytes.
+func ParseSize(size string) (uint64, error) {
+	str := strings.ToLower(strings.TrimSpace(size))
+	mul := uint64(1)
+	for _, u := range sizeUnits {
+		if strings.HasSuffix(str, u.suffix) {
+			str = strings.TrimSpace(strings.TrimSuffix(str, u.suffix))
+			mul = u.bytes
+			break
+		}
+	}
+	bytes, whole, err := scaleDecimal(str, int64(mul), 1)
+	if err != nil {
+		return 0, fmt.Errorf("size '%s' is invalid, use a number with B, KB, KiB, MB, MiB, GB or GiB", size)
+	}
+	if !whole {
+		return 0, fmt.Errorf("size '%s' is not a whole number of bytes", size)
+	}
+	return bytes, nil
+}
+
+// FormatSize renders bytes with a binary or decimal unit when it divides evenly.
+func FormatSize(bytes uint64) string {
+	if bytes == 0 {
+		return "0B"
+	}
+	for _, u := range sizeUnits {
+		if u.bytes > 1 && bytes%u.bytes == 0 {
+			return fmt.Sprintf("%d%s", bytes/u.bytes, strings.Replace(strings.ToUpper(u.suffix), "I", "i", 1))
+		}
+	}
+	return fmt.Sprintf("%dB", bytes)
+}
+
+func (pol PolMod) Validation() error {
+	if pol.Ident == "" {
+		return errors.New("policy needs an ident")
+	}
+	switch pol.Info.PolType {
+	case PolTypeTrtcm:
+		if pol.Info.PeakInfoRate < pol.Info.CommittedInfoRate {
+			return fmt.Errorf("trTCM peak rate %s is lower than committed rate %s",
+				FormatRate(pol.Info.PeakInfoRate), FormatRate(pol.Info.CommittedInfoRate))
+		}
+	case PolTypeSrtcm:
+	default:
+		return fmt.Errorf("policy type %d is not supported (0: trTCM, 1: srTCM)", pol.Info.PolType)
+	}
+	if pol.Info.CommittedInfoRate == 0 {
+		return errors.New("policy needs a committed rate")
+	}
+	switch pol.Target.AttachMent {
+	case PolAttachPort, PolAttachLbRule:
+	default:
+		return fmt.Errorf("policy attachment %d is not supported (1: port, 2: lb-rule)", pol.Target.AttachMent)
+	}
+	if pol.Target.PolObjName == "" {
+		return errors.New("policy needs an object to attach to")
+	}
+	return nil
+}
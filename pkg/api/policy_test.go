@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package api
+
+import "testing"
+
+func TestParseRate(t *testing.T) {
+	tests := []struct {
+		rate    string
+		want    uint64
+		wantErr bool
+	}{
+		{"500", 500, false},
+		{"500Mbps", 500, false},
+		{"1.5Gbps", 1500, false},
+		{"2.01Gbps", 2010, false},
+		{"2.999g", 2999, false},
+		{"0.001Tbps", 1000, false},
+		{"1000Kbps", 1, false},
+		{"1000000bps", 1, false},
+		{" 10 Gbps ", 10000, false},
+		{"1e3", 1000, false},
+		{"1.5Mbps", 0, true},
+		{"1Kbps", 0, true},
+		{"2.0001Gbps", 0, true},
+		{"-1Mbps", 0, true},
+		{"1/2Gbps", 0, true},
+		{"fast", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.rate, func(t *testing.T) {
+			got, err := ParseRate(tt.rate)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRate(%q) error = %v, wantErr %v", tt.rate, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseRate(%q) = %d, want %d", tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatRateRoundTrip(t *testing.T) {
+	max := uint64(3000000)
+	if testing.Short() {
+		max = 100000
+	}
+	for mbps := uint64(1); mbps <= max; mbps++ {
+		rate := FormatRate(mbps)
+		got, err := ParseRate(rate)
+		if err != nil || got != mbps {
+			t.Fatalf("ParseRate(FormatRate(%d) = %q) = %d, %v", mbps, rate, got, err)
+		}
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		size    string
+		want    uint64
+		wantErr bool
+	}{
+		{"64", 64, false},
+		{"64KiB", 65536, false},
+		{"1.1KB", 1100, false},
+		{"0.5MiB", 524288, false},
+		{"1.0001KB", 0, true},
+		{"-1B", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.size, func(t *testing.T) {
+			got, err := ParseSize(tt.size)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSize(%q) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSize(%q) = %d, want %d", tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSizeRoundTrip(t *testing.T) {
+	for _, bytes := range []uint64{0, 1, 1000, 1024, 1536, 65536, 1000000, 1 << 20, 3 << 30, 5000000000} {
+		size := FormatSize(bytes)
+		got, err := ParseSize(size)
+		if err != nil || got != bytes {
+			t.Errorf("ParseSize(FormatSize(%d) = %q) = %d, %v", bytes, size, got, err)
+		}
+	}
+}
@@ -19,10 +19,14 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
+	"strconv"
+	"sync"
 )
 
 const (
@@ -68,6 +72,74 @@ type RESTOptions struct {
 	Timeout     int16
 	ServiceName string
 	Token       string
+	// Servers - API servers of the cluster nodes to run a command on
+	Servers []string
+	// Log - records the outcome of each request when set
+	Log *RequestLog
+	// Transport - transport of the clients instead of http.DefaultTransport when set
+	Transport http.RoundTripper
+}
+
+// RequestLog - outcome of the requests made with a RESTOptions. It tells whether a
+// command run on a node of --servers failed without looking at what it printed.
+type RequestLog struct {
+	mu       sync.Mutex
+	requests int
+	errs     []string
+}
+
+func (l *RequestLog) record(req *http.Request, resp *http.Response, err error) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests++
+	if err != nil {
+		l.errs = append(l.errs, fmt.Sprintf("%s %s: %s", req.Method, req.URL.Path, err.Error()))
+	} else if resp.StatusCode >= http.StatusBadRequest {
+		l.errs = append(l.errs, fmt.Sprintf("%s %s: %s", req.Method, req.URL.Path, resp.Status))
+	}
+}
+
+// Requests returns the number of requests made
+func (l *RequestLog) Requests() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.requests
+}
+
+// Errors returns the requests which failed or got an error status
+func (l *RequestLog) Errors() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.errs...)
+}
+
+// WithServer returns a copy of the options for another API server.
+// The node is "<IP|host>", "<IP|host>:<port>" or "[<IPv6>]:<port>". The port defaults to the current one.
+func (o RESTOptions) WithServer(node string) (*RESTOptions, error) {
+	host, port := node, ""
+	if h, p, err := net.SplitHostPort(node); err == nil {
+		host, port = h, p
+	}
+	if host == "" {
+		return nil, fmt.Errorf("API server '%s' is invalid format", node)
+	}
+	if port != "" {
+		val, err := strconv.ParseUint(port, 10, 15)
+		if err != nil || val == 0 {
+			return nil, fmt.Errorf("API server port '%s' is invalid", port)
+		}
+		o.ServerPort = int16(val)
+	}
+	o.ServerIP = host
+	return &o, nil
+}
+
+// Server returns the API server as "<host>:<port>"
+func (o RESTOptions) Server() string {
+	return net.JoinHostPort(o.ServerIP, strconv.Itoa(int(o.ServerPort)))
 }
 
 type RESTClient struct {
@@ -80,7 +152,7 @@ func (r *RESTClient) GetProcotol() string {
 }
 
 func (r *RESTClient) GetHost() string {
-	return fmt.Sprintf("%s:%d", r.Options.ServerIP, int(r.Options.ServerPort))
+	return r.Options.Server()
 }
 
 func (r *RESTClient) GET(ctx context.Context, getURL string) (*http.Response, error) {
@@ -92,7 +164,9 @@ func (r *RESTClient) GET(ctx context.Context, getURL string) (*http.Response, er
 	// move RESTOptions
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", r.Options.Token)
-	return r.Client.Do(req)
+	resp, err := r.Client.Do(req)
+	r.Options.Log.record(req, resp, err)
+	return resp, err
 }
 
 func (r *RESTClient) POST(ctx context.Context, postURL string, body []byte) (*http.Response, error) {
@@ -104,7 +178,19 @@ func (r *RESTClient) POST(ctx context.Context, postURL string, body []byte) (*ht
 	// move RESTOptions
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", r.Options.Token)
-	return r.Client.Do(req)
+	return r.doBuffered(req)
+}
+
+func (r *RESTClient) PUT(ctx context.Context, putURL string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, putURL, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	r.getTokens()
+	// move RESTOptions
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", r.Options.Token)
+	return r.doBuffered(req)
 }
 
 func (r *RESTClient) DELETE(ctx context.Context, deleteURL string) (*http.Response, error) {
@@ -116,7 +202,24 @@ func (r *RESTClient) DELETE(ctx context.Context, deleteURL string) (*http.Respon
 	// move RESTOptions
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", r.Options.Token)
-	return r.Client.Do(req)
+	return r.doBuffered(req)
+}
+
+// doBuffered reads the whole response body before returning. The callers may cancel
+// the context of the request before the body is read, and the result of a change is small.
+func (r *RESTClient) doBuffered(req *http.Request) (*http.Response, error) {
+	resp, err := r.Client.Do(req)
+	r.Options.Log.record(req, resp, err)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
 }
 
 func (r *RESTClient) getTokens() {
@@ -15,7 +15,11 @@
  */
 package api
 
-import "sort"
+import (
+	"fmt"
+	"net"
+	"sort"
+)
 
 type Route struct {
 	CommonAPI
@@ -33,7 +37,7 @@ type RouteGetEntryStatistic struct {
 	Packets int `json:"packets"`
 }
 
-// Routev4Get - Info about an route
+// Routev4Get - Info about an IPv4 or IPv6 route
 type Routev4Get struct {
 	// Flags - flag type
 	Flags string `json:"flags" yaml:"flags"`
@@ -60,3 +64,45 @@ func (routeresp RouteModGet) Sort() {
 		return routeresp.RouteAttr[i].Dst < routeresp.RouteAttr[j].Dst
 	})
 }
+
+// MakeRoute makes a route to dst via the gateway
+func MakeRoute(dst, gateway, protocol string) (Routev4Get, error) {
+	route := Routev4Get{Dst: dst, Gw: gateway, Protocol: protocol}
+	return route, route.Validation()
+}
+
+func (route Routev4Get) Validation() error {
+	_, dst, err := net.ParseCIDR(route.Dst)
+	if err != nil {
+		return fmt.Errorf("DestinationIPNet '%s' is invalid format", route.Dst)
+	}
+	if route.Gw == "" {
+		return nil
+	}
+	gw := net.ParseIP(route.Gw)
+	if gw == nil {
+		return fmt.Errorf("gateway IP '%s' is invalid format", route.Gw)
+	}
+	if (gw.To4() != nil) != (dst.IP.To4() != nil) {
+		return fmt.Errorf("gateway IP '%s' is not the same IP family as %s", route.Gw, route.Dst)
+	}
+	return nil
+}
+
+// LongestPrefixMatch returns the route of the longest prefix which includes ip, as the datapath would choose.
+func (routeresp RouteModGet) LongestPrefixMatch(ip net.IP) (Routev4Get, bool) {
+	var best Routev4Get
+	bestLen := -1
+	isV4 := ip.To4() != nil
+	for _, route := range routeresp.RouteAttr {
+		_, dst, err := net.ParseCIDR(route.Dst)
+		if err != nil || (dst.IP.To4() != nil) != isV4 || !dst.Contains(ip) {
+			continue
+		}
+		if ones, _ := dst.Mask.Size(); ones > bestLen {
+			best = route
+			bestLen = ones
+		}
+	}
+	return best, bestLen >= 0
+}
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"net"
 	"sort"
+	"strconv"
+	"strings"
 )
 
 type Session struct {
@@ -48,12 +50,76 @@ type ConfigurationSessionFile struct {
 }
 
 func (s SessionMod) Validation() error {
+	if s.Ident == "" {
+		return fmt.Errorf("ident is empty")
+	}
+	if s.Ip == nil {
+		return fmt.Errorf("session IP is empty")
+	}
 	if s.AnTun.TeID == 0 || s.CnTun.TeID == 0 {
 		return fmt.Errorf("TeID need to be not 0")
 	}
+	if s.AnTun.Addr == nil || s.CnTun.Addr == nil {
+		return fmt.Errorf("tunnel IP is empty")
+	}
 	return nil
 }
 
+// ParseSessTun parses a tunnel as "<TeID>:<TunnelIP>". An IPv6 tunnel IP may be in brackets
+// like "1:[2001:db8::1]", but it doesn't need to be because the TeID is split at the first colon.
+func ParseSessTun(tunnel string) (SessTun, error) {
+	teid, addr, ok := strings.Cut(strings.TrimSpace(tunnel), ":")
+	if !ok {
+		return SessTun{}, fmt.Errorf("tunnel '%s' is invalid format. it should be '<TeID>:<TunnelIP>'", tunnel)
+	}
+	return MakeSessTun(teid, addr)
+}
+
+// MakeSessTun makes a tunnel of a TeID and a tunnel IP
+func MakeSessTun(teid, addr string) (SessTun, error) {
+	val, err := strconv.ParseUint(strings.TrimSpace(teid), 10, 32)
+	if err != nil {
+		return SessTun{}, fmt.Errorf("TeID '%s' is invalid format", teid)
+	}
+	addr = strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(addr), "["), "]")
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return SessTun{}, fmt.Errorf("tunnel IP '%s' is invalid format", addr)
+	}
+	return SessTun{TeID: uint32(val), Addr: ip}, nil
+}
+
+// SessionFilter - sessions to look up. An empty field matches any session.
+type SessionFilter struct {
+	Ident string
+	// TeID of the access or the core network tunnel
+	TeID uint32
+	// UEIP - session IP of the UE
+	UEIP net.IP
+	// TunnelIP of the access or the core network tunnel
+	TunnelIP net.IP
+}
+
+func (f SessionFilter) Empty() bool {
+	return f.Ident == "" && f.TeID == 0 && f.UEIP == nil && f.TunnelIP == nil
+}
+
+func (f SessionFilter) Match(s SessionMod) bool {
+	if f.Ident != "" && s.Ident != f.Ident {
+		return false
+	}
+	if f.TeID != 0 && s.AnTun.TeID != f.TeID && s.CnTun.TeID != f.TeID {
+		return false
+	}
+	if f.UEIP != nil && !f.UEIP.Equal(s.Ip) {
+		return false
+	}
+	if f.TunnelIP != nil && !f.TunnelIP.Equal(s.AnTun.Addr) && !f.TunnelIP.Equal(s.CnTun.Addr) {
+		return false
+	}
+	return true
+}
+
 func (sessionresp SessionInformationGet) Sort() {
 	sort.Slice(sessionresp.SessionInfo, func(i, j int) bool {
 		return sessionresp.SessionInfo[i].Ident < sessionresp.SessionInfo[j].Ident
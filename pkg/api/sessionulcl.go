@@ -49,3 +49,30 @@ func (ulclresp UlclInformationGet) Sort() {
 		return ulclresp.UlclInfo[i].Ident < ulclresp.UlclInfo[j].Ident
 	})
 }
+
+// SessionWithUlCl - a session joined with its ULCL classifiers, which have the ident of the session
+type SessionWithUlCl struct {
+	SessionMod `yaml:",inline"`
+	UlCls      []UlClArg `json:"ulcls" yaml:"ulcls"`
+}
+
+// OfSession returns the ULCL classifiers of the session ident
+func (ulclresp UlclInformationGet) OfSession(ident string) []UlClArg {
+	args := []UlClArg{}
+	for _, ulcl := range ulclresp.UlclInfo {
+		if ulcl.Ident == ident {
+			args = append(args, ulcl.Args)
+		}
+	}
+	return args
+}
+
+// JoinSessionUlCl joins each session with its ULCL classifiers.
+// ULCLs without a session are not in the result.
+func JoinSessionUlCl(sessionresp SessionInformationGet, ulclresp UlclInformationGet) []SessionWithUlCl {
+	joined := make([]SessionWithUlCl, 0, len(sessionresp.SessionInfo))
+	for _, s := range sessionresp.SessionInfo {
+		joined = append(joined, SessionWithUlCl{SessionMod: s, UlCls: ulclresp.OfSession(s.Ident)})
+	}
+	return joined
+}
@@ -0,0 +1,184 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package api
+
+import (
+	"fmt"
+	"net"
+)
+
+// Kinds of the objects which a topology expands into
+const (
+	TopologyKindVlan       = "Vlan"
+	TopologyKindVlanMember = "VlanMember"
+	TopologyKindVxlan      = "Vxlan"
+	TopologyKindVxlanPeer  = "VxlanPeer"
+	TopologyKindIPAddress  = "IPaddress"
+)
+
+// ConfigurationTopologyFile - an overlay of VLANs and VXLANs declared in a single file
+type ConfigurationTopologyFile struct {
+	TypeMeta   `yaml:",inline"`
+	ObjectMeta `yaml:"metadata,omitempty"`
+	Spec       TopologySpec `yaml:"spec"`
+}
+
+type TopologySpec struct {
+	Vlans  []TopologyVlan  `yaml:"vlans,omitempty"`
+	Vxlans []TopologyVxlan `yaml:"vxlans,omitempty"`
+}
+
+// TopologyVlan - a VLAN bridge "vlan<vid>" with its members and gateway IPs
+type TopologyVlan struct {
+	Vid      int      `yaml:"vid"`
+	Tagged   []string `yaml:"tagged,omitempty"`
+	Untagged []string `yaml:"untagged,omitempty"`
+	// Gateways - IP addresses of the bridge as <IP>/<prefix length>
+	Gateways []string `yaml:"gateways,omitempty"`
+}
+
+// TopologyVxlan - a VXLAN bridge "vxlan<vxlanID>" on an endpoint device with its peers and gateway IPs.
+// The endpoint device can be a VLAN bridge of the same topology.
+type TopologyVxlan struct {
+	VxlanID     int      `yaml:"vxlanID"`
+	EndpointDev string   `yaml:"epIntf"`
+	Peers       []string `yaml:"peers,omitempty"`
+	// Gateways - IP addresses of the bridge as <IP>/<prefix length>
+	Gateways []string `yaml:"gateways,omitempty"`
+}
+
+// TopologyObject - an object of a topology, which is the same as the file of its kind
+type TopologyObject struct {
+	Kind string
+	// Name - the object to show to the user
+	Name string
+	// File - Configuration*File of the kind
+	File interface{}
+}
+
+func VlanBridgeName(vid int) string {
+	return fmt.Sprintf("vlan%d", vid)
+}
+
+func VxlanBridgeName(vxlanID int) string {
+	return fmt.Sprintf("vxlan%d", vxlanID)
+}
+
+func (topo TopologySpec) Validation() error {
+	if len(topo.Vlans) == 0 && len(topo.Vxlans) == 0 {
+		return fmt.Errorf("topology has no vlan and no vxlan")
+	}
+	vids := map[int]bool{}
+	for _, vlan := range topo.Vlans {
+		if vlan.Vid < 1 || vlan.Vid > 4094 {
+			return fmt.Errorf("vlan id %d should be 1~4094", vlan.Vid)
+		}
+		if vids[vlan.Vid] {
+			return fmt.Errorf("vlan %d is duplicated", vlan.Vid)
+		}
+		vids[vlan.Vid] = true
+		members := map[string]bool{}
+		for _, dev := range append(append([]string{}, vlan.Tagged...), vlan.Untagged...) {
+			if members[dev] {
+				return fmt.Errorf("%s is a member of vlan %d more than once", dev, vlan.Vid)
+			}
+			members[dev] = true
+		}
+		if err := validateTopologyGateways(VlanBridgeName(vlan.Vid), vlan.Gateways); err != nil {
+			return err
+		}
+	}
+	vxlanIDs := map[int]bool{}
+	for _, vxlan := range topo.Vxlans {
+		if vxlan.VxlanID < 1 || vxlan.VxlanID > 16777215 {
+			return fmt.Errorf("vxlan id %d should be 1~16777215", vxlan.VxlanID)
+		}
+		if vxlanIDs[vxlan.VxlanID] {
+			return fmt.Errorf("vxlan %d is duplicated", vxlan.VxlanID)
+		}
+		vxlanIDs[vxlan.VxlanID] = true
+		if vxlan.EndpointDev == "" {
+			return fmt.Errorf("vxlan %d need epIntf", vxlan.VxlanID)
+		}
+		peers := map[string]bool{}
+		for _, peer := range vxlan.Peers {
+			ip := net.ParseIP(peer)
+			if ip == nil {
+				return fmt.Errorf("peer IP '%s' of vxlan %d is invalid format", peer, vxlan.VxlanID)
+			}
+			if peers[ip.String()] {
+				return fmt.Errorf("peer IP '%s' of vxlan %d is duplicated", peer, vxlan.VxlanID)
+			}
+			peers[ip.String()] = true
+		}
+		if err := validateTopologyGateways(VxlanBridgeName(vxlan.VxlanID), vxlan.Gateways); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateTopologyGateways(dev string, gateways []string) error {
+	for _, gw := range gateways {
+		if _, _, err := net.ParseCIDR(gw); err != nil {
+			return fmt.Errorf("gateway '%s' of %s should be <IP>/<prefix length>", gw, dev)
+		}
+	}
+	return nil
+}
+
+// Objects returns the objects of the topology in the order of the dependency.
+// A bridge is before its members, peers and IPs, and the VLANs are before the VXLANs
+// which can use them as the endpoint device. Teardown is in the reverse order.
+func (topo TopologySpec) Objects() []TopologyObject {
+	var objs []TopologyObject
+	addIPs := func(dev string, gateways []string) {
+		for _, gw := range gateways {
+			objs = append(objs, TopologyObject{Kind: TopologyKindIPAddress, Name: fmt.Sprintf("ip %s on %s", gw, dev),
+				File: ConfigurationIPv4File{TypeMeta: TypeMeta{Kind: TopologyKindIPAddress}, Spec: Ipv4AddrMod{Dev: dev, IP: gw}}})
+		}
+	}
+	for _, vlan := range topo.Vlans {
+		objs = append(objs, TopologyObject{Kind: TopologyKindVlan, Name: fmt.Sprintf("vlan %d", vlan.Vid),
+			File: ConfigurationVlanFile{TypeMeta: TypeMeta{Kind: TopologyKindVlan}, Spec: VlanBridgeMod{Vid: vlan.Vid}}})
+		for _, member := range []struct {
+			devs   []string
+			tagged bool
+		}{{vlan.Tagged, true}, {vlan.Untagged, false}} {
+			for _, dev := range member.devs {
+				name := fmt.Sprintf("vlan %d member %s", vlan.Vid, dev)
+				if member.tagged {
+					name += " (tagged)"
+				}
+				objs = append(objs, TopologyObject{Kind: TopologyKindVlanMember, Name: name,
+					File: ConfigurationVlanMemberFile{TypeMeta: TypeMeta{Kind: TopologyKindVlanMember}, ObjectMeta: ObjectMeta{VlanID: vlan.Vid},
+						Spec: VlanMemberMod{Dev: dev, Tagged: member.tagged}}})
+			}
+		}
+		addIPs(VlanBridgeName(vlan.Vid), vlan.Gateways)
+	}
+	for _, vxlan := range topo.Vxlans {
+		objs = append(objs, TopologyObject{Kind: TopologyKindVxlan, Name: fmt.Sprintf("vxlan %d on %s", vxlan.VxlanID, vxlan.EndpointDev),
+			File: ConfigurationVxlanFile{TypeMeta: TypeMeta{Kind: TopologyKindVxlan}, Spec: VxlanBridgeMod{VxLanID: vxlan.VxlanID, EndpointDev: vxlan.EndpointDev}}})
+		for _, peer := range vxlan.Peers {
+			objs = append(objs, TopologyObject{Kind: TopologyKindVxlanPeer, Name: fmt.Sprintf("vxlan %d peer %s", vxlan.VxlanID, peer),
+				File: ConfigurationVxlanPeerFile{TypeMeta: TypeMeta{Kind: TopologyKindVxlanPeer}, ObjectMeta: ObjectMeta{VxlanID: vxlan.VxlanID},
+					Spec: VxlanPeerMod{PeerIP: peer}}})
+		}
+		addIPs(VxlanBridgeName(vxlan.VxlanID), vxlan.Gateways)
+	}
+	return objs
+}
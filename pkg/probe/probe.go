@@ -0,0 +1,346 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package probe
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Results of a probe
+const (
+	OK      = "ok"
+	Fail    = "fail"
+	Unknown = "unknown"
+)
+
+// Result - result of probing an endpoint from this host
+type Result struct {
+	Probe   string        `json:"probe"`
+	Result  string        `json:"result"`
+	Detail  string        `json:"detail,omitempty"`
+	Latency time.Duration `json:"latency"`
+}
+
+// Target - what to probe and how
+type Target struct {
+	IP       string
+	Port     uint16
+	Protocol string
+	// HTTP is set for services with a host or TLS, Scheme is "http" or "https"
+	HTTP   bool
+	Scheme string
+	Host   string
+}
+
+// Probe probes the target with a probe matching its protocol
+func Probe(t Target, timeout time.Duration) Result {
+	addr := net.JoinHostPort(t.IP, strconv.Itoa(int(t.Port)))
+	start := time.Now()
+	var r Result
+	switch {
+	case t.HTTP:
+		r = probeHTTP(t, addr, timeout)
+	case t.Protocol == "tcp":
+		r = probeTCP(addr, timeout)
+	case t.Protocol == "udp":
+		r = probeUDP(addr, timeout)
+	case t.Protocol == "sctp":
+		r = probeSCTP(t.IP, t.Port, timeout)
+	case t.Protocol == "icmp":
+		r = probeICMP(t.IP, timeout)
+	default:
+		return Result{Probe: "-", Result: Unknown, Detail: fmt.Sprintf("protocol %s is not probed", t.Protocol)}
+	}
+	if r.Result == OK {
+		r.Latency = time.Since(start)
+	}
+	return r
+}
+
+func probeTCP(addr string, timeout time.Duration) Result {
+	r := Result{Probe: "tcp connect"}
+	conn, err := net.DialTimeout("tcp", addr, timeout)
+	if err != nil {
+		r.Result, r.Detail = Fail, probeError(err)
+		return r
+	}
+	conn.Close()
+	r.Result = OK
+	return r
+}
+
+// probeUDP sends an empty datagram. A reply means the port is open and an
+// ICMP port unreachable means it is closed. No reply says nothing.
+func probeUDP(addr string, timeout time.Duration) Result {
+	r := Result{Probe: "udp"}
+	conn, err := net.DialTimeout("udp", addr, timeout)
+	if err != nil {
+		r.Result, r.Detail = Fail, probeError(err)
+		return r
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := conn.Write([]byte{}); err != nil {
+		r.Result, r.Detail = Fail, probeError(err)
+		return r
+	}
+	buf := make([]byte, 1500)
+	if _, err := conn.Read(buf); err != nil {
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			r.Result, r.Detail = Unknown, "no reply"
+			return r
+		}
+		r.Result, r.Detail = Fail, probeError(err)
+		return r
+	}
+	r.Result = OK
+	return r
+}
+
+// probeSCTP lets the kernel send an INIT and waits for the association
+func probeSCTP(ip string, port uint16, timeout time.Duration) Result {
+	r := Result{Probe: "sctp init"}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		r.Result, r.Detail = Fail, fmt.Sprintf("'%s' is not a valid IP", ip)
+		return r
+	}
+	family := unix.AF_INET6
+	var sa unix.Sockaddr
+	if ip4 := addr.To4(); ip4 != nil {
+		family = unix.AF_INET
+		sa4 := &unix.SockaddrInet4{Port: int(port)}
+		copy(sa4.Addr[:], ip4)
+		sa = sa4
+	} else {
+		sa6 := &unix.SockaddrInet6{Port: int(port)}
+		copy(sa6.Addr[:], addr.To16())
+		sa = sa6
+	}
+	fd, err := unix.Socket(family, unix.SOCK_STREAM|unix.SOCK_NONBLOCK|unix.SOCK_CLOEXEC, unix.IPPROTO_SCTP)
+	if err != nil {
+		r.Result, r.Detail = Unknown, fmt.Sprintf("sctp is not available on this host (%s)", err.Error())
+		return r
+	}
+	defer unix.Close(fd)
+
+	if err := unix.Connect(fd, sa); err != nil && err != unix.EINPROGRESS {
+		r.Result, r.Detail = Fail, err.Error()
+		return r
+	}
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLOUT}}
+	n, err := unix.Poll(fds, int(timeout.Milliseconds()))
+	if err != nil {
+		r.Result, r.Detail = Fail, err.Error()
+		return r
+	}
+	if n == 0 {
+		r.Result, r.Detail = Fail, "timeout"
+		return r
+	}
+	soErr, err := unix.GetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_ERROR)
+	if err != nil {
+		r.Result, r.Detail = Fail, err.Error()
+		return r
+	}
+	if soErr != 0 {
+		r.Result, r.Detail = Fail, syscall.Errno(soErr).Error()
+		return r
+	}
+	r.Result = OK
+	return r
+}
+
+// probeICMP sends an echo request. An unprivileged ping socket is tried first
+// and a raw socket when the host does not allow it (net.ipv4.ping_group_range).
+func probeICMP(ip string, timeout time.Duration) Result {
+	r := Result{Probe: "icmp echo"}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		r.Result, r.Detail = Fail, fmt.Sprintf("'%s' is not a valid IP", ip)
+		return r
+	}
+	family, proto := unix.AF_INET6, unix.IPPROTO_ICMPV6
+	echoType, replyType := byte(128), byte(129)
+	var sa unix.Sockaddr
+	if ip4 := addr.To4(); ip4 != nil {
+		family, proto = unix.AF_INET, unix.IPPROTO_ICMP
+		echoType, replyType = 8, 0
+		sa4 := &unix.SockaddrInet4{}
+		copy(sa4.Addr[:], ip4)
+		sa = sa4
+	} else {
+		sa6 := &unix.SockaddrInet6{}
+		copy(sa6.Addr[:], addr.To16())
+		sa = sa6
+	}
+	raw := false
+	fd, err := unix.Socket(family, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, proto)
+	if err != nil {
+		raw = true
+		fd, err = unix.Socket(family, unix.SOCK_RAW|unix.SOCK_CLOEXEC, proto)
+		if err != nil {
+			r.Result, r.Detail = Unknown, fmt.Sprintf("icmp is not permitted on this host (%s)", err.Error())
+			return r
+		}
+	}
+	defer unix.Close(fd)
+
+	// The kernel sets the id of a ping socket and the checksum of ICMPv6
+	id := uint16(os.Getpid())
+	seq := uint16(time.Now().UnixNano())
+	msg := []byte{echoType, 0, 0, 0, byte(id >> 8), byte(id), byte(seq >> 8), byte(seq), 'l', 'o', 'x', 'i'}
+	if family == unix.AF_INET {
+		sum := icmpChecksum(msg)
+		msg[2], msg[3] = byte(sum>>8), byte(sum)
+	}
+	deadline := time.Now().Add(timeout)
+	if err := unix.Sendto(fd, msg, 0, sa); err != nil {
+		r.Result, r.Detail = Fail, probeError(err)
+		return r
+	}
+	buf := make([]byte, 1500)
+	for {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			r.Result, r.Detail = Fail, "timeout"
+			return r
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		n, err := unix.Poll(fds, int(wait.Milliseconds())+1)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			r.Result, r.Detail = Fail, err.Error()
+			return r
+		}
+		if n == 0 {
+			continue
+		}
+		n, from, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			r.Result, r.Detail = Fail, probeError(err)
+			return r
+		}
+		reply := buf[:n]
+		// A raw IPv4 socket receives the IP header too
+		if raw && family == unix.AF_INET && len(reply) > 0 {
+			hlen := int(reply[0]&0x0f) * 4
+			if hlen > len(reply) {
+				continue
+			}
+			reply = reply[hlen:]
+		}
+		if len(reply) < 8 || reply[0] != replyType || !sameSockaddrIP(from, addr) {
+			continue
+		}
+		if raw && (reply[4] != msg[4] || reply[5] != msg[5]) {
+			continue
+		}
+		if reply[6] != msg[6] || reply[7] != msg[7] {
+			continue
+		}
+		r.Result = OK
+		return r
+	}
+}
+
+func icmpChecksum(b []byte) uint16 {
+	var sum uint32
+	for i := 0; i+1 < len(b); i += 2 {
+		sum += uint32(b[i])<<8 | uint32(b[i+1])
+	}
+	if len(b)%2 == 1 {
+		sum += uint32(b[len(b)-1]) << 8
+	}
+	for sum>>16 != 0 {
+		sum = sum&0xffff + sum>>16
+	}
+	return ^uint16(sum)
+}
+
+func sameSockaddrIP(sa unix.Sockaddr, ip net.IP) bool {
+	switch sa := sa.(type) {
+	case *unix.SockaddrInet4:
+		return net.IP(sa.Addr[:]).Equal(ip)
+	case *unix.SockaddrInet6:
+		return net.IP(sa.Addr[:]).Equal(ip)
+	}
+	return false
+}
+
+// probeHTTP sends a GET. Any response below 500 means the backend serves.
+func probeHTTP(t Target, addr string, timeout time.Duration) Result {
+	r := Result{Probe: t.Scheme + " get"}
+	client := http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Backends behind a load balancer rarely have a cert for their own IP
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true, ServerName: t.Host},
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	req, err := http.NewRequest(http.MethodGet, t.Scheme+"://"+addr+"/", nil)
+	if err != nil {
+		r.Result, r.Detail = Fail, err.Error()
+		return r
+	}
+	if t.Host != "" {
+		req.Host = t.Host
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		r.Result, r.Detail = Fail, probeError(err)
+		return r
+	}
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	resp.Body.Close()
+	r.Detail = resp.Status
+	if resp.StatusCode >= 500 {
+		r.Result = Fail
+		return r
+	}
+	r.Result = OK
+	return r
+}
+
+func probeError(err error) string {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return "timeout"
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return "refused"
+	}
+	if errors.Is(err, syscall.EHOSTUNREACH) || errors.Is(err, syscall.ENETUNREACH) {
+		return "unreachable"
+	}
+	return err.Error()
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright (c) 2022 NetLOX Inc
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at:
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+package probe
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const testProbeTimeout = 500 * time.Millisecond
+
+// closedAddr returns an address of the network nothing listens on
+func closedAddr(t *testing.T, network string) string {
+	t.Helper()
+	var addr string
+	if network == "tcp" {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr = ln.Addr().String()
+		ln.Close()
+	} else {
+		pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		addr = pc.LocalAddr().String()
+		pc.Close()
+	}
+	return addr
+}
+
+func splitAddr(t *testing.T, addr string) (string, uint16) {
+	t.Helper()
+	host, portStr, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := strconv.ParseUint(portStr, 10, 16)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return host, uint16(port)
+}
+
+func checkProbe(t *testing.T, r Result, result, detail string) {
+	t.Helper()
+	if r.Result != result || (detail != "" && r.Detail != detail) {
+		t.Errorf("probe %s = %s (%s), want %s (%s)", r.Probe, r.Result, r.Detail, result, detail)
+	}
+}
+
+func TestProbeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	checkProbe(t, probeTCP(ln.Addr().String(), testProbeTimeout), OK, "")
+	checkProbe(t, probeTCP(closedAddr(t, "tcp"), testProbeTimeout), Fail, "refused")
+}
+
+func TestProbeTCPTimeout(t *testing.T) {
+	// A listener that never accepts and has a backlog of 0 drops the SYNs
+	// once its queue is full, so the connect hangs
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fd)
+	if err := unix.Bind(fd, &unix.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := unix.Listen(fd, 0); err != nil {
+		t.Fatal(err)
+	}
+	sa, err := unix.Getsockname(fd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := net.JoinHostPort("127.0.0.1", strconv.Itoa(sa.(*unix.SockaddrInet4).Port))
+	for i := 0; i < 4; i++ {
+		r := probeTCP(addr, 200*time.Millisecond)
+		if r.Result == Fail {
+			checkProbe(t, r, Fail, "timeout")
+			return
+		}
+	}
+	t.Skip("the accept queue of this host did not fill up")
+}
+
+func TestProbeUDP(t *testing.T) {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pc.Close()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			pc.WriteTo(buf[:n], from)
+		}
+	}()
+	checkProbe(t, probeUDP(pc.LocalAddr().String(), testProbeTimeout), OK, "")
+	checkProbe(t, probeUDP(closedAddr(t, "udp"), testProbeTimeout), Fail, "refused")
+
+	// A socket that never replies tells nothing
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer silent.Close()
+	checkProbe(t, probeUDP(silent.LocalAddr().String(), 100*time.Millisecond), Unknown, "no reply")
+}
+
+func TestProbeHTTP(t *testing.T) {
+	var gotHost string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		gotHost = r.Host
+		switch r.Host {
+		case "down.example":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "moved.example":
+			http.Redirect(w, r, "http://other.example/", http.StatusFound)
+		case "slow.example":
+			time.Sleep(300 * time.Millisecond)
+		case "missing.example":
+			http.NotFound(w, r)
+		}
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+	tlsSrv := httptest.NewTLSServer(mux)
+	defer tlsSrv.Close()
+
+	addrOf := func(s *httptest.Server) string {
+		u, _ := url.Parse(s.URL)
+		return u.Host
+	}
+	tests := []struct {
+		name    string
+		target  Target
+		addr    string
+		timeout time.Duration
+		result  string
+		detail  string
+	}{
+		{"ok", Target{Scheme: "http", Host: "web.example"}, addrOf(srv), testProbeTimeout, OK, "200 OK"},
+		{"https without a valid cert", Target{Scheme: "https", Host: "web.example"}, addrOf(tlsSrv), testProbeTimeout, OK, "200 OK"},
+		{"4xx still serves", Target{Scheme: "http", Host: "missing.example"}, addrOf(srv), testProbeTimeout, OK, "404 Not Found"},
+		{"redirect is not followed", Target{Scheme: "http", Host: "moved.example"}, addrOf(srv), testProbeTimeout, OK, "302 Found"},
+		{"5xx", Target{Scheme: "http", Host: "down.example"}, addrOf(srv), testProbeTimeout, Fail, "503 Service Unavailable"},
+		{"timeout", Target{Scheme: "http", Host: "slow.example"}, addrOf(srv), 100 * time.Millisecond, Fail, "timeout"},
+		{"refused", Target{Scheme: "http"}, closedAddr(t, "tcp"), testProbeTimeout, Fail, "refused"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotHost = ""
+			r := probeHTTP(tt.target, tt.addr, tt.timeout)
+			checkProbe(t, r, tt.result, tt.detail)
+			if r.Probe != tt.target.Scheme+" get" {
+				t.Errorf("probe = %s", r.Probe)
+			}
+			if tt.target.Host != "" && tt.result == OK && gotHost != tt.target.Host {
+				t.Errorf("server got host %q, want %q", gotHost, tt.target.Host)
+			}
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	ip, port := splitAddr(t, ln.Addr().String())
+
+	r := Probe(Target{IP: ip, Port: port, Protocol: "tcp"}, testProbeTimeout)
+	checkProbe(t, r, OK, "")
+	if r.Probe != "tcp connect" || r.Latency <= 0 {
+		t.Errorf("probe %s latency %v", r.Probe, r.Latency)
+	}
+
+	_, closedPort := splitAddr(t, closedAddr(t, "tcp"))
+	r = Probe(Target{IP: ip, Port: closedPort, Protocol: "tcp"}, testProbeTimeout)
+	checkProbe(t, r, Fail, "refused")
+	if r.Latency != 0 {
+		t.Errorf("failed probe has latency %v", r.Latency)
+	}
+
+	checkProbe(t, Probe(Target{IP: ip, Port: port, Protocol: "gre"}, testProbeTimeout), Unknown, "protocol gre is not probed")
+	checkProbe(t, Probe(Target{IP: "10.0.0", Protocol: "icmp"}, testProbeTimeout), Fail, "'10.0.0' is not a valid IP")
+}